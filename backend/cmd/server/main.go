@@ -1,34 +1,85 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Embed the zone database so IANA time zones resolve in minimal containers
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/netpo4ki/event-poster/internal/controllers"
 	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/jobs"
+	"github.com/netpo4ki/event-poster/internal/logging"
+	"github.com/netpo4ki/event-poster/internal/metrics"
 	"github.com/netpo4ki/event-poster/internal/middleware"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/notifications"
+	"github.com/netpo4ki/event-poster/internal/realtime"
 	"github.com/netpo4ki/event-poster/internal/services"
+	"github.com/netpo4ki/event-poster/internal/tracing"
 )
 
+// jobRunRetention is how long the runs of background jobs and the delivery log of webhooks are kept
+const jobRunRetention = 30 * 24 * time.Hour
+
 func main() {
+	// Log structured lines; the standard log package goes through the same logger
+	logConfig, err := logging.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	logger := logging.New(os.Stderr, logConfig)
+	slog.SetDefault(logger)
+	services.SetLogger(logger)
+	controllers.SetLogger(logger)
+
+	// Trace requests through the services down to the database
+	traceConfig, err := tracing.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid tracing configuration: %v", err)
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), traceConfig)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
 	// Initialize database
 	database.InitDB()
 	defer database.CloseDB()
+	if err := metrics.RegisterDB(database.DB, "main"); err != nil {
+		log.Fatalf("Failed to export database metrics: %v", err)
+	}
+
+	// Make the users listed in ADMIN_USERS admins
+	if err := services.NewUserService().GrantAdminRoles(context.Background()); err != nil {
+		log.Fatalf("Failed to grant admin roles: %v", err)
+	}
+
+	// Deliver notifications from background workers
+	notificationQueue := notifications.NewQueue(notifications.NewSenderFromEnv(), notifications.DefaultQueueConfig)
+	notifications.SetDefault(notificationQueue)
+
+	// Push live updates, e.g. seat availability, to connected clients
+	liveHub := realtime.NewHub(realtime.DefaultHubConfig)
+	realtime.SetDefault(liveHub)
 
 	// Create router
-	router := gin.Default()
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.Tracing(), middleware.RequestLogger(logger), middleware.Metrics(), gin.Recovery())
 
 	// Configure CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.RequestIDHeader, "traceparent", "tracestate"},
+		ExposeHeaders:    []string{middleware.RequestIDHeader},
 		AllowCredentials: true,
 	}))
 
@@ -37,6 +88,9 @@ func main() {
 		c.String(http.StatusOK, "OK")
 	})
 
+	// Prometheus scrapes metrics from here
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// Set up API routes
 	api := router.Group("/api")
 
@@ -44,7 +98,15 @@ func main() {
 	api.POST("/register", controllers.Register)
 	api.POST("/login", controllers.Login)
 	api.GET("/events", controllers.GetEvents)
-	api.GET("/events/:id", controllers.GetEvent)
+	api.GET("/events/stream", middleware.OptionalJWTAuth(), controllers.StreamEvents)
+	api.GET("/events/:id", middleware.OptionalJWTAuth(), controllers.GetEvent)
+	api.GET("/events/:id/stream", middleware.OptionalJWTAuth(), controllers.StreamEvent)
+	api.GET("/events/:id/ticket-types", middleware.OptionalJWTAuth(), controllers.GetTicketTypes)
+	api.GET("/events/:id/questions", middleware.OptionalJWTAuth(), controllers.GetEventQuestions)
+	api.GET("/series/:id", controllers.GetSeries)
+	api.GET("/venues", controllers.GetVenues)
+	api.GET("/venues/:id", controllers.GetVenue)
+	api.GET("/check-in/manifest-key", controllers.GetManifestKey)
 
 	// Routes that require authentication
 	authRoutes := api.Group("/")
@@ -53,34 +115,106 @@ func main() {
 		// User routes
 		authRoutes.GET("/me", controllers.GetCurrentUser)
 		authRoutes.GET("/my-events", controllers.GetMyEvents)
+		authRoutes.GET("/my-events/feedback", controllers.GetMyEventsFeedback)
 		authRoutes.GET("/my-registrations", controllers.GetMyRegistrations)
+		authRoutes.GET("/my-attendance", controllers.GetMyAttendance)
 
 		// Event routes
 		authRoutes.POST("/events", controllers.CreateEvent)
 		authRoutes.PUT("/events/:id", controllers.UpdateEvent)
 		authRoutes.DELETE("/events/:id", controllers.DeleteEvent)
+		authRoutes.POST("/events/:id/status", controllers.ChangeEventStatus)
+		authRoutes.PUT("/events/:id/questions", controllers.SetEventQuestions)
+		authRoutes.GET("/events/:id/attendees", controllers.GetEventAttendees)
+		authRoutes.GET("/events/:id/attendees/export", controllers.ExportEventAttendees)
+		authRoutes.POST("/events/:id/check-in", controllers.CheckInAttendee)
+		authRoutes.GET("/events/:id/attendance", controllers.GetEventAttendance)
+		authRoutes.GET("/events/:id/feedback", controllers.GetEventFeedback)
+		authRoutes.GET("/events/:id/check-in/stats", controllers.GetCheckInStats)
+		authRoutes.GET("/events/:id/check-in/manifest", controllers.GetCheckInManifest)
+		authRoutes.POST("/events/:id/check-in/sync", controllers.SyncCheckIns)
+
+		// Ticket type routes
+		authRoutes.POST("/events/:id/ticket-types", controllers.CreateTicketType)
+		authRoutes.PUT("/ticket-types/:id", controllers.UpdateTicketType)
+		authRoutes.DELETE("/ticket-types/:id", controllers.DeleteTicketType)
+
+		// Venue routes
+		authRoutes.POST("/venues", controllers.CreateVenue)
+		authRoutes.PUT("/venues/:id", controllers.UpdateVenue)
+		authRoutes.DELETE("/venues/:id", controllers.DeleteVenue)
+
+		// Event series routes
+		authRoutes.POST("/series", controllers.CreateSeries)
+		authRoutes.DELETE("/series/:id", controllers.CancelSeries)
 
 		// Registration routes
 		authRoutes.GET("/registrations", controllers.GetRegistrations)
 		authRoutes.GET("/registrations/:id", controllers.GetRegistration)
 		authRoutes.POST("/registrations", controllers.CreateRegistration)
 		authRoutes.PUT("/registrations/:id", controllers.UpdateRegistration)
+		authRoutes.PUT("/registrations/:id/guests", controllers.UpdateRegistrationGuests)
+		authRoutes.GET("/registrations/:id/ticket", controllers.GetRegistrationTicket)
+		authRoutes.POST("/registrations/:id/feedback", controllers.SubmitFeedback)
+		authRoutes.POST("/registrations/:id/approve", controllers.ApproveRegistration)
+		authRoutes.POST("/registrations/:id/reject", controllers.RejectRegistration)
 		authRoutes.DELETE("/registrations/:id", controllers.DeleteRegistration)
+
+		// Webhook routes
+		authRoutes.POST("/webhooks", controllers.CreateWebhook)
+		authRoutes.GET("/webhooks", controllers.GetUserWebhooks)
+		authRoutes.DELETE("/webhooks/:id", controllers.DeleteWebhook)
+		authRoutes.GET("/webhooks/:id/deliveries", controllers.GetWebhookDeliveries)
+		authRoutes.POST("/webhooks/:id/test", controllers.SendTestWebhook)
+		authRoutes.POST("/webhooks/:id/deliveries/:deliveryId/retry", controllers.RetryWebhookDelivery)
+
+		// Notification inbox routes
+		authRoutes.GET("/notifications", controllers.GetNotifications)
+		authRoutes.POST("/notifications/read-all", controllers.MarkAllNotificationsRead)
+		authRoutes.POST("/notifications/:id/read", controllers.MarkNotificationRead)
+		authRoutes.POST("/notifications/:id/unread", controllers.MarkNotificationUnread)
+		authRoutes.GET("/notifications/preferences", controllers.GetNotificationPreferences)
+		authRoutes.PUT("/notifications/preferences", controllers.UpdateNotificationPreferences)
 	}
 
-	// Set up periodic task to clean up expired events
-	go func() {
-		for {
-			eventService := services.NewEventService()
-			err := eventService.DeleteExpiredEvents()
-			if err != nil {
-				log.Printf("Error deleting expired events: %v", err)
-			}
-
-			// Wait for 1 hour before next check
-			time.Sleep(1 * time.Hour)
+	// Organizer dashboards; browsers can't send headers with WebSockets, so
+	// the token may be given as access_token
+	api.GET("/dashboard/ws", middleware.WebSocketAuthMiddleware(), controllers.DashboardSocket)
+
+	// Routes for administrators
+	adminRoutes := api.Group("/admin")
+	adminRoutes.Use(middleware.JWTAuthMiddleware(), middleware.RequireRole(models.RoleAdmin))
+	{
+		adminRoutes.GET("/jobs", controllers.GetJobs)
+		adminRoutes.GET("/jobs/runs", controllers.GetJobRuns)
+	}
+
+	// Run scheduled and recurring jobs in the background
+	eventService := services.NewEventService()
+	webhookService := services.NewWebhookService()
+	worker := jobs.NewWorker(jobs.DefaultWorkerConfig)
+	worker.Handle(services.JobEventReminder, services.NewReminderService().SendReminder)
+	recurringJobs := []struct {
+		name, schedule string
+		run            jobs.TaskFunc
+	}{
+		{"publish-scheduled-events", "@hourly", eventService.PublishScheduledEvents},
+		{"complete-expired-events", "@hourly", eventService.CompleteExpiredEvents},
+		{"prune-job-runs", "@daily", func(ctx context.Context) error {
+			_, err := jobs.PruneRuns(time.Now().Add(-jobRunRetention))
+			return err
+		}},
+		{"dispatch-webhooks", "* * * * *", webhookService.DispatchWebhooks},
+		{"prune-webhook-history", "@daily", func(ctx context.Context) error {
+			return webhookService.PruneWebhookHistory(ctx, time.Now().Add(-jobRunRetention))
+		}},
+	}
+	for _, job := range recurringJobs {
+		if err := worker.Cron(job.name, job.schedule, job.run); err != nil {
+			log.Fatalf("Failed to schedule %s: %v", job.name, err)
 		}
-	}()
+	}
+	worker.Start()
 
 	// Determine port
 	port := os.Getenv("PORT")
@@ -95,7 +229,7 @@ func main() {
 	}
 
 	go func() {
-		log.Printf("Server starting on port %s", port)
+		logger.Info("Server starting", "port", port)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
@@ -106,7 +240,17 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	logger.Info("Shutting down server")
+	liveHub.Close()
+	worker.Close()
+	notificationQueue.Close()
 	database.CloseDB()
-	log.Println("Server stopped")
+
+	// Send the spans still buffered
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("Failed to flush traces", "error", err)
+	}
+	logger.Info("Server stopped")
 }
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetEventAttendance returns registered, checked-in and no-show counts of an event to its creator
+func GetEventAttendance(c *gin.Context) {
+	id := c.Param("id")
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := eventService.GetEventByID(c.Request.Context(), eventID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get event"})
+		}
+		return
+	}
+
+	if !isEventCreator(c, event) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you don't have permission to view the attendance of this event"})
+		return
+	}
+
+	attendance, err := eventService.GetAttendanceForEvent(c.Request.Context(), eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get attendance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attendance)
+}
+
+// GetMyAttendance returns the attendance history of the current user
+func GetMyAttendance(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	attendance, err := eventService.GetUserAttendance(c.Request.Context(), userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get attendance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attendance)
+}
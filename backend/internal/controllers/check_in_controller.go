@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/services"
+	"github.com/netpo4ki/event-poster/internal/tickets"
+)
+
+var checkInService = services.NewCheckInService()
+
+// GetRegistrationTicket returns the ticket of a registration as a QR code.
+// The format query parameter picks png (default), svg or json with the raw code.
+func GetRegistrationTicket(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	registrationID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	code, err := checkInService.GetTicketCode(c.Request.Context(), registrationID, userID.(int64))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		} else {
+			respondWithError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	switch c.DefaultQuery("format", "png") {
+	case "png":
+		image, err := tickets.QRPNG(code, tickets.DefaultQRSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render ticket"})
+			return
+		}
+		c.Data(http.StatusOK, "image/png", image)
+	case "svg":
+		image, err := tickets.QRSVG(code)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render ticket"})
+			return
+		}
+		c.Data(http.StatusOK, "image/svg+xml", image)
+	case "json":
+		c.JSON(http.StatusOK, gin.H{"registration_id": registrationID, "code": code})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be one of png, svg or json"})
+	}
+}
+
+// CheckInAttendee checks in the ticket scanned at the door of an event.
+// Duplicate scans are answered with 409 and the time of the first check-in.
+func CheckInAttendee(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req models.CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	checkIn, err := checkInService.CheckIn(c.Request.Context(), eventID, &req, userID.(int64))
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		case err == services.ErrAlreadyCheckedIn:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": services.ErrAlreadyCheckedIn.Code, "check_in": checkIn})
+		default:
+			respondWithError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, checkIn)
+}
+
+// GetCheckInStats returns how many ticket holders of an event have checked in
+func GetCheckInStats(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	stats, err := checkInService.GetCheckInStats(c.Request.Context(), eventID, userID.(int64))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		} else {
+			respondWithError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetCheckInManifest returns a signed snapshot of the valid tickets of an event for scanning offline
+func GetCheckInManifest(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	manifest, err := checkInService.GetManifest(c.Request.Context(), eventID, userID.(int64))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		} else {
+			respondWithError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, manifest)
+}
+
+// GetManifestKey returns the public key that check-in manifests are signed
+// with, for devices to verify manifests while offline
+func GetManifestKey(c *gin.Context) {
+	c.JSON(http.StatusOK, models.ManifestKey{
+		Algorithm: tickets.ManifestAlgorithm,
+		PublicKey: base64.RawURLEncoding.EncodeToString(tickets.ManifestPublicKey()),
+	})
+}
+
+// SyncCheckIns uploads the check-ins a device recorded offline and returns how each was reconciled
+func SyncCheckIns(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req models.CheckInSyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := checkInService.SyncCheckIns(c.Request.Context(), eventID, &req, userID.(int64))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		} else {
+			respondWithError(c, err, http.StatusBadRequest)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
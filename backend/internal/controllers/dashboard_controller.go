@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/netpo4ki/event-poster/internal/middleware"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/realtime"
+)
+
+const (
+	// dashboardWriteWait is how long a write to a dashboard may take
+	dashboardWriteWait = 10 * time.Second
+	// dashboardPongWait is how long a dashboard may stay silent before it is considered gone
+	dashboardPongWait = 60 * time.Second
+	// dashboardPingPeriod is how often dashboards are pinged; it must be less than dashboardPongWait
+	dashboardPingPeriod = dashboardPongWait * 9 / 10
+	// dashboardMaxCommandSize caps the size of commands sent by dashboards
+	dashboardMaxCommandSize = 4096
+)
+
+// The API is used from other origins (see the CORS setup) and authenticates
+// with tokens rather than cookies, so any origin may connect
+var dashboardUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// DashboardSocket upgrades to a WebSocket that pushes registrations,
+// cancellations, check-ins and feedback of the events the organizer
+// subscribes to with {"type": "subscribe", "event_ids": [...]}
+func DashboardSocket(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	broadcaster := realtime.Default()
+	if broadcaster == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Live updates are not available"})
+		return
+	}
+
+	conn, err := dashboardUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already answered the request
+		logger.WarnContext(c.Request.Context(), "Failed to upgrade dashboard connection", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	session := &dashboardSession{
+		ctx:     c.Request.Context(),
+		conn:    conn,
+		userID:  userID.(int64),
+		watched: make(map[int64]bool),
+	}
+
+	sub, _, err := broadcaster.Subscribe(realtime.Filter{UserID: session.userID, Names: models.DashboardTopics}, 0)
+	if err != nil {
+		session.close(websocket.CloseTryAgainLater, "live updates are not available")
+		return
+	}
+	defer sub.Close()
+
+	// The connection ends when the token it was opened with expires
+	var expired <-chan time.Time
+	if claims, ok := c.Get("claims"); ok && claims.(*middleware.Claims).ExpiresAt != 0 {
+		timer := time.NewTimer(time.Until(time.Unix(claims.(*middleware.Claims).ExpiresAt, 0)))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	commands := make(chan models.DashboardCommand)
+	readDone := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+	go session.read(commands, readDone, stop)
+
+	ping := time.NewTicker(dashboardPingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-readDone:
+			return
+		case cmd := <-commands:
+			if err := session.handle(cmd); err != nil {
+				return
+			}
+		case msg, open := <-sub.Messages():
+			if !open {
+				if sub.Err() == realtime.ErrLagging {
+					// The client should reconnect and subscribe again
+					session.close(websocket.CloseTryAgainLater, "too slow to keep up with the updates")
+				} else {
+					session.close(websocket.CloseGoingAway, "server is shutting down")
+				}
+				return
+			}
+			if !session.watched[msg.EventID] {
+				continue
+			}
+			err := session.send(&models.DashboardMessage{Type: msg.Name, EventID: msg.EventID, Data: msg.Data})
+			if err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(dashboardWriteWait)); err != nil {
+				return
+			}
+		case <-expired:
+			session.close(websocket.ClosePolicyViolation, "token expired")
+			return
+		}
+	}
+}
+
+// dashboardSession is an open dashboard connection. Only the goroutine of
+// DashboardSocket writes to it; read runs in its own goroutine.
+type dashboardSession struct {
+	ctx     context.Context // Of the request that opened the connection
+	conn    *websocket.Conn
+	userID  int64
+	watched map[int64]bool
+}
+
+// read passes the commands of the client on until the connection fails or stop is closed
+func (s *dashboardSession) read(commands chan<- models.DashboardCommand, done chan<- struct{}, stop <-chan struct{}) {
+	defer close(done)
+
+	s.conn.SetReadLimit(dashboardMaxCommandSize)
+	s.conn.SetReadDeadline(time.Now().Add(dashboardPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(dashboardPongWait))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var cmd models.DashboardCommand
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			cmd = models.DashboardCommand{}
+		}
+		select {
+		case commands <- cmd:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// handle carries out a command, answering with the events now watched or an error
+func (s *dashboardSession) handle(cmd models.DashboardCommand) error {
+	switch cmd.Type {
+	case models.DashboardSubscribe:
+		if len(s.watched)+len(cmd.EventIDs) > models.MaxStreamEvents {
+			return s.sendError("too many events to watch")
+		}
+		for _, id := range cmd.EventIDs {
+			if msg := s.checkOrganizer(id); msg != "" {
+				return s.sendError(msg)
+			}
+		}
+		for _, id := range cmd.EventIDs {
+			s.watched[id] = true
+		}
+	case models.DashboardUnsubscribe:
+		for _, id := range cmd.EventIDs {
+			delete(s.watched, id)
+		}
+	default:
+		return s.sendError("unknown command, expected subscribe or unsubscribe")
+	}
+
+	watched := make([]int64, 0, len(s.watched))
+	for id := range s.watched {
+		watched = append(watched, id)
+	}
+	sort.Slice(watched, func(i, j int) bool { return watched[i] < watched[j] })
+	return s.send(&models.DashboardMessage{Type: models.DashboardSubscribed, EventIDs: watched})
+}
+
+// checkOrganizer explains why the user can't watch an event, or returns "" if they can
+func (s *dashboardSession) checkOrganizer(eventID int64) string {
+	event, err := eventService.GetEventByID(s.ctx, eventID)
+	if err == sql.ErrNoRows {
+		return fmt.Sprintf("event %d not found", eventID)
+	}
+	if err != nil {
+		return fmt.Sprintf("failed to get event %d", eventID)
+	}
+	// Check if the user has permission to watch this event
+	if event.CreatorID != s.userID {
+		return fmt.Sprintf("you don't have permission to watch event %d", eventID)
+	}
+	return ""
+}
+
+func (s *dashboardSession) sendError(message string) error {
+	return s.send(&models.DashboardMessage{Type: models.DashboardError, Error: message})
+}
+
+func (s *dashboardSession) send(msg *models.DashboardMessage) error {
+	msg.SentAt = time.Now().UTC()
+	s.conn.SetWriteDeadline(time.Now().Add(dashboardWriteWait))
+	return s.conn.WriteJSON(msg)
+}
+
+// close tells the client why the connection ends
+func (s *dashboardSession) close(code int, reason string) {
+	message := websocket.FormatCloseMessage(code, reason)
+	s.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(dashboardWriteWait))
+}
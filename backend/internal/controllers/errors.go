@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netpo4ki/event-poster/internal/services"
+)
+
+// serviceErrorStatus maps the codes of business rule violations to HTTP status codes
+var serviceErrorStatus = map[string]int{
+	services.ErrEventNotFound.Code:                 http.StatusNotFound,
+	services.ErrVenueNotFound.Code:                 http.StatusNotFound,
+	services.ErrVenueCapacityExceeded.Code:         http.StatusBadRequest,
+	services.ErrVenueConflict.Code:                 http.StatusConflict,
+	services.ErrVenueInUse.Code:                    http.StatusConflict,
+	services.ErrEventNotEditable.Code:              http.StatusConflict,
+	services.ErrInvalidStatusTransition.Code:       http.StatusConflict,
+	services.ErrEventEnded.Code:                    http.StatusBadRequest,
+	services.ErrEventFullyBooked.Code:              http.StatusBadRequest,
+	services.ErrAlreadyRegistered.Code:             http.StatusBadRequest,
+	services.ErrEventNotEnded.Code:                 http.StatusConflict,
+	services.ErrEventNotOpen.Code:                  http.StatusBadRequest,
+	services.ErrTicketTypeNotFound.Code:            http.StatusNotFound,
+	services.ErrTicketTypeRequired.Code:            http.StatusBadRequest,
+	services.ErrTicketSalesClosed.Code:             http.StatusBadRequest,
+	services.ErrTicketTypeSoldOut.Code:             http.StatusBadRequest,
+	services.ErrTicketCapacityExceeded.Code:        http.StatusBadRequest,
+	services.ErrTicketTypeInUse.Code:               http.StatusConflict,
+	services.ErrTooManyGuests.Code:                 http.StatusBadRequest,
+	services.ErrNotEnoughSeats.Code:                http.StatusBadRequest,
+	services.ErrInvalidRegistrationTransition.Code: http.StatusConflict,
+	services.ErrRegistrationNotYetOpen.Code:        http.StatusBadRequest,
+	services.ErrRegistrationClosed.Code:            http.StatusBadRequest,
+	services.ErrCancellationDeadlinePassed.Code:    http.StatusForbidden,
+	services.ErrTicketNotIssued.Code:               http.StatusConflict,
+	services.ErrInvalidTicket.Code:                 http.StatusBadRequest,
+	services.ErrTicketWrongEvent.Code:              http.StatusBadRequest,
+	services.ErrTicketRevoked.Code:                 http.StatusConflict,
+	services.ErrAlreadyCheckedIn.Code:              http.StatusConflict,
+	services.ErrRegistrationCheckedIn.Code:         http.StatusConflict,
+	services.ErrTooManyNoShows.Code:                http.StatusForbidden,
+	services.ErrFeedbackNotOpen.Code:               http.StatusBadRequest,
+	services.ErrFeedbackClosed.Code:                http.StatusBadRequest,
+	services.ErrFeedbackNotAllowed.Code:            http.StatusForbidden,
+	services.ErrFeedbackExists.Code:                http.StatusConflict,
+	services.ErrNotSeriesCreator.Code:              http.StatusForbidden,
+	services.ErrSeriesCancelled.Code:               http.StatusConflict,
+	services.ErrDeliveryNotDead.Code:               http.StatusConflict,
+	services.CodeForbidden:                         http.StatusForbidden,
+	services.CodeInvalidAnswer:                     http.StatusBadRequest,
+	services.CodeInvalidSeries:                     http.StatusBadRequest,
+}
+
+// respondWithError writes err as a JSON error. Business rule violations carry
+// their code and matching status, anything else is reported with fallbackStatus.
+func respondWithError(c *gin.Context, err error, fallbackStatus int) {
+	var serviceErr *services.Error
+	if errors.As(err, &serviceErr) {
+		status, ok := serviceErrorStatus[serviceErr.Code]
+		if !ok {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": serviceErr.Message, "code": serviceErr.Code})
+		return
+	}
+
+	c.JSON(fallbackStatus, gin.H{"error": err.Error()})
+}
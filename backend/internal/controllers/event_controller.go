@@ -2,20 +2,52 @@ package controllers
 
 import (
 	"database/sql"
-	"log"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/netpo4ki/event-poster/internal/geo"
 	"github.com/netpo4ki/event-poster/internal/models"
 	"github.com/netpo4ki/event-poster/internal/services"
 )
 
 var eventService = services.NewEventService()
 
-// GetEvents returns all events
+// GetEvents returns all events, optionally only those happening now (?happening=now)
+// or those near a point (?near=lat,lng&radius_km=10), nearest first
 func GetEvents(c *gin.Context) {
-	events, err := eventService.GetAllEvents()
+	var filter models.EventFilter
+	switch c.Query("happening") {
+	case "":
+	case "now":
+		filter.HappeningNow = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "happening filter must be now"})
+		return
+	}
+
+	if near := c.Query("near"); near != "" {
+		point, err := parsePoint(near)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.Near = point
+		filter.RadiusKm = models.DefaultSearchRadiusKm
+
+		if radius := c.Query("radius_km"); radius != "" {
+			filter.RadiusKm, err = strconv.ParseFloat(radius, 64)
+			if err != nil || filter.RadiusKm <= 0 || filter.RadiusKm > models.MaxSearchRadiusKm {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "radius_km must be a number between 0 and 500"})
+				return
+			}
+		}
+	}
+
+	events, err := eventService.GetAllEvents(c.Request.Context(), filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get events"})
 		return
@@ -25,35 +57,92 @@ func GetEvents(c *gin.Context) {
 	var response []gin.H
 	for _, event := range events {
 		// Get registration count for the event
-		registrationsCount, err := eventService.GetRegistrationsCountForEvent(event.ID)
+		registrationsCount, err := eventService.GetRegistrationsCountForEvent(c.Request.Context(), event.ID)
 		if err != nil {
-			log.Printf("Failed to get registrations count for event ID %d: %v", event.ID, err)
+			logger.ErrorContext(c.Request.Context(), "Failed to get registrations count", "event_id", event.ID, "error", err)
 			// Continue with other events if one fails
 			continue
 		}
 
-		// Calculate available seats
-		availableSeats := event.Seats - registrationsCount
-
 		// Add event with available seats to response
-		response = append(response, gin.H{
-			"id":              event.ID,
-			"title":           event.Title,
-			"description":     event.Description,
-			"location":        event.Location,
-			"event_type":      event.EventType,
-			"event_date":      event.EventDate,
-			"seats":           event.Seats,
-			"created_at":      event.CreatedAt,
-			"creator_id":      event.CreatorID,
-			"available_seats": availableSeats,
-			"registrations":   registrationsCount,
-		})
+		response = append(response, eventResponse(&event, registrationsCount))
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// parsePoint parses coordinates given as "lat,lng"
+func parsePoint(value string) (*geo.Point, error) {
+	invalid := errors.New("near must be given as latitude,longitude")
+
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return nil, invalid
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return nil, invalid
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return nil, invalid
+	}
+
+	point := geo.Point{Lat: lat, Lng: lng}
+	if !point.Valid() {
+		return nil, errors.New("near is out of the valid latitude and longitude range")
+	}
+	return &point, nil
+}
+
+// eventResponse builds the JSON representation of an event with its seat availability.
+// event_date and end_date are UTC instants, the local_ variants are wall time in the event's time zone.
+func eventResponse(event *models.Event, registrationsCount int) gin.H {
+	now := time.Now().UTC()
+	response := gin.H{
+		"id":                          event.ID,
+		"title":                       event.Title,
+		"description":                 event.Description,
+		"location":                    event.Location,
+		"event_type":                  event.EventType,
+		"event_date":                  event.EventDate,
+		"local_event_date":            event.LocalEventDate(),
+		"end_date":                    event.EndDate,
+		"local_end_date":              event.LocalEndDate(),
+		"multi_day":                   event.IsMultiDay(),
+		"timezone":                    event.Timezone,
+		"seats":                       event.Seats,
+		"max_guests_per_registration": event.MaxGuests,
+		"max_no_shows":                event.MaxNoShows,
+		"reminder_offsets":            event.ReminderOffsets,
+		"requires_approval":           event.RequiresApproval,
+		"reserve_pending_seats":       event.ReservePendingSeats,
+		"registration_opens_at":       event.RegistrationOpensAt,
+		"registration_closes_at":      event.RegistrationClosesAt,
+		"cancellation_deadline":       event.CancellationDeadline,
+		"registration_state":          event.RegistrationStateAt(now),
+		"can_cancel":                  event.CanCancelAt(now),
+		"server_time":                 now, // Lets clients count down to the window without trusting their own clock
+		"created_at":                  event.CreatedAt,
+		"creator_id":                  event.CreatorID,
+		"venue_id":                    event.VenueID,
+		"series_id":                   event.SeriesID,
+		"status":                      event.Status,
+		"status_reason":               event.StatusReason,
+		"publish_at":                  event.PublishAt,
+		"latitude":                    event.Latitude,
+		"longitude":                   event.Longitude,
+		"average_rating":              event.AverageRating,
+		"ratings_count":               event.RatingsCount,
+		"available_seats":             event.AvailableSeats(registrationsCount),
+		"registrations":               registrationsCount,
+	}
+	if event.DistanceKm != nil {
+		response["distance_km"] = *event.DistanceKm
+	}
+	return response
+}
+
 // GetMyEvents returns events created by the current user
 func GetMyEvents(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -62,7 +151,7 @@ func GetMyEvents(c *gin.Context) {
 		return
 	}
 
-	events, err := eventService.GetEventsByUser(userID.(int64))
+	events, err := eventService.GetEventsByUser(c.Request.Context(), userID.(int64))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get events"})
 		return
@@ -72,30 +161,15 @@ func GetMyEvents(c *gin.Context) {
 	var response []gin.H
 	for _, event := range events {
 		// Get registration count for the event
-		registrationsCount, err := eventService.GetRegistrationsCountForEvent(event.ID)
+		registrationsCount, err := eventService.GetRegistrationsCountForEvent(c.Request.Context(), event.ID)
 		if err != nil {
-			log.Printf("Failed to get registrations count for event ID %d: %v", event.ID, err)
+			logger.ErrorContext(c.Request.Context(), "Failed to get registrations count", "event_id", event.ID, "error", err)
 			// Continue with other events if one fails
 			continue
 		}
 
-		// Calculate available seats
-		availableSeats := event.Seats - registrationsCount
-
 		// Add event with available seats to response
-		response = append(response, gin.H{
-			"id":              event.ID,
-			"title":           event.Title,
-			"description":     event.Description,
-			"location":        event.Location,
-			"event_type":      event.EventType,
-			"event_date":      event.EventDate,
-			"seats":           event.Seats,
-			"created_at":      event.CreatedAt,
-			"creator_id":      event.CreatorID,
-			"available_seats": availableSeats,
-			"registrations":   registrationsCount,
-		})
+		response = append(response, eventResponse(&event, registrationsCount))
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -110,7 +184,7 @@ func GetEvent(c *gin.Context) {
 		return
 	}
 
-	event, err := eventService.GetEventByID(eventID)
+	event, err := eventService.GetEventByID(c.Request.Context(), eventID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
@@ -120,30 +194,38 @@ func GetEvent(c *gin.Context) {
 		return
 	}
 
+	// Drafts are only visible to their creator
+	isCreator := isEventCreator(c, event)
+	if !event.IsPubliclyVisible() && !isCreator {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
 	// Get registration count for the event
-	registrationsCount, err := eventService.GetRegistrationsCountForEvent(eventID)
+	registrationsCount, err := eventService.GetRegistrationsCountForEvent(c.Request.Context(), eventID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get registrations count"})
 		return
 	}
 
-	// Calculate available seats
-	availableSeats := event.Seats - registrationsCount
+	// Hidden ticket types are only listed to the creator
+	ticketTypes, err := ticketTypeService.GetTicketTypes(c.Request.Context(), eventID, isCreator)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get ticket types"})
+		return
+	}
 
 	// Return event with available seats
-	c.JSON(http.StatusOK, gin.H{
-		"id":              event.ID,
-		"title":           event.Title,
-		"description":     event.Description,
-		"location":        event.Location,
-		"event_type":      event.EventType,
-		"event_date":      event.EventDate,
-		"seats":           event.Seats,
-		"created_at":      event.CreatedAt,
-		"creator_id":      event.CreatorID,
-		"available_seats": availableSeats,
-		"registrations":   registrationsCount,
-	})
+	response := eventResponse(event, registrationsCount)
+	response["ticket_types"] = ticketTypes
+	c.JSON(http.StatusOK, response)
+}
+
+// isEventCreator reports whether the optionally authenticated user created the event
+func isEventCreator(c *gin.Context, event *models.Event) bool {
+	userID, _ := c.Get("user_id")
+	id, ok := userID.(int64)
+	return ok && id == event.CreatorID
 }
 
 // CreateEvent creates a new event
@@ -161,13 +243,48 @@ func CreateEvent(c *gin.Context) {
 		return
 	}
 
-	id, err := eventService.CreateEvent(&req, userID.(int64))
+	id, err := eventService.CreateEvent(c.Request.Context(), &req, userID.(int64))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err == services.ErrVenueConflict {
+			respondWithVenueConflict(c, &req)
+		} else {
+			respondWithError(c, err, http.StatusInternalServerError)
+		}
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"id": id})
+	response := gin.H{"id": id}
+	if req.AllowVenueConflict {
+		// The overlap was accepted, but the organizer should still know about it
+		if conflicts := venueConflicts(c, &req, id); len(conflicts) > 0 {
+			response["warnings"] = gin.H{"venue_conflicts": conflicts}
+		}
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// venueConflicts lists the events at the requested venue that overlap the request
+func venueConflicts(c *gin.Context, req *models.EventRequest, exclude ...int64) []models.Event {
+	if req.VenueID == nil || req.EndDate == nil {
+		return nil
+	}
+
+	conflicts, err := venueService.FindConflicts(c.Request.Context(), *req.VenueID, req.EventDate, *req.EndDate, exclude)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Failed to find venue conflicts", "venue_id", *req.VenueID, "error", err)
+		return nil
+	}
+	return conflicts
+}
+
+// respondWithVenueConflict rejects a request that overlaps other events at its venue
+func respondWithVenueConflict(c *gin.Context, req *models.EventRequest, exclude ...int64) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":     services.ErrVenueConflict.Message,
+		"code":      services.ErrVenueConflict.Code,
+		"conflicts": venueConflicts(c, req, exclude...),
+	})
 }
 
 // UpdateEvent updates an existing event
@@ -186,18 +303,27 @@ func UpdateEvent(c *gin.Context) {
 		return
 	}
 
+	// Occurrences of a series can be edited individually or together with all later ones
+	scope, err := models.ParseUpdateScope(c.Query("scope"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var req models.EventRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err = eventService.UpdateEvent(eventID, &req, userID.(int64))
+	err = eventService.UpdateEvent(c.Request.Context(), eventID, &req, userID.(int64), scope)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		} else if err == services.ErrVenueConflict {
+			respondWithVenueConflict(c, &req, eventID)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondWithError(c, err, http.StatusInternalServerError)
 		}
 		return
 	}
@@ -221,7 +347,7 @@ func DeleteEvent(c *gin.Context) {
 		return
 	}
 
-	err = eventService.DeleteEvent(eventID, userID.(int64))
+	err = eventService.DeleteEvent(c.Request.Context(), eventID, userID.(int64))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
@@ -233,3 +359,38 @@ func DeleteEvent(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Event deleted successfully"})
 }
+
+// ChangeEventStatus moves an event to another lifecycle status
+func ChangeEventStatus(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req models.StatusChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = eventService.ChangeStatus(c.Request.Context(), eventID, &req, userID.(int64))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		} else {
+			respondWithError(c, err, http.StatusBadRequest)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Event status updated successfully", "status": req.Status})
+}
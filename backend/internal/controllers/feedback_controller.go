@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/services"
+)
+
+var feedbackService = services.NewFeedbackService()
+
+// SubmitFeedback leaves a rating and comment on the event of a registration
+func SubmitFeedback(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	registrationID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	var req models.FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	feedbackID, err := feedbackService.SubmitFeedback(c.Request.Context(), registrationID, &req, userID.(int64))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		} else {
+			respondWithError(c, err, http.StatusBadRequest)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": feedbackID})
+}
+
+// GetEventFeedback returns the feedback on an event with its rating distribution
+func GetEventFeedback(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	feedback, err := feedbackService.GetEventFeedback(c.Request.Context(), eventID, userID.(int64))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, feedback)
+}
+
+// GetMyEventsFeedback returns the rating summaries across the events of the current user
+func GetMyEventsFeedback(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	feedback, err := feedbackService.GetOrganizerFeedback(c.Request.Context(), userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feedback"})
+		return
+	}
+
+	c.JSON(http.StatusOK, feedback)
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/services"
+)
+
+var jobService = services.NewJobService()
+
+// GetJobs returns the recurring background jobs and how many scheduled jobs are in each status
+func GetJobs(c *gin.Context) {
+	overview, err := jobService.GetJobsOverview(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
+
+// GetJobRuns returns the latest runs of background jobs, optionally filtered by
+// job name and status. Pass the smallest ID seen as before_id to page further back.
+func GetJobRuns(c *gin.Context) {
+	filter := models.JobRunFilter{
+		JobName: c.Query("name"),
+		Status:  c.Query("status"),
+	}
+
+	if beforeID := c.Query("before_id"); beforeID != "" {
+		id, err := strconv.ParseInt(beforeID, 10, 64)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before_id"})
+			return
+		}
+		filter.BeforeID = id
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filter.Limit = n
+	}
+
+	runs, err := jobService.GetJobRuns(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
@@ -0,0 +1,12 @@
+package controllers
+
+import "log/slog"
+
+// logger is what the controllers log with, the same logger as the services.
+// Log with the context of the request so the lines carry its request ID.
+var logger = slog.Default()
+
+// SetLogger sets the logger of the controllers. Call it before serving requests.
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/services"
+)
+
+var inboxService = services.NewInboxService()
+
+// GetNotifications lists the inbox of the current user, newest first. Older
+// pages are fetched with before_id set to the last ID of the previous page.
+func GetNotifications(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	filter := models.NotificationFilter{UnreadOnly: c.Query("unread") == "true"}
+	if value := c.Query("before_id"); value != "" {
+		beforeID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || beforeID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before_id"})
+			return
+		}
+		filter.BeforeID = beforeID
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	inbox, err := inboxService.GetNotifications(c.Request.Context(), userID.(int64), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notifications"})
+		return
+	}
+
+	unread, err := inboxService.CountUnread(c.Request.Context(), userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notifications": inbox, "unread_count": unread})
+}
+
+// MarkNotificationRead marks a notification of the current user as read
+func MarkNotificationRead(c *gin.Context) {
+	markNotification(c, true)
+}
+
+// MarkNotificationUnread marks a notification of the current user as unread
+func MarkNotificationUnread(c *gin.Context) {
+	markNotification(c, false)
+}
+
+func markNotification(c *gin.Context, read bool) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	notificationID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	notification, err := inboxService.MarkRead(c.Request.Context(), notificationID, userID.(int64), read)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllNotificationsRead marks the whole inbox of the current user as read
+func MarkAllNotificationsRead(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	marked, err := inboxService.MarkAllRead(c.Request.Context(), userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"marked_read": marked})
+}
+
+// GetNotificationPreferences returns where each kind of notification is
+// delivered to the current user
+func GetNotificationPreferences(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	preferences, err := inboxService.GetPreferences(c.Request.Context(), userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notification preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdateNotificationPreferences changes where kinds of notifications are
+// delivered to the current user
+func UpdateNotificationPreferences(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.NotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preferences, err := inboxService.UpdatePreferences(c.Request.Context(), userID.(int64), &req)
+	if err != nil {
+		respondWithError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
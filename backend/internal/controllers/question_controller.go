@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/services"
+)
+
+var questionService = services.NewQuestionService()
+
+// GetEventQuestions returns the questions currently asked when registering for an event
+func GetEventQuestions(c *gin.Context) {
+	id := c.Param("id")
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := eventService.GetEventByID(c.Request.Context(), eventID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get event"})
+		}
+		return
+	}
+
+	// The questions of drafts are only visible to the creator
+	if !event.IsPubliclyVisible() && !isEventCreator(c, event) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	questions, err := questionService.GetQuestions(c.Request.Context(), eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get questions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, questions)
+}
+
+// SetEventQuestions replaces the questions asked when registering for an event
+func SetEventQuestions(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req models.QuestionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	version, err := questionService.SetQuestions(c.Request.Context(), eventID, &req, userID.(int64))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		} else {
+			respondWithError(c, err, http.StatusBadRequest)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Questions updated successfully", "version": version})
+}
@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"context"
 	"database/sql"
+	"encoding/csv"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/netpo4ki/event-poster/internal/models"
@@ -15,8 +19,14 @@ var registrationService = services.NewRegistrationService()
 
 // userService is already declared in user_controller.go
 
-// GetRegistrations returns all registrations
+// GetRegistrations returns the registrations of the current user and of the events they organize
 func GetRegistrations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
 	// Check if filtering by event ID
 	var eventID *int64
 	eventIDParam := c.Query("event_id")
@@ -29,7 +39,7 @@ func GetRegistrations(c *gin.Context) {
 		eventID = &id
 	}
 
-	registrations, err := registrationService.GetAllRegistrations(eventID)
+	registrations, err := registrationService.GetAllRegistrations(c.Request.Context(), eventID, userID.(int64))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get registrations"})
 		return
@@ -46,7 +56,7 @@ func GetMyRegistrations(c *gin.Context) {
 		return
 	}
 
-	registrations, err := registrationService.GetUserRegistrations(userID.(int64))
+	registrations, err := registrationService.GetUserRegistrations(c.Request.Context(), userID.(int64))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get registrations"})
 		return
@@ -64,8 +74,14 @@ func GetRegistration(c *gin.Context) {
 		return
 	}
 
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
 	// Get the registration with event details for a more complete response
-	registration, err := registrationService.GetRegistrationWithEventDetails(registrationID)
+	registration, err := registrationService.GetRegistrationForUser(c.Request.Context(), registrationID, userID.(int64))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
@@ -92,7 +108,7 @@ func CreateRegistration(c *gin.Context) {
 	userID = &idValue
 
 	// Get user information for registration
-	user, err := userService.GetUserByID(idValue)
+	user, err := userService.GetUserByID(c.Request.Context(), idValue)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user information"})
 		return
@@ -113,20 +129,9 @@ func CreateRegistration(c *gin.Context) {
 		req.LastName = user.Username // Use username as last name if no space in username
 	}
 
-	id, err := registrationService.CreateRegistration(&req, userID)
+	id, err := registrationService.CreateRegistration(c.Request.Context(), &req, userID)
 	if err != nil {
-		// More specific error handling based on business logic
-		if err.Error() == "event is fully booked" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		} else if err.Error() == "event not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else if err.Error() == "cannot register for a past event" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		} else if err.Error() == "you have already registered for this event" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		respondWithError(c, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -155,12 +160,12 @@ func UpdateRegistration(c *gin.Context) {
 		return
 	}
 
-	err = registrationService.UpdateRegistration(registrationID, &req, userID.(int64))
+	err = registrationService.UpdateRegistration(c.Request.Context(), registrationID, &req, userID.(int64))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondWithError(c, err, http.StatusBadRequest)
 		}
 		return
 	}
@@ -168,6 +173,41 @@ func UpdateRegistration(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Registration updated successfully"})
 }
 
+// UpdateRegistrationGuests changes the guests of a registration
+func UpdateRegistrationGuests(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	registrationID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	var req models.GuestsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = registrationService.UpdateGuests(c.Request.Context(), registrationID, &req, userID.(int64))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		} else {
+			respondWithError(c, err, http.StatusBadRequest)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Guests updated successfully"})
+}
+
 // DeleteRegistration deletes a registration
 func DeleteRegistration(c *gin.Context) {
 	// Get user ID from context (set by authentication middleware)
@@ -184,15 +224,171 @@ func DeleteRegistration(c *gin.Context) {
 		return
 	}
 
-	err = registrationService.DeleteRegistration(registrationID, userID.(int64))
+	err = registrationService.DeleteRegistration(c.Request.Context(), registrationID, userID.(int64))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		} else {
+			respondWithError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Registration cancelled successfully"})
+}
+
+// ApproveRegistration accepts a pending registration
+func ApproveRegistration(c *gin.Context) {
+	decideRegistration(c, registrationService.ApproveRegistration, "Registration approved successfully")
+}
+
+// RejectRegistration turns down a pending registration
+func RejectRegistration(c *gin.Context) {
+	decideRegistration(c, registrationService.RejectRegistration, "Registration rejected successfully")
+}
+
+// decideRegistration runs an organizer's decision on the registration in the URL
+func decideRegistration(c *gin.Context, decide func(context.Context, int64, *models.RegistrationDecisionRequest, int64) error, message string) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	registrationID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	// The reason is optional, so an empty body is fine
+	var req models.RegistrationDecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	err = decide(c.Request.Context(), registrationID, &req, userID.(int64))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondWithError(c, err, http.StatusBadRequest)
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Registration deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// GetEventAttendees returns the attendees of an event with their answers
+func GetEventAttendees(c *gin.Context) {
+	attendees, ok := loadEventAttendees(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, attendees)
+}
+
+// ExportEventAttendees returns the attendees of an event with their answers as CSV
+func ExportEventAttendees(c *gin.Context) {
+	attendees, ok := loadEventAttendees(c)
+	if !ok {
+		return
+	}
+
+	eventID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	questions, err := questionService.GetAllQuestions(c.Request.Context(), eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get questions"})
+		return
+	}
+
+	// One column per question; labels of earlier versions are marked with their version
+	latestVersion := 0
+	for _, question := range questions {
+		latestVersion = question.Version
+	}
+	header := []string{"registration_id", "first_name", "last_name", "status", "ticket_type", "guest_count", "guests", "notes", "registered_at", "checked_in_at"}
+	columns := make(map[int64]int, len(questions))
+	for _, question := range questions {
+		label := question.Label
+		if question.Version != latestVersion {
+			label = fmt.Sprintf("%s (v%d)", label, question.Version)
+		}
+		columns[question.ID] = len(header)
+		header = append(header, label)
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=event-%d-attendees.csv", eventID))
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write(header)
+	for _, attendee := range attendees {
+		guests := make([]string, 0, len(attendee.Guests))
+		for _, guest := range attendee.Guests {
+			guests = append(guests, strings.TrimSpace(guest.FirstName+" "+guest.LastName))
+		}
+
+		checkedInAt := ""
+		if attendee.CheckedInAt != nil {
+			checkedInAt = attendee.CheckedInAt.Format(time.RFC3339)
+		}
+
+		record := make([]string, len(header))
+		copy(record, []string{
+			strconv.FormatInt(attendee.ID, 10),
+			attendee.FirstName,
+			attendee.LastName,
+			string(attendee.Status),
+			attendee.TicketTypeName,
+			strconv.Itoa(attendee.GuestCount),
+			strings.Join(guests, "; "),
+			attendee.Notes,
+			attendee.CreatedAt.Format(time.RFC3339),
+			checkedInAt,
+		})
+		for _, answer := range attendee.Answers {
+			record[columns[answer.QuestionID]] = models.FormatAnswer(answer.Value)
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+}
+
+// loadEventAttendees reads the attendees of the event in the URL, writing the
+// error response and returning false when they can't be listed
+func loadEventAttendees(c *gin.Context) ([]models.Attendee, bool) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	id := c.Param("id")
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return nil, false
+	}
+
+	attendees, err := registrationService.GetAttendees(c.Request.Context(), eventID, userID.(int64))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		} else {
+			respondWithError(c, err, http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+
+	return attendees, true
 }
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/services"
+)
+
+var seriesService = services.NewSeriesService()
+
+// GetSeries returns a series together with its occurrences
+func GetSeries(c *gin.Context) {
+	id := c.Param("id")
+	seriesID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	series, err := seriesService.GetSeriesByID(c.Request.Context(), seriesID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get series"})
+		}
+		return
+	}
+
+	occurrences, err := seriesService.GetSeriesOccurrences(c.Request.Context(), seriesID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get series occurrences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series":      series,
+		"occurrences": occurrences,
+	})
+}
+
+// CreateSeries creates a new recurring event series
+func CreateSeries(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.SeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := seriesService.CreateSeries(c.Request.Context(), &req, userID.(int64))
+	if err != nil {
+		respondWithError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// CancelSeries cancels all upcoming occurrences of a series
+func CancelSeries(c *gin.Context) {
+	// Get user ID from context (set by authentication middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	seriesID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	cancellation, err := seriesService.CancelSeries(c.Request.Context(), seriesID, userID.(int64))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		} else {
+			respondWithError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                 "Series cancelled successfully",
+		"cancelled_occurrences":   cancellation.CancelledOccurrences,
+		"cancelled_registrations": cancellation.CancelledRegistrations,
+	})
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/realtime"
+	"github.com/netpo4ki/event-poster/internal/services"
+)
+
+const (
+	// streamHeartbeat is how often idle streams send a comment so proxies keep them open
+	streamHeartbeat = 15 * time.Second
+	// streamWriteTimeout drops clients that don't take a write within this time
+	streamWriteTimeout = 10 * time.Second
+	// streamRetry is how long clients wait before reconnecting, in milliseconds
+	streamRetry = 3000
+)
+
+// StreamEvent pushes the seat availability and status of an event as
+// Server-Sent Events, starting with its current state
+func StreamEvent(c *gin.Context) {
+	id := c.Param("id")
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := eventService.GetEventByID(c.Request.Context(), eventID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get event"})
+		}
+		return
+	}
+
+	// Drafts are only visible to their creator
+	if !event.IsPubliclyVisible() && !isEventCreator(c, event) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	streamUpdates(c, []int64{eventID})
+}
+
+// StreamEvents pushes the changes of several events (?ids=1,2,3) or, without
+// ids, of every event as Server-Sent Events. Listed events start with their
+// current state.
+func StreamEvents(c *gin.Context) {
+	var eventIDs []int64
+	if ids := c.Query("ids"); ids != "" {
+		for _, field := range strings.Split(ids, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "ids must be a comma separated list of event IDs"})
+				return
+			}
+			eventIDs = append(eventIDs, id)
+		}
+		if len(eventIDs) > models.MaxStreamEvents {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "too many events to watch"})
+			return
+		}
+	}
+
+	streamUpdates(c, eventIDs)
+}
+
+// streamUpdates streams the live updates of the given events, or of every
+// event when none are given, until the client goes away. A client resuming
+// with Last-Event-ID gets the updates it missed; if they are no longer known
+// it gets a reset event and the current state of the listed events instead.
+func streamUpdates(c *gin.Context, eventIDs []int64) {
+	broadcaster := realtime.Default()
+	if broadcaster == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Live updates are not available"})
+		return
+	}
+
+	var lastID uint64
+	if value := c.GetHeader("Last-Event-ID"); value != "" {
+		lastID, _ = strconv.ParseUint(value, 10, 64)
+	}
+
+	filter := realtime.Filter{
+		EventIDs: eventIDs,
+		Names:    []string{services.LiveAvailability, services.LiveEventDeleted},
+	}
+	if userID, ok := c.Get("user_id"); ok {
+		filter.UserID = userID.(int64)
+	}
+
+	sub, resumed, err := broadcaster.Subscribe(filter, lastID)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Live updates are not available"})
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Keep nginx from buffering the stream
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	rc := http.NewResponseController(w)
+	write := func(send func() error) bool {
+		// Slow clients would otherwise hold the stream open forever
+		rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if err := send(); err != nil {
+			return false
+		}
+		w.Flush()
+		return true
+	}
+
+	ok := write(func() error {
+		if _, err := w.WriteString("retry: " + strconv.Itoa(streamRetry) + "\n\n"); err != nil {
+			return err
+		}
+		if resumed {
+			return nil
+		}
+		if lastID != 0 {
+			if err := realtime.WriteSSEData(w, "reset", []byte("{}")); err != nil {
+				return err
+			}
+		}
+		return writeSnapshots(c, eventIDs, filter.UserID)
+	})
+	if !ok {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case msg, open := <-sub.Messages():
+			if !open {
+				// Lagging clients reconnect and resume from the last update they got
+				if sub.Err() == realtime.ErrLagging {
+					logger.InfoContext(c.Request.Context(), "Dropping live stream of a client that fell behind")
+				}
+				return
+			}
+			if !write(func() error { return realtime.WriteSSE(w, msg) }) {
+				return
+			}
+		case <-heartbeat.C:
+			if !write(func() error { return realtime.WriteSSEComment(w, "heartbeat") }) {
+				return
+			}
+		}
+	}
+}
+
+// writeSnapshots sends the current seat availability of the events the user may see
+func writeSnapshots(c *gin.Context, eventIDs []int64, userID int64) error {
+	for _, id := range eventIDs {
+		event, err := eventService.GetEventByID(c.Request.Context(), id)
+		if err != nil {
+			continue
+		}
+		if !event.IsPubliclyVisible() && event.CreatorID != userID {
+			continue
+		}
+
+		availability, err := eventService.GetSeatAvailability(c.Request.Context(), id)
+		if err != nil {
+			logger.ErrorContext(c.Request.Context(), "Failed to get seat availability", "event_id", id, "error", err)
+			continue
+		}
+		data, err := json.Marshal(availability)
+		if err != nil {
+			return err
+		}
+		if err := realtime.WriteSSEData(c.Writer, services.LiveAvailability, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -18,7 +18,7 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	id, err := userService.Register(&req)
+	id, err := userService.Register(c.Request.Context(), &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -35,7 +35,7 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	resp, err := userService.Login(&req)
+	resp, err := userService.Login(c.Request.Context(), &req)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -52,13 +52,19 @@ func GetCurrentUser(c *gin.Context) {
 		return
 	}
 
-	user, err := userService.GetUserByID(userID.(int64))
+	user, err := userService.GetUserByID(c.Request.Context(), userID.(int64))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	unread, err := inboxService.CountUnread(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CurrentUser{User: *user, UnreadNotifications: unread})
 }
 
 // GetUserRegistrations gets registrations for the current user
@@ -69,7 +75,7 @@ func GetUserRegistrations(c *gin.Context) {
 		return
 	}
 
-	registrations, err := userService.GetUserRegistrations(userID.(int64))
+	registrations, err := userService.GetUserRegistrations(c.Request.Context(), userID.(int64))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user registrations"})
 		return
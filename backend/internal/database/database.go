@@ -2,15 +2,26 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
+	"github.com/netpo4ki/event-poster/internal/tracing"
 )
 
 // DB is the database connection
 var DB *sql.DB
 
+// driverName is SQLite with every statement run for a traced request traced
+const driverName = "sqlite3_traced"
+
+func init() {
+	sql.Register(driverName, tracing.WrapDriver(&sqlite3.SQLiteDriver{}, "sqlite"))
+}
+
 // InitDB initializes the database
 func InitDB() {
 	dbPath := os.Getenv("DB_PATH")
@@ -23,10 +34,10 @@ func InitDB() {
 
 		// Default to a SQLite database in the data directory
 		dbPath = "./data/event_poster.db"
-		log.Printf("Using database at: %s", dbPath)
+		slog.Info("Using default database", "path", dbPath)
 	}
 
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open(driverName, dbPath)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
@@ -63,6 +74,7 @@ func createTables() {
 	if err != nil {
 		log.Fatalf("Failed to create users table: %v", err)
 	}
+	addColumnIfMissing("users", "locale", "TEXT NOT NULL DEFAULT 'en'")
 
 	// Create events table if it doesn't exist
 	_, err = DB.Exec(`
@@ -83,6 +95,95 @@ func createTables() {
 		log.Fatalf("Failed to create events table: %v", err)
 	}
 
+	// Create venues table if it doesn't exist
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS venues (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			address TEXT,
+			latitude REAL,
+			longitude REAL,
+			capacity INTEGER NOT NULL,
+			accessibility TEXT,
+			creator_id INTEGER,
+			created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (creator_id) REFERENCES users(id)
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create venues table: %v", err)
+	}
+
+	// Create event series table if it doesn't exist
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS event_series (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			description TEXT,
+			location TEXT,
+			event_type TEXT NOT NULL,
+			start_date TEXT NOT NULL,
+			seats INTEGER NOT NULL,
+			frequency TEXT NOT NULL,
+			repeat_interval INTEGER NOT NULL DEFAULT 1,
+			occurrence_count INTEGER,
+			until_date TEXT,
+			exceptions TEXT,
+			creator_id INTEGER,
+			cancelled_at TEXT,
+			created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (creator_id) REFERENCES users(id)
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create event_series table: %v", err)
+	}
+
+	// Link events to the series they were expanded from
+	addColumnIfMissing("events", "series_id", "INTEGER REFERENCES event_series(id)")
+
+	// Keep the IANA time zone events and series were created in
+	addColumnIfMissing("events", "timezone", "TEXT NOT NULL DEFAULT 'UTC'")
+	addColumnIfMissing("event_series", "timezone", "TEXT NOT NULL DEFAULT 'UTC'")
+	normalizeEventDates()
+
+	// Events have an explicit end; existing rows get the default two hour duration
+	addColumnIfMissing("events", "end_date", "TEXT")
+	addColumnIfMissing("event_series", "duration_minutes", "INTEGER NOT NULL DEFAULT 120")
+	_, err = DB.Exec(`
+		UPDATE events
+		SET end_date = strftime('%Y-%m-%dT%H:%M:%SZ', event_date, '+2 hours')
+		WHERE end_date IS NULL
+	`)
+	if err != nil {
+		log.Fatalf("Failed to set default event end dates: %v", err)
+	}
+
+	// Events and series can take place at a venue
+	addColumnIfMissing("events", "venue_id", "INTEGER REFERENCES venues(id)")
+	addColumnIfMissing("event_series", "venue_id", "INTEGER REFERENCES venues(id)")
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_events_venue ON events (venue_id, event_date)")
+	if err != nil {
+		log.Fatalf("Failed to create events venue index: %v", err)
+	}
+
+	// Event coordinates come from the venue or from geocoding the location
+	addColumnIfMissing("events", "latitude", "REAL")
+	addColumnIfMissing("events", "longitude", "REAL")
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_events_coordinates ON events (latitude, longitude)")
+	if err != nil {
+		log.Fatalf("Failed to create events coordinates index: %v", err)
+	}
+
+	// Events move through a lifecycle instead of being deleted when cancelled
+	addColumnIfMissing("events", "status", "TEXT NOT NULL DEFAULT 'published'")
+	addColumnIfMissing("events", "status_reason", "TEXT")
+	addColumnIfMissing("events", "publish_at", "TEXT")
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_events_status ON events (status, event_date)")
+	if err != nil {
+		log.Fatalf("Failed to create events status index: %v", err)
+	}
+
 	// Create registrations table if it doesn't exist
 	_, err = DB.Exec(`
 		CREATE TABLE IF NOT EXISTS registrations (
@@ -100,7 +201,433 @@ func createTables() {
 		log.Fatalf("Failed to create registrations table: %v", err)
 	}
 
-	log.Println("Database tables verified successfully")
+	// Registrations are kept but marked cancelled when they stop holding a seat
+	addColumnIfMissing("registrations", "status", "TEXT NOT NULL DEFAULT 'confirmed'")
+
+	// Create ticket types table if it doesn't exist
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS ticket_types (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			description TEXT,
+			capacity INTEGER NOT NULL,
+			sales_start TEXT,
+			sales_end TEXT,
+			visibility TEXT NOT NULL DEFAULT 'public',
+			created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create ticket_types table: %v", err)
+	}
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_ticket_types_event ON ticket_types (event_id)")
+	if err != nil {
+		log.Fatalf("Failed to create ticket types index: %v", err)
+	}
+
+	// Registrations for events with ticket types hold a seat in one tier
+	addColumnIfMissing("registrations", "ticket_type_id", "INTEGER REFERENCES ticket_types(id)")
+
+	// A registration takes a seat for its owner plus one for each guest
+	addColumnIfMissing("events", "max_guests_per_registration", "INTEGER NOT NULL DEFAULT 0")
+	addColumnIfMissing("registrations", "guest_count", "INTEGER NOT NULL DEFAULT 0")
+
+	// Create registration guests table if it doesn't exist
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS registration_guests (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			registration_id INTEGER NOT NULL,
+			first_name TEXT NOT NULL,
+			last_name TEXT,
+			FOREIGN KEY (registration_id) REFERENCES registrations(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create registration_guests table: %v", err)
+	}
+
+	// Notes and answers to the custom questions of an event are kept with each registration
+	addColumnIfMissing("registrations", "notes", "TEXT")
+	addColumnIfMissing("registrations", "question_version", "INTEGER")
+	addColumnIfMissing("events", "question_version", "INTEGER NOT NULL DEFAULT 0")
+
+	// Events may require the organizer to approve registrations
+	addColumnIfMissing("events", "requires_approval", "INTEGER NOT NULL DEFAULT 0")
+	addColumnIfMissing("events", "reserve_pending_seats", "INTEGER NOT NULL DEFAULT 0")
+	addColumnIfMissing("registrations", "seat_reserved", "INTEGER NOT NULL DEFAULT 1")
+	addColumnIfMissing("registrations", "status_reason", "TEXT")
+
+	// Events may limit when attendees can register and cancel
+	addColumnIfMissing("events", "registration_opens_at", "TEXT")
+	addColumnIfMissing("events", "registration_closes_at", "TEXT")
+	addColumnIfMissing("events", "cancellation_deadline", "TEXT")
+
+	// Registrations are checked in once at the door by scanning their ticket
+	addColumnIfMissing("registrations", "checked_in_at", "TEXT")
+	addColumnIfMissing("registrations", "checked_in_by", "INTEGER REFERENCES users(id)")
+	addColumnIfMissing("registrations", "checked_in_device", "TEXT")
+
+	// Events may turn away users who often register without showing up
+	addColumnIfMissing("events", "max_no_shows", "INTEGER NOT NULL DEFAULT 0")
+
+	// Attendees are reminded this many minutes before an event starts
+	addColumnIfMissing("events", "reminder_offsets", "TEXT NOT NULL DEFAULT '1440,60'")
+
+	// Create check-in records table if it doesn't exist. It keeps the scans
+	// uploaded by offline devices so uploading a record again is harmless.
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS checkin_records (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_id INTEGER NOT NULL,
+			record_id TEXT NOT NULL,
+			device_id TEXT NOT NULL,
+			registration_id INTEGER,
+			scanned_at TEXT NOT NULL,
+			received_at TEXT NOT NULL,
+			uploaded_by INTEGER,
+			result TEXT NOT NULL,
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+			FOREIGN KEY (registration_id) REFERENCES registrations(id) ON DELETE SET NULL,
+			FOREIGN KEY (uploaded_by) REFERENCES users(id) ON DELETE SET NULL,
+			UNIQUE (event_id, device_id, record_id)
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create checkin_records table: %v", err)
+	}
+
+	// Create event questions table if it doesn't exist
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS event_questions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_id INTEGER NOT NULL,
+			version INTEGER NOT NULL,
+			position INTEGER NOT NULL,
+			label TEXT NOT NULL,
+			type TEXT NOT NULL,
+			options TEXT,
+			required INTEGER NOT NULL DEFAULT 0,
+			created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create event_questions table: %v", err)
+	}
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_event_questions_event ON event_questions (event_id, version)")
+	if err != nil {
+		log.Fatalf("Failed to create event questions index: %v", err)
+	}
+
+	// Create registration answers table if it doesn't exist
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS registration_answers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			registration_id INTEGER NOT NULL,
+			question_id INTEGER NOT NULL,
+			value TEXT NOT NULL,
+			FOREIGN KEY (registration_id) REFERENCES registrations(id) ON DELETE CASCADE,
+			FOREIGN KEY (question_id) REFERENCES event_questions(id) ON DELETE CASCADE,
+			UNIQUE (registration_id, question_id)
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create registration_answers table: %v", err)
+	}
+
+	// Create event feedback table if it doesn't exist
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS event_feedback (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_id INTEGER NOT NULL,
+			registration_id INTEGER NOT NULL UNIQUE,
+			user_id INTEGER,
+			rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+			comment TEXT,
+			created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+			FOREIGN KEY (registration_id) REFERENCES registrations(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create event_feedback table: %v", err)
+	}
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_event_feedback_event ON event_feedback (event_id)")
+	if err != nil {
+		log.Fatalf("Failed to create event feedback index: %v", err)
+	}
+
+	// Create scheduled jobs table if it doesn't exist. Jobs are run by the
+	// background worker; their key keeps the same job from being added twice.
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS scheduled_jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL,
+			job_key TEXT NOT NULL UNIQUE,
+			payload TEXT,
+			run_at TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+			finished_at TEXT
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create scheduled_jobs table: %v", err)
+	}
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_scheduled_jobs_due ON scheduled_jobs (status, run_at)")
+	if err != nil {
+		log.Fatalf("Failed to create scheduled jobs index: %v", err)
+	}
+
+	// A worker leases the jobs it runs, so only one instance runs each job. Jobs
+	// whose lease ran out, e.g. because the instance died, are run again.
+	addColumnIfMissing("scheduled_jobs", "locked_by", "TEXT")
+	addColumnIfMissing("scheduled_jobs", "locked_until", "TEXT")
+
+	// Create recurring jobs table if it doesn't exist. It holds the next run of
+	// the jobs repeated on a cron schedule, leased like scheduled jobs.
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS recurring_jobs (
+			name TEXT PRIMARY KEY,
+			schedule TEXT NOT NULL,
+			next_run_at TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			locked_by TEXT,
+			locked_until TEXT,
+			last_run_at TEXT,
+			last_status TEXT,
+			last_error TEXT
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create recurring_jobs table: %v", err)
+	}
+
+	// Create job runs table if it doesn't exist. Every run of a scheduled or
+	// recurring job is recorded for administrators.
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS job_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			job_name TEXT NOT NULL,
+			job_id INTEGER,
+			attempt INTEGER NOT NULL,
+			worker_id TEXT NOT NULL,
+			status TEXT NOT NULL,
+			error TEXT,
+			started_at TEXT NOT NULL,
+			finished_at TEXT,
+			duration_ms INTEGER,
+			FOREIGN KEY (job_id) REFERENCES scheduled_jobs(id) ON DELETE SET NULL
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create job_runs table: %v", err)
+	}
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_job_runs_started ON job_runs (started_at)")
+	if err != nil {
+		log.Fatalf("Failed to create job runs index: %v", err)
+	}
+
+	// Create reminder deliveries table if it doesn't exist. It records who got
+	// each reminder so a job that is run again doesn't remind anyone twice.
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS reminder_deliveries (
+			job_id INTEGER NOT NULL,
+			registration_id INTEGER NOT NULL,
+			sent_at TEXT NOT NULL,
+			PRIMARY KEY (job_id, registration_id),
+			FOREIGN KEY (job_id) REFERENCES scheduled_jobs(id) ON DELETE CASCADE,
+			FOREIGN KEY (registration_id) REFERENCES registrations(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create reminder_deliveries table: %v", err)
+	}
+
+	// Create webhooks table if it doesn't exist. Webhooks of a user cover the
+	// events they organize, or only one of them when event_id is set. There's
+	// no foreign key on event_id so the webhook outlives the event and still
+	// gets told that it was deleted.
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS webhooks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			event_id INTEGER,
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			topics TEXT NOT NULL DEFAULT '',
+			active INTEGER NOT NULL DEFAULT 1,
+			created_at TEXT NOT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create webhooks table: %v", err)
+	}
+
+	// Create webhook outbox table if it doesn't exist. Changes add their
+	// webhooks here in their own transaction; they are sent from here later.
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS webhook_outbox (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			topic TEXT NOT NULL,
+			event_id INTEGER NOT NULL,
+			owner_id INTEGER,
+			payload TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			dispatched_at TEXT
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create webhook_outbox table: %v", err)
+	}
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_webhook_outbox_pending ON webhook_outbox (dispatched_at, id)")
+	if err != nil {
+		log.Fatalf("Failed to create webhook outbox index: %v", err)
+	}
+
+	// Create webhook deliveries table if it doesn't exist. Each outbox entry is
+	// delivered once to every matching webhook.
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			webhook_id INTEGER NOT NULL,
+			outbox_id INTEGER,
+			topic TEXT NOT NULL,
+			body TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TEXT,
+			response_status INTEGER,
+			last_error TEXT,
+			duration_ms INTEGER,
+			created_at TEXT NOT NULL,
+			delivered_at TEXT,
+			FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE,
+			FOREIGN KEY (outbox_id) REFERENCES webhook_outbox(id) ON DELETE SET NULL,
+			UNIQUE (webhook_id, outbox_id)
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create webhook_deliveries table: %v", err)
+	}
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at)")
+	if err != nil {
+		log.Fatalf("Failed to create webhook deliveries index: %v", err)
+	}
+
+	// Create the in-app notification inbox
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			title TEXT NOT NULL,
+			body TEXT NOT NULL,
+			event_id INTEGER,
+			registration_id INTEGER,
+			read_at TEXT,
+			created_at TEXT NOT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create notifications table: %v", err)
+	}
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications (user_id, id)")
+	if err != nil {
+		log.Fatalf("Failed to create notifications index: %v", err)
+	}
+
+	// Channels users picked per kind of notification; kinds without a row go to both
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS notification_preferences (
+			user_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			channel TEXT NOT NULL,
+			PRIMARY KEY (user_id, kind),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		log.Fatalf("Failed to create notification_preferences table: %v", err)
+	}
+
+	slog.Info("Database tables verified successfully")
+}
+
+// addColumnIfMissing adds a column to an existing table, since SQLite has no ADD COLUMN IF NOT EXISTS
+func addColumnIfMissing(table, column, definition string) {
+	rows, err := DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		log.Fatalf("Failed to inspect %s table: %v", table, err)
+	}
+
+	exists := false
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			log.Fatalf("Failed to inspect %s table: %v", table, err)
+		}
+		if name == column {
+			exists = true
+		}
+	}
+	rows.Close()
+
+	if exists {
+		return
+	}
+
+	_, err = DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		log.Fatalf("Failed to add %s.%s column: %v", table, column, err)
+	}
+	slog.Info("Added column", "table", table, "column", column)
+}
+
+// normalizeEventDates rewrites event dates stored with a local offset as UTC,
+// so that string comparisons in queries compare the actual instants
+func normalizeEventDates() {
+	rows, err := DB.Query("SELECT id, event_date FROM events WHERE event_date NOT LIKE '%Z'")
+	if err != nil {
+		log.Fatalf("Failed to read event dates: %v", err)
+	}
+
+	updates := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var eventDateStr string
+		if err := rows.Scan(&id, &eventDateStr); err != nil {
+			rows.Close()
+			log.Fatalf("Failed to read event dates: %v", err)
+		}
+
+		eventDate, err := time.Parse(time.RFC3339, eventDateStr)
+		if err != nil {
+			slog.Warn("Skipping event with unparseable date", "event_id", id)
+			continue
+		}
+		updates[id] = eventDate.UTC().Format(time.RFC3339)
+	}
+	rows.Close()
+
+	for id, eventDate := range updates {
+		if _, err := DB.Exec("UPDATE events SET event_date = ? WHERE id = ?", eventDate, id); err != nil {
+			log.Fatalf("Failed to normalize date of event %d: %v", id, err)
+		}
+	}
+	if len(updates) > 0 {
+		slog.Info("Normalized event dates to UTC", "events", len(updates))
+	}
 }
 
 // CloseDB closes the database connection
@@ -0,0 +1,62 @@
+package geo
+
+import "math"
+
+// EarthRadiusKm is the mean radius of the Earth in kilometers
+const EarthRadiusKm = 6371.0
+
+// Point is a position given in decimal degrees
+type Point struct {
+	Lat float64 `json:"latitude"`
+	Lng float64 `json:"longitude"`
+}
+
+// BoundingBox is a latitude/longitude rectangle
+type BoundingBox struct {
+	MinLat, MaxLat float64
+	MinLng, MaxLng float64
+}
+
+// Valid reports whether the point lies within the latitude and longitude ranges
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// DistanceKm returns the great-circle distance between two points using the haversine formula
+func DistanceKm(a, b Point) float64 {
+	lat1 := toRadians(a.Lat)
+	lat2 := toRadians(b.Lat)
+	dLat := toRadians(b.Lat - a.Lat)
+	dLng := toRadians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBoxAround returns a rectangle that contains every point within radiusKm of center.
+// It is meant as a cheap, index-friendly prefilter before computing exact distances.
+// Near the poles or across the antimeridian the box widens to the full longitude range.
+func BoundingBoxAround(center Point, radiusKm float64) BoundingBox {
+	dLat := radiusKm / EarthRadiusKm * 180 / math.Pi
+	box := BoundingBox{
+		MinLat: math.Max(-90, center.Lat-dLat),
+		MaxLat: math.Min(90, center.Lat+dLat),
+		MinLng: -180,
+		MaxLng: 180,
+	}
+
+	if box.MinLat > -90 && box.MaxLat < 90 {
+		dLng := math.Asin(math.Min(1, math.Sin(radiusKm/EarthRadiusKm)/math.Cos(toRadians(center.Lat)))) * 180 / math.Pi
+		if center.Lng-dLng >= -180 && center.Lng+dLng <= 180 {
+			box.MinLng = center.Lng - dLng
+			box.MaxLng = center.Lng + dLng
+		}
+	}
+
+	return box
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,129 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+var (
+	berlin = Point{Lat: 52.5200, Lng: 13.4050}
+	paris  = Point{Lat: 48.8566, Lng: 2.3522}
+)
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Point
+		want float64 // km
+	}{
+		{"same point", berlin, berlin, 0},
+		{"Berlin to Paris", berlin, paris, 878},
+		{"one degree along the equator", Point{0, 0}, Point{0, 1}, 111.19},
+		{"pole to pole", Point{90, 0}, Point{-90, 0}, math.Pi * EarthRadiusKm},
+		{"across the antimeridian", Point{0, 179.5}, Point{0, -179.5}, 111.19},
+	}
+
+	for _, tt := range tests {
+		got := DistanceKm(tt.a, tt.b)
+		if math.Abs(got-tt.want) > 1 {
+			t.Errorf("%s: DistanceKm = %.2f, want %.2f", tt.name, got, tt.want)
+		}
+		if back := DistanceKm(tt.b, tt.a); math.Abs(back-got) > 1e-9 {
+			t.Errorf("%s: DistanceKm isn't symmetric: %.6f and %.6f", tt.name, got, back)
+		}
+	}
+}
+
+func (b BoundingBox) contains(p Point) bool {
+	return p.Lat >= b.MinLat && p.Lat <= b.MaxLat && p.Lng >= b.MinLng && p.Lng <= b.MaxLng
+}
+
+func TestBoundingBoxAroundContainsCircle(t *testing.T) {
+	centers := []Point{berlin, {Lat: -33.87, Lng: 151.21}, {Lat: 0, Lng: 0}, {Lat: 70, Lng: 25}}
+	radius := 50.0
+
+	for _, center := range centers {
+		box := BoundingBoxAround(center, radius)
+		// Points on the circle in every direction must be inside the box
+		for bearing := 0.0; bearing < 360; bearing += 15 {
+			p := destination(center, bearing, radius*0.999)
+			if !box.contains(p) {
+				t.Errorf("box %+v around %+v misses %+v at bearing %.0f", box, center, p, bearing)
+			}
+		}
+	}
+}
+
+func TestBoundingBoxAroundIsTight(t *testing.T) {
+	box := BoundingBoxAround(berlin, 10)
+	if box.contains(paris) {
+		t.Errorf("box %+v around Berlin contains Paris", box)
+	}
+	if height := box.MaxLat - box.MinLat; math.Abs(height-2*10/111.19) > 0.01 {
+		t.Errorf("box is %.4f degrees high, want about %.4f", height, 2*10/111.19)
+	}
+}
+
+func TestBoundingBoxAroundWidens(t *testing.T) {
+	tests := []struct {
+		name   string
+		center Point
+	}{
+		{"near the north pole", Point{Lat: 89.9, Lng: 10}},
+		{"near the south pole", Point{Lat: -89.9, Lng: 10}},
+		{"at the antimeridian", Point{Lat: 0, Lng: 179.9}},
+	}
+
+	for _, tt := range tests {
+		box := BoundingBoxAround(tt.center, 50)
+		if box.MinLng != -180 || box.MaxLng != 180 {
+			t.Errorf("%s: box %+v doesn't span every longitude", tt.name, box)
+		}
+		if !box.contains(tt.center) {
+			t.Errorf("%s: box %+v doesn't contain its center", tt.name, box)
+		}
+	}
+}
+
+func TestPointValid(t *testing.T) {
+	tests := []struct {
+		point Point
+		valid bool
+	}{
+		{berlin, true},
+		{Point{Lat: 90, Lng: 180}, true},
+		{Point{Lat: 90.1, Lng: 0}, false},
+		{Point{Lat: 0, Lng: -180.1}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.point.Valid(); got != tt.valid {
+			t.Errorf("%+v.Valid() = %v, want %v", tt.point, got, tt.valid)
+		}
+	}
+}
+
+func TestStubGeocoder(t *testing.T) {
+	g := NewStubGeocoder()
+	g.Add("York", Point{Lat: 53.96, Lng: -1.08})
+
+	point, err := g.Geocode("Madison Square Garden, New York")
+	if err != nil || point != (Point{Lat: 40.7128, Lng: -74.0060}) {
+		t.Errorf("Geocode(New York) = %+v, %v, want New York rather than York", point, err)
+	}
+	if _, err := g.Geocode("Atlantis"); err != ErrNotFound {
+		t.Errorf("Geocode(Atlantis) error = %v, want ErrNotFound", err)
+	}
+}
+
+// destination returns the point distanceKm away from start in the direction of bearing (degrees)
+func destination(start Point, bearing, distanceKm float64) Point {
+	lat1 := toRadians(start.Lat)
+	lng1 := toRadians(start.Lng)
+	theta := toRadians(bearing)
+	delta := distanceKm / EarthRadiusKm
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta))
+	lng2 := lng1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat1), math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2))
+	return Point{Lat: lat2 * 180 / math.Pi, Lng: lng2 * 180 / math.Pi}
+}
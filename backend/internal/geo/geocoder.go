@@ -0,0 +1,67 @@
+package geo
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNotFound is returned when an address can't be resolved to coordinates
+var ErrNotFound = errors.New("location not found")
+
+// Geocoder resolves free-text addresses to coordinates
+type Geocoder interface {
+	Geocode(address string) (Point, error)
+}
+
+// DefaultGeocoder is used by services that need to geocode locations.
+// Replace it at startup to plug in a real geocoding provider.
+var DefaultGeocoder Geocoder = NewStubGeocoder()
+
+// StubGeocoder is an offline geocoder that knows a fixed set of places.
+// An address resolves to the first known place whose name it contains.
+type StubGeocoder struct {
+	places map[string]Point
+}
+
+// NewStubGeocoder creates a StubGeocoder with a small built-in gazetteer of major cities
+func NewStubGeocoder() *StubGeocoder {
+	return &StubGeocoder{
+		places: map[string]Point{
+			"amsterdam":        {Lat: 52.3676, Lng: 4.9041},
+			"berlin":           {Lat: 52.5200, Lng: 13.4050},
+			"london":           {Lat: 51.5074, Lng: -0.1278},
+			"madrid":           {Lat: 40.4168, Lng: -3.7038},
+			"moscow":           {Lat: 55.7558, Lng: 37.6173},
+			"new york":         {Lat: 40.7128, Lng: -74.0060},
+			"paris":            {Lat: 48.8566, Lng: 2.3522},
+			"prague":           {Lat: 50.0755, Lng: 14.4378},
+			"saint petersburg": {Lat: 59.9311, Lng: 30.3609},
+			"san francisco":    {Lat: 37.7749, Lng: -122.4194},
+			"tokyo":            {Lat: 35.6762, Lng: 139.6503},
+			"vienna":           {Lat: 48.2082, Lng: 16.3738},
+		},
+	}
+}
+
+// Add registers an additional place with the stub
+func (g *StubGeocoder) Add(name string, point Point) {
+	g.places[strings.ToLower(name)] = point
+}
+
+// Geocode returns the coordinates of the known place mentioned in the address.
+// When several match, the longest name wins so "New York" isn't mistaken for "York".
+func (g *StubGeocoder) Geocode(address string) (Point, error) {
+	normalized := strings.ToLower(address)
+
+	var match string
+	for name := range g.places {
+		if strings.Contains(normalized, name) && len(name) > len(match) {
+			match = name
+		}
+	}
+	if match == "" {
+		return Point{}, ErrNotFound
+	}
+
+	return g.places[match], nil
+}
@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// A Wednesday
+	start := time.Date(2026, time.March, 4, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.March, 4, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.March, 4, 10, 15, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2026, time.March, 4, 10, 25, 0, 0, time.UTC)},
+		{"0 9-17 * * *", time.Date(2026, time.March, 4, 11, 0, 0, 0, time.UTC)},
+		{"0 8-20/6 * * *", time.Date(2026, time.March, 4, 14, 0, 0, 0, time.UTC)},
+		{"30 6,18 * * *", time.Date(2026, time.March, 4, 18, 30, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 1-5", time.Date(2026, time.March, 4, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, time.March, 8, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 6 *", time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		// With both days restricted either one matches
+		{"0 0 20 * 5", time.Date(2026, time.March, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, time.March, 4, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, time.March, 5, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		cron, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Errorf("ParseCron(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		if got := cron.Next(start); !got.Equal(tt.want) {
+			t.Errorf("ParseCron(%q).Next(%v) = %v, want %v", tt.spec, start, got, tt.want)
+		}
+	}
+}
+
+func TestCronNextIsAfter(t *testing.T) {
+	cron, err := ParseCron("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A time matching the schedule isn't returned again
+	at := time.Date(2026, time.March, 4, 10, 0, 0, 0, time.UTC)
+	if got, want := cron.Next(at), at.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", at, got, want)
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@fortnightly",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"1- * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"1,,2 * * * *",
+		"0 0 30 2 *",
+	}
+
+	for _, spec := range specs {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", spec)
+		}
+	}
+}
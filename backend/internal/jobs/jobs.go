@@ -0,0 +1,108 @@
+// Package jobs runs background work: jobs stored in the database to run once
+// at a given time, and recurring jobs repeated on a cron schedule.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+)
+
+// Status is the state of a scheduled job
+type Status string
+
+const (
+	// StatusPending jobs wait for their run time
+	StatusPending Status = "pending"
+	// StatusRunning jobs were claimed by the worker
+	StatusRunning Status = "running"
+	// StatusDone jobs ran successfully
+	StatusDone Status = "done"
+	// StatusFailed jobs failed on every attempt
+	StatusFailed Status = "failed"
+	// StatusCancelled jobs are no longer needed
+	StatusCancelled Status = "cancelled"
+)
+
+// RunStatus is the outcome of a run of a job
+type RunStatus string
+
+const (
+	// RunRunning runs haven't finished yet, or their instance died
+	RunRunning RunStatus = "running"
+	// RunSucceeded runs finished without an error
+	RunSucceeded RunStatus = "succeeded"
+	// RunFailed runs returned an error, panicked or ran out of time
+	RunFailed RunStatus = "failed"
+	// RunInterrupted runs were cancelled because their worker shut down
+	RunInterrupted RunStatus = "interrupted"
+)
+
+// Job is a unit of work stored in the scheduled_jobs table
+type Job struct {
+	ID       int64
+	Kind     string // Picks the handler
+	Key      string // Unique, so scheduling the same work again is harmless
+	Payload  string // Handler specific, usually JSON
+	RunAt    time.Time
+	Status   Status
+	Attempts int
+}
+
+// Execer is implemented by both *sql.DB and *sql.Tx, so jobs can be scheduled
+// in the same transaction as the change that needs them
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Schedule adds a job to run at runAt. If a job with the same key exists it is
+// left alone, unless it was cancelled, in which case it is scheduled again.
+// Jobs that already ran are never run again.
+func Schedule(ctx context.Context, db Execer, kind, key, payload string, runAt time.Time) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO scheduled_jobs (kind, job_key, payload, run_at, status)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (job_key) DO UPDATE
+		SET run_at = excluded.run_at, payload = excluded.payload, status = excluded.status, attempts = 0, last_error = NULL
+		WHERE status = ?
+	`, kind, key, payload, formatTime(runAt), StatusPending, StatusCancelled)
+	return err
+}
+
+// CancelPending cancels the pending jobs whose key starts with prefix, except
+// those listed in keep
+func CancelPending(ctx context.Context, db Execer, prefix string, keep ...string) (int, error) {
+	query := "UPDATE scheduled_jobs SET status = ? WHERE status = ? AND job_key LIKE ? ESCAPE '\\'"
+	args := []interface{}{StatusCancelled, StatusPending, escapeLike(prefix) + "%"}
+	if len(keep) > 0 {
+		query += " AND job_key NOT IN (?" + strings.Repeat(", ?", len(keep)-1) + ")"
+		for _, key := range keep {
+			args = append(args, key)
+		}
+	}
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	cancelled, err := result.RowsAffected()
+	return int(cancelled), err
+}
+
+// PruneRuns deletes the records of runs that started before the given time
+func PruneRuns(before time.Time) (int, error) {
+	result, err := database.DB.Exec("DELETE FROM job_runs WHERE started_at < ?", formatTime(before))
+	if err != nil {
+		return 0, err
+	}
+	pruned, err := result.RowsAffected()
+	return int(pruned), err
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,406 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Handler runs a scheduled job. Returning an error retries the job later. ctx
+// is cancelled when the worker shuts down or the lease on the job runs out.
+type Handler func(ctx context.Context, job *Job) error
+
+// TaskFunc runs one occurrence of a recurring job
+type TaskFunc func(ctx context.Context) error
+
+// WorkerConfig tunes a Worker
+type WorkerConfig struct {
+	ID            string        // Names the instance holding a lease, defaults to the host name and process ID
+	PollInterval  time.Duration // How often due jobs are looked for
+	BatchSize     int           // Scheduled jobs picked up per poll
+	MaxAttempts   int           // Tries per job before it is marked failed
+	RetryDelay    time.Duration // Delay before the first retry; it doubles with every further one, up to six hours
+	LeaseDuration time.Duration // How long a run may take before another instance may take the job over
+}
+
+// DefaultWorkerConfig is a sensible configuration for a small deployment
+var DefaultWorkerConfig = WorkerConfig{
+	PollInterval:  30 * time.Second,
+	BatchSize:     50,
+	MaxAttempts:   5,
+	RetryDelay:    time.Minute,
+	LeaseDuration: 5 * time.Minute,
+}
+
+// recurringJob is a job repeated on a cron schedule
+type recurringJob struct {
+	name string
+	cron *Cron
+	run  TaskFunc
+}
+
+// Worker runs due scheduled jobs and recurring jobs in the background. Any
+// number of instances may share a database: each job is leased to the
+// instance running it, and taken over by another one if its lease runs out.
+type Worker struct {
+	config    WorkerConfig
+	handlers  map[string]Handler
+	recurring []*recurringJob
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWorker creates a worker; register handlers and recurring jobs before starting it
+func NewWorker(config WorkerConfig) *Worker {
+	if config.ID == "" {
+		host, _ := os.Hostname()
+		config.ID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultWorkerConfig.PollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultWorkerConfig.BatchSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultWorkerConfig.MaxAttempts
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = DefaultWorkerConfig.RetryDelay
+	}
+	if config.LeaseDuration <= 0 {
+		config.LeaseDuration = DefaultWorkerConfig.LeaseDuration
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Worker{
+		config:   config,
+		handlers: make(map[string]Handler),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Handle sets the handler running the scheduled jobs of a kind
+func (w *Worker) Handle(kind string, handler Handler) {
+	w.handlers[kind] = handler
+}
+
+// Cron runs fn on the schedule given by spec, see ParseCron. The next run is
+// stored, so a job added for the first time runs right away and runs missed
+// while no instance was up are caught up once.
+func (w *Worker) Cron(name, spec string, fn TaskFunc) error {
+	cron, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+
+	// A changed schedule takes effect from now on
+	_, err = database.DB.Exec(`
+		INSERT INTO recurring_jobs (name, schedule, next_run_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE
+		SET schedule = excluded.schedule, next_run_at = ?, attempts = 0
+		WHERE schedule <> excluded.schedule
+	`, name, spec, formatTime(time.Now()), formatTime(cron.Next(time.Now())))
+	if err != nil {
+		return err
+	}
+
+	w.recurring = append(w.recurring, &recurringJob{name: name, cron: cron, run: fn})
+	return nil
+}
+
+// Start starts polling for due jobs
+func (w *Worker) Start() {
+	slog.Info("Worker starting", "worker", w.config.ID, "recurring_jobs", len(w.recurring))
+	w.wg.Add(1)
+	go w.loop()
+}
+
+// Close cancels the running job and waits for the worker to stop. The
+// interrupted job is released so it runs again without losing an attempt.
+func (w *Worker) Close() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// loop polls until the worker is closed
+func (w *Worker) loop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		w.runRecurringJobs()
+		w.runDueJobs()
+
+		select {
+		case <-ticker.C:
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// runRecurringJobs runs the recurring jobs whose next run has come
+func (w *Worker) runRecurringJobs() {
+	for _, job := range w.recurring {
+		if w.ctx.Err() != nil {
+			return
+		}
+		if err := w.runRecurringJob(job); err != nil {
+			slog.Error("Recurring job failed", "worker", w.config.ID, "job", job.name, "error", err)
+		}
+	}
+}
+
+// runRecurringJob leases a recurring job if it is due, runs it and schedules its next run
+func (w *Worker) runRecurringJob(job *recurringJob) error {
+	now := time.Now()
+	result, err := database.DB.Exec(`
+		UPDATE recurring_jobs
+		SET locked_by = ?, locked_until = ?, attempts = attempts + 1
+		WHERE name = ? AND next_run_at <= ? AND (locked_until IS NULL OR locked_until < ?)
+	`, w.config.ID, formatTime(now.Add(w.config.LeaseDuration)), job.name, formatTime(now), formatTime(now))
+	if err != nil {
+		return err
+	}
+	if claimed, err := result.RowsAffected(); err != nil || claimed == 0 {
+		return err
+	}
+
+	var attempt int
+	if err := database.DB.QueryRow("SELECT attempts FROM recurring_jobs WHERE name = ?", job.name).Scan(&attempt); err != nil {
+		return err
+	}
+
+	status, runErr := w.execute(job.name, nil, attempt, job.run)
+
+	next := job.cron.Next(now)
+	switch status {
+	case RunInterrupted:
+		// Run again soon, as if this attempt didn't happen
+		next = now
+		attempt--
+	case RunFailed:
+		if attempt < w.config.MaxAttempts {
+			if retryAt := now.Add(w.retryDelay(attempt)); retryAt.Before(next) {
+				next = retryAt
+			}
+		} else {
+			slog.Warn("Recurring job keeps failing, waiting for its next run", "worker", w.config.ID, "job", job.name, "attempts", attempt)
+			attempt = 0
+		}
+	default:
+		attempt = 0
+	}
+
+	_, err = database.DB.Exec(`
+		UPDATE recurring_jobs
+		SET next_run_at = ?, attempts = ?, locked_by = NULL, locked_until = NULL,
+			last_run_at = ?, last_status = ?, last_error = ?
+		WHERE name = ? AND locked_by = ?
+	`, formatTime(next), attempt, formatTime(now), status, errorString(runErr), job.name, w.config.ID)
+	return err
+}
+
+// runDueJobs runs the scheduled jobs whose time has come, oldest first,
+// along with those whose lease ran out while they were running
+func (w *Worker) runDueJobs() {
+	now := formatTime(time.Now())
+	rows, err := database.DB.Query(`
+		SELECT id, kind, job_key, payload, run_at, status, attempts
+		FROM scheduled_jobs
+		WHERE (status = ? AND run_at <= ?)
+		   OR (status = ? AND (locked_until IS NULL OR locked_until < ?))
+		ORDER BY run_at, id
+		LIMIT ?
+	`, StatusPending, now, StatusRunning, now, w.config.BatchSize)
+	if err != nil {
+		slog.Error("Failed to look up due jobs", "worker", w.config.ID, "error", err)
+		return
+	}
+
+	var due []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			slog.Error("Failed to read job", "worker", w.config.ID, "error", err)
+			continue
+		}
+		due = append(due, job)
+	}
+	rows.Close()
+
+	for _, job := range due {
+		if w.ctx.Err() != nil {
+			return
+		}
+		if err := w.runJob(job); err != nil {
+			slog.Error("Job failed", "worker", w.config.ID, "job_id", job.ID, "kind", job.Kind, "error", err)
+		}
+	}
+}
+
+// runJob leases a scheduled job, runs its handler and records the outcome
+func (w *Worker) runJob(job *Job) error {
+	now := time.Now()
+	// Leasing only succeeds while nobody else holds the job, and not once it was cancelled meanwhile
+	result, err := database.DB.Exec(`
+		UPDATE scheduled_jobs
+		SET status = ?, attempts = attempts + 1, locked_by = ?, locked_until = ?
+		WHERE id = ? AND (status = ? OR (status = ? AND (locked_until IS NULL OR locked_until < ?)))
+	`, StatusRunning, w.config.ID, formatTime(now.Add(w.config.LeaseDuration)),
+		job.ID, StatusPending, StatusRunning, formatTime(now))
+	if err != nil {
+		return err
+	}
+	if claimed, err := result.RowsAffected(); err != nil || claimed == 0 {
+		return err
+	}
+	job.Attempts++
+	job.Status = StatusRunning
+
+	handler, ok := w.handlers[job.Kind]
+	status, runErr := w.execute(job.Kind, &job.ID, job.Attempts, func(ctx context.Context) error {
+		if !ok {
+			return fmt.Errorf("no handler for jobs of kind %s", job.Kind)
+		}
+		return handler(ctx, job)
+	})
+
+	finishedAt := formatTime(time.Now())
+	switch {
+	case status == RunSucceeded:
+		_, err = database.DB.Exec(`
+			UPDATE scheduled_jobs SET status = ?, last_error = NULL, finished_at = ?, locked_by = NULL, locked_until = NULL
+			WHERE id = ? AND locked_by = ?
+		`, StatusDone, finishedAt, job.ID, w.config.ID)
+	case status == RunInterrupted:
+		_, err = database.DB.Exec(`
+			UPDATE scheduled_jobs SET status = ?, attempts = attempts - 1, locked_by = NULL, locked_until = NULL
+			WHERE id = ? AND locked_by = ?
+		`, StatusPending, job.ID, w.config.ID)
+	case !ok || job.Attempts >= w.config.MaxAttempts:
+		slog.Error("Job failed for good", "worker", w.config.ID, "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", runErr)
+		_, err = database.DB.Exec(`
+			UPDATE scheduled_jobs SET status = ?, last_error = ?, finished_at = ?, locked_by = NULL, locked_until = NULL
+			WHERE id = ? AND locked_by = ?
+		`, StatusFailed, runErr.Error(), finishedAt, job.ID, w.config.ID)
+	default:
+		retryAt := time.Now().Add(w.retryDelay(job.Attempts))
+		slog.Warn("Job failed, retrying", "worker", w.config.ID, "job_id", job.ID, "kind", job.Kind, "retry_at", formatTime(retryAt), "error", runErr)
+		_, err = database.DB.Exec(`
+			UPDATE scheduled_jobs SET status = ?, last_error = ?, run_at = ?, locked_by = NULL, locked_until = NULL
+			WHERE id = ? AND locked_by = ?
+		`, StatusPending, runErr.Error(), formatTime(retryAt), job.ID, w.config.ID)
+	}
+	return err
+}
+
+// execute runs fn for at most the lease duration and records the run
+func (w *Worker) execute(name string, jobID *int64, attempt int, fn TaskFunc) (RunStatus, error) {
+	startedAt := time.Now()
+	var runID int64
+	result, err := database.DB.Exec(`
+		INSERT INTO job_runs (job_name, job_id, attempt, worker_id, status, started_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, name, jobID, attempt, w.config.ID, RunRunning, formatTime(startedAt))
+	if err == nil {
+		runID, err = result.LastInsertId()
+	}
+	if err != nil {
+		slog.Error("Failed to record job run", "worker", w.config.ID, "job", name, "error", err)
+	}
+
+	// Each run is a trace of its own, holding the spans of the services it calls
+	ctx, span := tracing.Start(w.ctx, "job "+name, attribute.String("job.name", name), attribute.Int("job.attempt", attempt))
+	ctx, cancel := context.WithTimeout(ctx, w.config.LeaseDuration)
+	runErr := safeRun(ctx, fn)
+	cancel()
+	tracing.RecordError(span, runErr)
+	span.End()
+
+	status := RunSucceeded
+	switch {
+	case runErr == nil:
+	case w.ctx.Err() != nil:
+		status = RunInterrupted
+	default:
+		status = RunFailed
+	}
+
+	if runID != 0 {
+		finishedAt := time.Now()
+		_, err = database.DB.Exec(`
+			UPDATE job_runs SET status = ?, error = ?, finished_at = ?, duration_ms = ?
+			WHERE id = ?
+		`, status, errorString(runErr), formatTime(finishedAt), finishedAt.Sub(startedAt).Milliseconds(), runID)
+		if err != nil {
+			slog.Error("Failed to record the outcome of job run", "worker", w.config.ID, "run_id", runID, "error", err)
+		}
+	}
+	return status, runErr
+}
+
+// safeRun runs fn, turning a panic into an error so it doesn't take the worker down
+func safeRun(ctx context.Context, fn TaskFunc) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return fn(ctx)
+}
+
+// maxRetryDelay caps the delay between retries, however often a job failed
+const maxRetryDelay = 6 * time.Hour
+
+// retryDelay is the delay before retrying after the given attempt failed
+func (w *Worker) retryDelay(attempt int) time.Duration {
+	delay := w.config.RetryDelay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// scanJob reads a job selected by runDueJobs
+func scanJob(rows *sql.Rows) (*Job, error) {
+	var job Job
+	var payload sql.NullString
+	var runAtStr string
+	if err := rows.Scan(&job.ID, &job.Kind, &job.Key, &payload, &runAtStr, &job.Status, &job.Attempts); err != nil {
+		return nil, err
+	}
+	job.Payload = payload.String
+	job.RunAt, _ = time.Parse(time.RFC3339, runAtStr)
+	return &job, nil
+}
+
+// formatTime formats a time the way the job tables store it
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// errorString returns the message of err as a nullable column value
+func errorString(err error) sql.NullString {
+	if err == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: err.Error(), Valid: true}
+}
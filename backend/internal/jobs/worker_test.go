@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	w := NewWorker(WorkerConfig{RetryDelay: time.Minute})
+	defer w.cancel()
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{5, 16 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, maxRetryDelay},
+		{100, maxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		if got := w.retryDelay(tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelayDefault(t *testing.T) {
+	w := NewWorker(WorkerConfig{})
+	defer w.cancel()
+
+	if got := w.retryDelay(1); got != DefaultWorkerConfig.RetryDelay {
+		t.Errorf("retryDelay(1) with a zero RetryDelay = %v, want %v", got, DefaultWorkerConfig.RetryDelay)
+	}
+}
@@ -0,0 +1,180 @@
+// Package logging sets up structured logging: the level and format of the
+// output, the request ID on every line logged for a request and the
+// redaction of personal data.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDKey is the attribute holding the ID of the request a line was logged for
+const RequestIDKey = "request_id"
+
+// TraceIDKey and SpanIDKey are the attributes holding the trace and span a
+// line was logged in, when the request is traced
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// RedactedValue replaces personal data in log lines
+const RedactedValue = "[redacted]"
+
+// personalKeys are the attributes holding personal data, e.g. of attendees.
+// Log IDs rather than these where the ID is enough.
+var personalKeys = map[string]bool{
+	"name":       true,
+	"first_name": true,
+	"last_name":  true,
+	"username":   true,
+	"email":      true,
+	"to":         true,
+	"phone":      true,
+	"address":    true,
+	"title":      true,
+	"client_ip":  true,
+}
+
+// Config configures the logger
+type Config struct {
+	Level  slog.Level
+	JSON   bool // JSON lines rather than key=value text
+	Redact bool // Replace personal data with RedactedValue
+}
+
+// DefaultConfig logs info and up as text with personal data redacted
+var DefaultConfig = Config{
+	Level:  slog.LevelInfo,
+	Redact: true,
+}
+
+// ConfigFromEnv reads LOG_LEVEL (debug, info, warn or error), LOG_FORMAT
+// (text or json) and LOG_REDACT (false to log personal data, e.g. while
+// debugging locally)
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig
+
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		if err := config.Level.UnmarshalText([]byte(level)); err != nil {
+			return config, fmt.Errorf("invalid LOG_LEVEL %q", level)
+		}
+	}
+
+	switch format := strings.ToLower(os.Getenv("LOG_FORMAT")); format {
+	case "", "text":
+	case "json":
+		config.JSON = true
+	default:
+		return config, fmt.Errorf("invalid LOG_FORMAT %q, expected text or json", format)
+	}
+
+	switch redact := strings.ToLower(os.Getenv("LOG_REDACT")); redact {
+	case "", "true", "1":
+	case "false", "0":
+		config.Redact = false
+	default:
+		return config, fmt.Errorf("invalid LOG_REDACT %q, expected true or false", redact)
+	}
+
+	return config, nil
+}
+
+// New creates a logger writing to w. Lines logged with a context carrying a
+// request ID (see WithRequestID) or a recorded span include them.
+func New(w io.Writer, config Config) *slog.Logger {
+	options := &slog.HandlerOptions{Level: config.Level}
+	if config.Redact {
+		options.ReplaceAttr = redact
+	}
+
+	var handler slog.Handler
+	if config.JSON {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// redact replaces the values of personal attributes, including every
+// attribute within a personal group, and masks personal data in errors
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if personalKeys[a.Key] {
+		return slog.String(a.Key, RedactedValue)
+	}
+	for _, group := range groups {
+		if personalKeys[group] {
+			return slog.String(a.Key, RedactedValue)
+		}
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, RedactMessage(err.Error()))
+		}
+	case slog.KindString:
+		if a.Key == "error" {
+			return slog.String(a.Key, RedactMessage(a.Value.String()))
+		}
+	}
+	return a
+}
+
+// quotedPattern matches quoted strings, which is how errors usually include
+// the input they are about, e.g. "invalid value %q"
+var quotedPattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// emailPattern matches email addresses, e.g. in the replies of mail servers
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// RedactMessage masks the parts of an error message that may hold personal
+// data: quoted values and email addresses
+func RedactMessage(message string) string {
+	message = quotedPattern.ReplaceAllString(message, `"`+RedactedValue+`"`)
+	return emailPattern.ReplaceAllString(message, RedactedValue)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of a request
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID and span carried by the context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String(RequestIDKey, id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsSampled() {
+		r.AddAttrs(slog.String(TraceIDKey, span.TraceID().String()), slog.String(SpanIDKey, span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
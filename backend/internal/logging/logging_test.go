@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// logLine logs a single line as JSON and decodes it
+func logLine(t *testing.T, config Config, log func(logger *slog.Logger)) map[string]interface{} {
+	t.Helper()
+
+	var buf bytes.Buffer
+	config.JSON = true
+	log(New(&buf, config))
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("log output %q is not JSON: %v", buf.String(), err)
+	}
+	return line
+}
+
+func TestRedactPersonalKeys(t *testing.T) {
+	line := logLine(t, DefaultConfig, func(logger *slog.Logger) {
+		logger.Info("Registration created", "registration_id", 7, "first_name", "Ada", "email", "ada@example.com")
+	})
+
+	if line["first_name"] != RedactedValue || line["email"] != RedactedValue {
+		t.Errorf("personal attributes not redacted: %v", line)
+	}
+	if line["registration_id"] != float64(7) {
+		t.Errorf("registration_id = %v, want 7", line["registration_id"])
+	}
+}
+
+func TestRedactGroups(t *testing.T) {
+	line := logLine(t, DefaultConfig, func(logger *slog.Logger) {
+		logger.Info("Sending",
+			slog.Group("user", slog.Int64("id", 3), slog.String("email", "ada@example.com")),
+			slog.Group("to", slog.String("mailbox", "ada"), slog.String("domain", "example.com")),
+		)
+	})
+
+	user := line["user"].(map[string]interface{})
+	if user["email"] != RedactedValue || user["id"] != float64(3) {
+		t.Errorf("user group = %v, want the email redacted and the ID kept", user)
+	}
+	to := line["to"].(map[string]interface{})
+	if to["mailbox"] != RedactedValue || to["domain"] != RedactedValue {
+		t.Errorf("to group = %v, want everything in it redacted", to)
+	}
+}
+
+func TestRedactWithGroup(t *testing.T) {
+	line := logLine(t, DefaultConfig, func(logger *slog.Logger) {
+		logger.WithGroup("address").Info("Geocoding", "street", "Main St 1")
+	})
+
+	address := line["address"].(map[string]interface{})
+	if address["street"] != RedactedValue {
+		t.Errorf("address group = %v, want the street redacted", address)
+	}
+}
+
+func TestRedactErrors(t *testing.T) {
+	err := fmt.Errorf("geocode: %w", errors.New(`Get "https://geo.example/search?q=Main+St+1": timeout`))
+	line := logLine(t, DefaultConfig, func(logger *slog.Logger) {
+		logger.Error("Failed", "error", err, "cause", errors.New("550 ada@example.com: mailbox unavailable"))
+	})
+
+	if got := line["error"].(string); strings.Contains(got, "Main") || !strings.Contains(got, "timeout") {
+		t.Errorf("error = %q, want the quoted URL redacted and the rest kept", got)
+	}
+	if got := line["cause"].(string); strings.Contains(got, "ada@") {
+		t.Errorf("cause = %q, want the email address redacted", got)
+	}
+}
+
+func TestRedactDisabled(t *testing.T) {
+	config := DefaultConfig
+	config.Redact = false
+	line := logLine(t, config, func(logger *slog.Logger) {
+		logger.Info("Registration created", "first_name", "Ada")
+	})
+
+	if line["first_name"] != "Ada" {
+		t.Errorf("first_name = %v, want it logged as is", line["first_name"])
+	}
+}
+
+func TestRedactMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"event not found", "event not found"},
+		{`unknown webhook topic "x"`, `unknown webhook topic "[redacted]"`},
+		{`value "say \"hi\"" is invalid`, `value "[redacted]" is invalid`},
+		{"smtp: 550 ada.l@mail.example.org unknown", "smtp: 550 [redacted] unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := RedactMessage(tt.message); got != tt.want {
+			t.Errorf("RedactMessage(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	line := logLine(t, DefaultConfig, func(logger *slog.Logger) {
+		logger.InfoContext(WithRequestID(context.Background(), "req-1"), "Handled")
+	})
+
+	if line[RequestIDKey] != "req-1" {
+		t.Errorf("%s = %v, want req-1", RequestIDKey, line[RequestIDKey])
+	}
+}
@@ -0,0 +1,88 @@
+// Package metrics defines the Prometheus metrics of the server and serves
+// them for scraping.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "event_poster"
+
+// HTTP metrics, labelled by the route pattern rather than the path so IDs
+// in URLs don't create a series per resource
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to handle HTTP requests, by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// DBQueryDuration times the database work of the services, by operation
+var DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "db_query_duration_seconds",
+	Help:      "Time taken by database operations, by operation.",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+}, []string{"operation"})
+
+// Domain metrics, counted by the services once a change is committed
+var (
+	RegistrationsCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_created_total",
+		Help:      "Registrations created, by their initial status.",
+	}, []string{"status"})
+
+	RegistrationsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_rejected_total",
+		Help:      "Registrations refused, by reason.",
+	}, []string{"reason"})
+
+	EventsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_created_total",
+		Help:      "Events created, including the occurrences of series.",
+	})
+
+	EventsPublished = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_scheduled_published_total",
+		Help:      "Draft events published when their publish time came.",
+	})
+
+	EventsCompleted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_expired_completed_total",
+		Help:      "Events marked completed by the cleanup of expired events.",
+	})
+)
+
+// QueryTimer starts timing a database operation; call ObserveDuration when it is done
+func QueryTimer(operation string) *prometheus.Timer {
+	return prometheus.NewTimer(DBQueryDuration.WithLabelValues(operation))
+}
+
+// RegisterDB exports the connection pool statistics of db
+func RegisterDB(db *sql.DB, name string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
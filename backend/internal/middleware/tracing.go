@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netpo4ki/event-poster/internal/logging"
+	"github.com/netpo4ki/event-poster/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a span for every request, continuing the trace of the caller
+// when it sent a traceparent header. The span is carried by the context of the
+// request, so the spans of the services and queries serving it are its children.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		// Spans are named by the route pattern, like the metrics, and renamed
+		// once routing is done
+		ctx, span := tracing.Tracer().Start(ctx, c.Request.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.URLPath(c.Request.URL.Path),
+			))
+		defer span.End()
+
+		// The request ID ties the trace to the lines logged for the request
+		if requestID, ok := c.Get("request_id"); ok {
+			span.SetAttributes(attribute.String(logging.RequestIDKey, requestID.(string)))
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		if route := c.FullPath(); route != "" {
+			span.SetName(fmt.Sprintf("%s %s", c.Request.Method, route))
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if userID, ok := c.Get("user_id"); ok {
+			span.SetAttributes(semconv.EnduserID(fmt.Sprint(userID)))
+		}
+
+		// Client errors are the caller's fault, not a failure of the server
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		}
+	}
+}
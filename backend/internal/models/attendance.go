@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// NoShowLookback is how far back no-shows count against the no-show limit of events
+const NoShowLookback = 365 * 24 * time.Hour
+
+// AttendanceOutcome tells whether the holder of a ticket came to the event
+type AttendanceOutcome string
+
+const (
+	// AttendanceAttended registrations were checked in
+	AttendanceAttended AttendanceOutcome = "attended"
+	// AttendanceNoShow registrations weren't checked in at an event that used check-in
+	AttendanceNoShow AttendanceOutcome = "no_show"
+	// AttendanceUpcoming registrations are for events that haven't ended yet
+	AttendanceUpcoming AttendanceOutcome = "upcoming"
+	// AttendanceUntracked registrations are for events that ended without anybody checking in,
+	// so nothing is known about who came
+	AttendanceUntracked AttendanceOutcome = "untracked"
+)
+
+// EventAttendance compares the ticket holders of an event with who checked in.
+// No-shows are only counted once the event has ended and used check-in.
+type EventAttendance struct {
+	EventID        int64   `json:"event_id"`
+	Ended          bool    `json:"ended"`
+	Tracked        bool    `json:"tracked"`
+	Registrations  int     `json:"registrations"`
+	Heads          int     `json:"heads"`
+	CheckedIn      int     `json:"checked_in"`
+	CheckedInHeads int     `json:"checked_in_heads"`
+	NoShows        int     `json:"no_shows"`
+	NoShowHeads    int     `json:"no_show_heads"`
+	AttendanceRate float64 `json:"attendance_rate"` // Share of registrations checked in, 0 to 1
+}
+
+// AttendanceRecord is a registration of a user with whether they came
+type AttendanceRecord struct {
+	RegistrationID int64             `json:"registration_id"`
+	EventID        int64             `json:"event_id"`
+	EventTitle     string            `json:"event_title"`
+	EventDate      time.Time         `json:"event_date"`
+	EventEndDate   time.Time         `json:"event_end_date"`
+	Outcome        AttendanceOutcome `json:"outcome"`
+	CheckedInAt    *time.Time        `json:"checked_in_at,omitempty"`
+}
+
+// UserAttendance is the attendance history of a user, most recent events first
+type UserAttendance struct {
+	UserID         int64              `json:"user_id"`
+	Attended       int                `json:"attended"`
+	NoShows        int                `json:"no_shows"`
+	AttendanceRate float64            `json:"attendance_rate"` // Share of tracked events attended, 0 to 1
+	History        []AttendanceRecord `json:"history"`
+}
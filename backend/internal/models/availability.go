@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// SeatAvailability is the live state of the seats of an event, pushed to
+// clients watching the event
+type SeatAvailability struct {
+	EventID        int64             `json:"event_id"`
+	Status         EventStatus       `json:"status"`
+	Seats          int               `json:"seats"`
+	Registrations  int               `json:"registrations"` // Seats taken, guests included
+	AvailableSeats int               `json:"available_seats"`
+	Waitlist       int               `json:"waitlist"` // People whose application waits for the organizer without holding a seat
+	TicketTypes    []TicketTypeSeats `json:"ticket_types,omitempty"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// TicketTypeSeats is the seat availability of a public ticket type
+type TicketTypeSeats struct {
+	ID             int64  `json:"id"`
+	Name           string `json:"name"`
+	AvailableSeats int    `json:"available_seats"`
+}
+
+// EventRemoval tells live clients that an event was deleted
+type EventRemoval struct {
+	EventID int64 `json:"event_id"`
+}
+
+// MaxStreamEvents is the number of events one multi-event stream may watch
+const MaxStreamEvents = 100
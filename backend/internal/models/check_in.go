@@ -0,0 +1,158 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CheckInRequest represents the request body for checking in a ticket at the door
+type CheckInRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// CheckIn is the outcome of scanning a ticket. A duplicate scan reports the
+// time of the first check-in instead of checking the registration in again.
+type CheckIn struct {
+	RegistrationID int64     `json:"registration_id"`
+	EventID        int64     `json:"event_id"`
+	FirstName      string    `json:"first_name"`
+	LastName       string    `json:"last_name"`
+	GuestCount     int       `json:"guest_count"`
+	Guests         []Guest   `json:"guests,omitempty"`
+	TicketTypeName string    `json:"ticket_type_name,omitempty"`
+	CheckedInAt    time.Time `json:"checked_in_at"`
+	Duplicate      bool      `json:"duplicate"`
+}
+
+// CheckInStats counts the registrations of an event that arrived so far.
+// Checking in a registration checks in its guests as well.
+type CheckInStats struct {
+	EventID        int64 `json:"event_id"`
+	Registrations  int   `json:"registrations"`
+	Heads          int   `json:"heads"`
+	CheckedIn      int   `json:"checked_in"`
+	CheckedInHeads int   `json:"checked_in_heads"`
+}
+
+// MaxCheckInSyncRecords caps how many offline check-in records a device uploads at once
+const MaxCheckInSyncRecords = 500
+
+// CheckInSyncResult is the outcome of reconciling an offline check-in record
+type CheckInSyncResult string
+
+const (
+	// SyncAccepted records are the earliest scan of their ticket and checked it in
+	SyncAccepted CheckInSyncResult = "accepted"
+	// SyncDuplicate records scanned a ticket that an earlier scan already checked in
+	SyncDuplicate CheckInSyncResult = "duplicate"
+	// SyncInvalid records scanned a code that isn't a ticket of ours
+	SyncInvalid CheckInSyncResult = "invalid"
+	// SyncWrongEvent records scanned a ticket of another event
+	SyncWrongEvent CheckInSyncResult = "wrong_event"
+	// SyncRevoked records scanned a ticket whose registration was cancelled or rejected
+	SyncRevoked CheckInSyncResult = "revoked"
+)
+
+// ManifestTicket is a valid ticket as listed in a check-in manifest. Scanners
+// match the SHA-256 hash of a scanned code, so the manifest can't be used to
+// print tickets.
+type ManifestTicket struct {
+	RegistrationID  int64      `json:"registration_id"`
+	CodeHash        string     `json:"code_hash"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	GuestCount      int        `json:"guest_count"`
+	TicketTypeName  string     `json:"ticket_type_name,omitempty"`
+	CheckedInAt     *time.Time `json:"checked_in_at,omitempty"`
+	CheckedInDevice string     `json:"checked_in_device,omitempty"`
+}
+
+// CheckInManifest is a snapshot of the valid tickets of an event for scanning offline
+type CheckInManifest struct {
+	EventID     int64            `json:"event_id"`
+	EventTitle  string           `json:"event_title"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Tickets     []ManifestTicket `json:"tickets"`
+}
+
+// SignedCheckInManifest is a manifest with the signature of its JSON encoding.
+// Devices verify it offline with the published ManifestKey.
+type SignedCheckInManifest struct {
+	Manifest  CheckInManifest `json:"manifest"`
+	Algorithm string          `json:"algorithm"`
+	Signature string          `json:"signature"`
+}
+
+// ManifestKey is the public key check-in manifests are signed with
+type ManifestKey struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"` // Base64 (URL alphabet, unpadded)
+}
+
+// CheckInOpensBefore is how long before an event starts its doors may open.
+// Offline scans claiming to be older are moved to that time, so a device with
+// a wrong or tampered clock can't win over scans made at the door.
+const CheckInOpensBefore = 6 * time.Hour
+
+// CheckInRecord is a scan made on a device while offline. Its ID is chosen by
+// the device and makes uploading the same record again harmless.
+type CheckInRecord struct {
+	ID        string    `json:"id"`
+	Code      string    `json:"code"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+// CheckInSyncRequest represents the request body for uploading offline check-ins
+type CheckInSyncRequest struct {
+	DeviceID string          `json:"device_id" binding:"required"`
+	Records  []CheckInRecord `json:"records"`
+}
+
+// CheckInSyncRecordResult tells a device how one of its records was reconciled.
+// For duplicates CheckedInAt and CheckedInDevice describe the scan that won.
+type CheckInSyncRecordResult struct {
+	ID              string            `json:"id"`
+	Result          CheckInSyncResult `json:"result"`
+	RegistrationID  *int64            `json:"registration_id,omitempty"`
+	CheckedInAt     *time.Time        `json:"checked_in_at,omitempty"`
+	CheckedInDevice string            `json:"checked_in_device,omitempty"`
+	Replayed        bool              `json:"replayed"`
+}
+
+// CheckInSyncResponse is the reconciliation of an uploaded batch
+type CheckInSyncResponse struct {
+	EventID int64                     `json:"event_id"`
+	Results []CheckInSyncRecordResult `json:"results"`
+	Stats   CheckInStats              `json:"stats"`
+}
+
+// Validate performs validation on the check-in sync request
+func (r *CheckInSyncRequest) Validate() error {
+	r.DeviceID = strings.TrimSpace(r.DeviceID)
+	if r.DeviceID == "" || len(r.DeviceID) > 100 {
+		return errors.New("device ID is required and can't be longer than 100 characters")
+	}
+	if len(r.Records) == 0 {
+		return errors.New("at least one check-in record is required")
+	}
+	if len(r.Records) > MaxCheckInSyncRecords {
+		return fmt.Errorf("at most %d check-in records can be uploaded at once", MaxCheckInSyncRecords)
+	}
+
+	seen := make(map[string]bool, len(r.Records))
+	for _, record := range r.Records {
+		if record.ID == "" || len(record.ID) > 100 {
+			return errors.New("check-in records need an ID of at most 100 characters")
+		}
+		if seen[record.ID] {
+			return fmt.Errorf("check-in record %q is included more than once", record.ID)
+		}
+		seen[record.ID] = true
+		if record.ScannedAt.IsZero() {
+			return fmt.Errorf("check-in record %q needs the time it was scanned", record.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Types of the messages sent to organizer dashboards
+const (
+	// DashboardRegistrationCreated carries the new Registration
+	DashboardRegistrationCreated = "registration.created"
+	// DashboardRegistrationCancelled carries the cancelled Registration
+	DashboardRegistrationCancelled = "registration.cancelled"
+	// DashboardCheckIn carries a CheckInUpdate
+	DashboardCheckIn = "check_in"
+	// DashboardFeedback carries the new Feedback
+	DashboardFeedback = "feedback"
+
+	// DashboardSubscribed confirms a subscribe or unsubscribe command with the events now watched
+	DashboardSubscribed = "subscribed"
+	// DashboardError reports a command that couldn't be carried out
+	DashboardError = "error"
+)
+
+// DashboardTopics lists the types of the messages about events
+var DashboardTopics = []string{
+	DashboardRegistrationCreated,
+	DashboardRegistrationCancelled,
+	DashboardCheckIn,
+	DashboardFeedback,
+}
+
+// Commands dashboard clients send
+const (
+	// DashboardSubscribe starts watching events
+	DashboardSubscribe = "subscribe"
+	// DashboardUnsubscribe stops watching events
+	DashboardUnsubscribe = "unsubscribe"
+)
+
+// DashboardCommand is a message from a dashboard client
+type DashboardCommand struct {
+	Type     string  `json:"type"`
+	EventIDs []int64 `json:"event_ids"`
+}
+
+// DashboardMessage is a message to a dashboard client
+type DashboardMessage struct {
+	Type     string          `json:"type"`
+	EventID  int64           `json:"event_id,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	EventIDs []int64         `json:"event_ids,omitempty"` // Watched events, for subscribed messages
+	Error    string          `json:"error,omitempty"`
+	SentAt   time.Time       `json:"sent_at"`
+}
+
+// CheckInUpdate tells a dashboard about a check-in along with the new totals
+type CheckInUpdate struct {
+	CheckIn CheckIn      `json:"check_in"`
+	Stats   CheckInStats `json:"stats"`
+}
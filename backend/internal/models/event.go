@@ -2,7 +2,13 @@ package models
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/netpo4ki/event-poster/internal/geo"
 )
 
 // Event represents an event in the system
@@ -13,22 +19,129 @@ type Event struct {
 	Location    string    `json:"location"`
 	EventType   string    `json:"event_type"`
 	EventDate   time.Time `json:"event_date"`
+	EndDate     time.Time `json:"end_date"`
+	Timezone    string    `json:"timezone"`
 	Seats       int       `json:"seats"`
+	MaxGuests   int       `json:"max_guests_per_registration"`
+	MaxNoShows  int       `json:"max_no_shows"`
 	CreatorID   int64     `json:"creator_id"`
-	CreatedAt   time.Time `json:"created_at"`
+
+	// ReminderOffsets are the minutes before the start at which attendees are reminded
+	ReminderOffsets []int `json:"reminder_offsets"`
+
+	RequiresApproval    bool `json:"requires_approval"`
+	ReservePendingSeats bool `json:"reserve_pending_seats"`
+	RegistrationWindow
+
+	VenueID      *int64      `json:"venue_id,omitempty"`
+	SeriesID     *int64      `json:"series_id,omitempty"`
+	Latitude     *float64    `json:"latitude,omitempty"`
+	Longitude    *float64    `json:"longitude,omitempty"`
+	Status       EventStatus `json:"status"`
+	StatusReason string      `json:"status_reason,omitempty"`
+	PublishAt    *time.Time  `json:"publish_at,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+
+	// AverageRating is the mean of the feedback ratings, nil until the first one
+	AverageRating *float64 `json:"average_rating"`
+	RatingsCount  int      `json:"ratings_count"`
+
+	// DistanceKm is only set when events are searched near a point
+	DistanceKm *float64 `json:"distance_km,omitempty"`
 }
 
 // EventRequest represents the request body for creating or updating an event
 type EventRequest struct {
-	Title       string    `json:"title" binding:"required"`
-	Description string    `json:"description"`
-	Location    string    `json:"location"`
-	EventType   string    `json:"event_type" binding:"required"`
-	EventDate   time.Time `json:"event_date" binding:"required"`
-	Seats       int       `json:"seats" binding:"required"`
+	Title       string     `json:"title" binding:"required"`
+	Description string     `json:"description"`
+	Location    string     `json:"location"`
+	EventType   string     `json:"event_type" binding:"required"`
+	EventDate   time.Time  `json:"event_date" binding:"required"`
+	EndDate     *time.Time `json:"end_date"` // Defaults to EventDate plus DefaultEventDuration
+	Timezone    string     `json:"timezone"` // IANA zone name, defaults to UTC
+	Seats       int        `json:"seats" binding:"required"`
+	MaxGuests   int        `json:"max_guests_per_registration"` // Guests each registration may bring, 0 for none
+	MaxNoShows  int        `json:"max_no_shows"`                // Users with this many recent no-shows can't register, 0 for no limit
+	VenueID     *int64     `json:"venue_id"`
+
+	// ReminderOffsets are the minutes before the start at which attendees are
+	// reminded. Defaults to DefaultReminderOffsets; an empty list sends none.
+	ReminderOffsets []int `json:"reminder_offsets"`
+
+	// RequiresApproval makes registrations pending until the organizer approves them.
+	// ReservePendingSeats lets pending registrations hold their seats meanwhile.
+	RequiresApproval    bool `json:"requires_approval"`
+	ReservePendingSeats bool `json:"reserve_pending_seats"`
+
+	// RegistrationWindow limits registering and cancelling; for series it applies to
+	// the first occurrence and moves along with the later ones
+	RegistrationWindow
+
+	Status    EventStatus `json:"status"`     // draft or published, defaults to published
+	PublishAt *time.Time  `json:"publish_at"` // Publishes a draft automatically at this time
+
+	// AllowVenueConflict accepts overlapping another event at the same venue instead of rejecting it
+	AllowVenueConflict bool `json:"allow_venue_conflict"`
+}
+
+// EventFilter narrows down the events returned by a listing
+type EventFilter struct {
+	HappeningNow bool       // Only events that have started and not yet ended
+	Near         *geo.Point // Only events within RadiusKm of this point, nearest first
+	RadiusKm     float64
+}
+
+// DefaultSearchRadiusKm is used for geo searches that don't specify a radius
+const DefaultSearchRadiusKm = 10
+
+// MaxSearchRadiusKm caps the radius of geo searches
+const MaxSearchRadiusKm = 500
+
+// DefaultTimezone is used for events created without an explicit time zone
+const DefaultTimezone = "UTC"
+
+// DefaultEventDuration is used for events created without an explicit end date
+const DefaultEventDuration = 2 * time.Hour
+
+// DefaultReminderOffsets remind attendees a day and an hour before an event starts
+var DefaultReminderOffsets = []int{24 * 60, 60}
+
+// MaxReminders caps the number of reminders per event
+const MaxReminders = 5
+
+// MaxReminderOffset is the earliest a reminder can be sent, in minutes before the start
+const MaxReminderOffset = 30 * 24 * 60
+
+// Normalize fills in the defaults of the event request: the time zone, the end
+// date, the reminders and the status. Times of the registration window are
+// stored in UTC and reminders are sorted, earliest first.
+func (r *EventRequest) Normalize() {
+	if r.Timezone == "" {
+		r.Timezone = DefaultTimezone
+	}
+	if r.EndDate == nil {
+		endDate := r.EventDate.Add(DefaultEventDuration)
+		r.EndDate = &endDate
+	}
+	r.RegistrationWindow.normalize()
+
+	if r.ReminderOffsets == nil {
+		r.ReminderOffsets = append([]int(nil), DefaultReminderOffsets...)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(r.ReminderOffsets)))
+
+	// Events are published immediately unless they are scheduled with publish_at
+	if r.Status == "" {
+		r.Status = EventPublished
+		if r.PublishAt != nil {
+			r.Status = EventDraft
+		}
+	}
 }
 
-// Validate performs validation on the event request
+// Validate performs validation on a normalized event request. Whether the event
+// date is in the past is only checked by ValidateEventDate, as existing events
+// that have started may still be edited.
 func (r *EventRequest) Validate() error {
 	if r.Title == "" {
 		return errors.New("title is required")
@@ -37,19 +150,75 @@ func (r *EventRequest) Validate() error {
 		return errors.New("event type is required")
 	}
 
-	// Allow dates that are a full day in the past to handle time zone differences and ensure
-	// events being created for "tomorrow" don't get marked as expired
-	oneDayAgo := time.Now().Add(-24 * time.Hour)
-	if r.EventDate.Before(oneDayAgo) {
-		return errors.New("event date must be no more than one day in the past")
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		return errors.New("timezone must be a valid IANA time zone name")
+	}
+
+	if r.EndDate == nil {
+		return errors.New("end date is required")
+	}
+	if !r.EndDate.After(r.EventDate) {
+		return errors.New("end date must be after the event date")
+	}
+	if err := r.RegistrationWindow.validate(*r.EndDate); err != nil {
+		return err
 	}
 
 	if r.Seats <= 0 {
 		return errors.New("number of seats must be greater than zero")
 	}
+	if r.MaxGuests < 0 {
+		return errors.New("max guests per registration must not be negative")
+	}
+	if r.MaxNoShows < 0 {
+		return errors.New("max no-shows must not be negative")
+	}
+	if err := r.validateReminderOffsets(); err != nil {
+		return err
+	}
+	if r.ReservePendingSeats && !r.RequiresApproval {
+		return errors.New("pending seats can only be reserved for events that require approval")
+	}
+	return r.validateInitialStatus()
+}
+
+// ValidateEventDate checks the date an event is created with or moved to, which
+// must not be in the past
+func (r *EventRequest) ValidateEventDate(now time.Time) error {
+	// EventDate carries its own offset, so comparing instants is exact regardless of zone
+	if r.EventDate.Before(now) {
+		return errors.New("event date must not be in the past")
+	}
+	return nil
+}
+
+// validateReminderOffsets checks the reminders of the event
+func (r *EventRequest) validateReminderOffsets() error {
+	if len(r.ReminderOffsets) > MaxReminders {
+		return fmt.Errorf("an event can have at most %d reminders", MaxReminders)
+	}
+
+	seen := make(map[int]bool, len(r.ReminderOffsets))
+	for _, offset := range r.ReminderOffsets {
+		if offset <= 0 || offset > MaxReminderOffset {
+			return fmt.Errorf("reminder offsets must be between 1 and %d minutes", MaxReminderOffset)
+		}
+		if seen[offset] {
+			return errors.New("reminder offsets must not repeat")
+		}
+		seen[offset] = true
+	}
 	return nil
 }
 
+// Duration returns how long the requested event lasts
+func (r *EventRequest) Duration() time.Duration {
+	if r.EndDate == nil {
+		return DefaultEventDuration
+	}
+	return r.EndDate.Sub(r.EventDate)
+}
+
 // ToEvent converts an EventRequest to an Event
 func (r *EventRequest) ToEvent() *Event {
 	return &Event{
@@ -57,9 +226,111 @@ func (r *EventRequest) ToEvent() *Event {
 		Description: r.Description,
 		Location:    r.Location,
 		EventType:   r.EventType,
-		EventDate:   r.EventDate,
+		EventDate:   r.EventDate.UTC(),
+		EndDate:     r.EventDate.Add(r.Duration()).UTC(),
+		Timezone:    r.Timezone,
 		Seats:       r.Seats,
+		MaxGuests:   r.MaxGuests,
+		MaxNoShows:  r.MaxNoShows,
+
+		ReminderOffsets:     r.ReminderOffsets,
+		RequiresApproval:    r.RequiresApproval,
+		ReservePendingSeats: r.ReservePendingSeats,
+		RegistrationWindow:  r.RegistrationWindow,
+		VenueID:             r.VenueID,
+		Status:              r.Status,
+		PublishAt:           r.PublishAt,
+	}
+}
+
+// TimeZone returns the time zone the event takes place in
+func (e *Event) TimeZone() *time.Location {
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// LocalEventDate returns the event date as wall time in the event's time zone
+func (e *Event) LocalEventDate() time.Time {
+	return e.EventDate.In(e.TimeZone())
+}
+
+// LocalEventDate returns the requested event date as wall time in the requested time zone.
+// Occurrences of a series are expanded from it so they keep the same local time across DST changes.
+func (r *EventRequest) LocalEventDate() time.Time {
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return r.EventDate
+	}
+	return r.EventDate.In(loc)
+}
+
+// ShiftWallClock moves t by the change of local date and time of day from from
+// to to, and places the result in the time zone of to. Occurrences of a series
+// moved along with one of them this way keep their wall-clock time even when
+// a DST change lies between them.
+func ShiftWallClock(t, from, to time.Time) time.Time {
+	shifted := wallClock(t).Add(wallClock(to).Sub(wallClock(from)))
+	return time.Date(shifted.Year(), shifted.Month(), shifted.Day(),
+		shifted.Hour(), shifted.Minute(), shifted.Second(), shifted.Nanosecond(), to.Location())
+}
+
+// wallClock returns the local date and time of t as if it were UTC, where
+// every day is 24 hours long
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// LocalEndDate returns the end date as wall time in the event's time zone
+func (e *Event) LocalEndDate() time.Time {
+	return e.EndDate.In(e.TimeZone())
+}
+
+// IsMultiDay reports whether the event ends on a later calendar day than it starts, in its own time zone
+func (e *Event) IsMultiDay() bool {
+	start := e.LocalEventDate()
+	end := e.LocalEndDate()
+	return start.Format("2006-01-02") != end.Format("2006-01-02")
+}
+
+// HasEnded reports whether the event is over at the given instant
+func (e *Event) HasEnded(now time.Time) bool {
+	return !e.EndDate.After(now)
+}
+
+// IsHappeningAt reports whether the event has started but not yet ended at the given instant
+func (e *Event) IsHappeningAt(now time.Time) bool {
+	return !e.EventDate.After(now) && e.EndDate.After(now)
+}
+
+// Coordinates returns the position of the event, if it is known
+func (e *Event) Coordinates() *geo.Point {
+	if e.Latitude == nil || e.Longitude == nil {
+		return nil
+	}
+	return &geo.Point{Lat: *e.Latitude, Lng: *e.Longitude}
+}
+
+// FormatReminderOffsets stores reminder offsets as a comma separated list of minutes
+func FormatReminderOffsets(offsets []int) string {
+	values := make([]string, len(offsets))
+	for i, offset := range offsets {
+		values[i] = strconv.Itoa(offset)
+	}
+	return strings.Join(values, ",")
+}
+
+// ParseReminderOffsets reads reminder offsets stored by FormatReminderOffsets
+func ParseReminderOffsets(value string) []int {
+	offsets := []int{}
+	for _, field := range strings.Split(value, ",") {
+		if offset, err := strconv.Atoi(strings.TrimSpace(field)); err == nil {
+			offsets = append(offsets, offset)
+		}
 	}
+	return offsets
 }
 
 // AvailableSeats returns the number of available seats for the event
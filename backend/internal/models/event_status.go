@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// EventStatus represents the stage of an event's lifecycle
+type EventStatus string
+
+const (
+	// EventDraft is an event that is not visible to attendees yet
+	EventDraft EventStatus = "draft"
+	// EventPublished is an event that is listed and open for registration
+	EventPublished EventStatus = "published"
+	// EventCancelled is an event that won't take place
+	EventCancelled EventStatus = "cancelled"
+	// EventPostponed is an event that is on hold until it gets a new date
+	EventPostponed EventStatus = "postponed"
+	// EventCompleted is an event that has taken place
+	EventCompleted EventStatus = "completed"
+)
+
+// eventTransitions lists the statuses each status may move to
+var eventTransitions = map[EventStatus][]EventStatus{
+	EventDraft:     {EventPublished, EventCancelled},
+	EventPublished: {EventCancelled, EventPostponed, EventCompleted},
+	EventPostponed: {EventPublished, EventCancelled},
+}
+
+// IsValid reports whether the status is one of the known lifecycle stages
+func (s EventStatus) IsValid() bool {
+	switch s {
+	case EventDraft, EventPublished, EventCancelled, EventPostponed, EventCompleted:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether an event may move from this status to next
+func (s EventStatus) CanTransitionTo(next EventStatus) bool {
+	for _, allowed := range eventTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEditable reports whether an event with this status may still be changed
+func (s EventStatus) IsEditable() bool {
+	return s != EventCancelled && s != EventCompleted
+}
+
+// StatusChangeRequest represents the request body for moving an event to another status
+type StatusChangeRequest struct {
+	Status EventStatus `json:"status" binding:"required"`
+	Reason string      `json:"reason"`
+}
+
+// Validate performs validation on the status change request
+func (r *StatusChangeRequest) Validate() error {
+	if !r.Status.IsValid() {
+		return errors.New("status must be one of draft, published, cancelled, postponed or completed")
+	}
+	return nil
+}
+
+// validateInitialStatus checks the status an event is created or edited with
+func (r *EventRequest) validateInitialStatus() error {
+	if r.Status != EventDraft && r.Status != EventPublished {
+		return errors.New("events can only be created as draft or published")
+	}
+
+	if r.PublishAt != nil {
+		if r.Status != EventDraft {
+			return errors.New("publish_at can only be set on draft events")
+		}
+		if r.EndDate != nil && !r.PublishAt.Before(*r.EndDate) {
+			return errors.New("publish_at must be before the event ends")
+		}
+	}
+	return nil
+}
+
+// IsPubliclyVisible reports whether attendees can see the event
+func (e *Event) IsPubliclyVisible() bool {
+	return e.Status != EventDraft
+}
+
+// ShouldPublish reports whether a scheduled draft is due for publication at the given instant
+func (e *Event) ShouldPublish(now time.Time) bool {
+	return e.Status == EventDraft && e.PublishAt != nil && !e.PublishAt.After(now)
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShiftWallClock(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	// Berlin switches to summer time on the 29th of March 2026
+	from := time.Date(2026, time.March, 20, 19, 0, 0, 0, berlin)
+	later := time.Date(2026, time.April, 3, 19, 0, 0, 0, berlin)
+
+	tests := []struct {
+		name string
+		to   time.Time
+		want time.Time
+	}{
+		{"an hour later", time.Date(2026, time.March, 20, 20, 0, 0, 0, berlin), time.Date(2026, time.April, 3, 20, 0, 0, 0, berlin)},
+		{"a day earlier", time.Date(2026, time.March, 19, 19, 0, 0, 0, berlin), time.Date(2026, time.April, 2, 19, 0, 0, 0, berlin)},
+		{"across the DST change", time.Date(2026, time.March, 30, 19, 0, 0, 0, berlin), time.Date(2026, time.April, 13, 19, 0, 0, 0, berlin)},
+		{"unchanged", from, later},
+		{"other time zone", time.Date(2026, time.March, 20, 19, 0, 0, 0, london), time.Date(2026, time.April, 3, 19, 0, 0, 0, london)},
+	}
+
+	for _, tt := range tests {
+		if got := ShiftWallClock(later, from, tt.to); !got.Equal(tt.want) {
+			t.Errorf("%s: ShiftWallClock = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEventRequestNormalize(t *testing.T) {
+	eventDate := time.Date(2026, time.May, 9, 18, 0, 0, 0, time.UTC)
+	publishAt := eventDate.Add(-24 * time.Hour)
+	req := EventRequest{Title: "Meetup", EventType: "meetup", EventDate: eventDate, Seats: 10, PublishAt: &publishAt}
+
+	// Validate doesn't fill in anything, so a request that wasn't normalized fails
+	if err := req.Validate(); err == nil {
+		t.Error("Validate() of a request without an end date succeeded")
+	}
+	if req.Timezone != "" || req.EndDate != nil || req.ReminderOffsets != nil || req.Status != "" {
+		t.Errorf("Validate() changed the request: %+v", req)
+	}
+
+	req.Normalize()
+	if req.Timezone != DefaultTimezone {
+		t.Errorf("Timezone = %q, want %q", req.Timezone, DefaultTimezone)
+	}
+	if req.EndDate == nil || !req.EndDate.Equal(eventDate.Add(DefaultEventDuration)) {
+		t.Errorf("EndDate = %v, want %v", req.EndDate, eventDate.Add(DefaultEventDuration))
+	}
+	if len(req.ReminderOffsets) != len(DefaultReminderOffsets) {
+		t.Errorf("ReminderOffsets = %v, want %v", req.ReminderOffsets, DefaultReminderOffsets)
+	}
+	if req.Status != EventDraft {
+		t.Errorf("Status of a scheduled event = %q, want %q", req.Status, EventDraft)
+	}
+	if err := req.Validate(); err != nil {
+		t.Errorf("Validate() of the normalized request failed: %v", err)
+	}
+}
+
+func TestEventRequestValidateEventDate(t *testing.T) {
+	now := time.Date(2026, time.May, 9, 18, 0, 0, 0, time.UTC)
+	req := EventRequest{Title: "Meetup", EventType: "meetup", EventDate: now.Add(-time.Hour), Seats: 10}
+	req.Normalize()
+
+	// Events that have started are valid, they just can't be created or moved there
+	if err := req.Validate(); err != nil {
+		t.Errorf("Validate() of an event that has started failed: %v", err)
+	}
+	if err := req.ValidateEventDate(now); err == nil {
+		t.Error("ValidateEventDate() of a past date succeeded")
+	}
+	req.EventDate = now.Add(time.Hour)
+	if err := req.ValidateEventDate(now); err != nil {
+		t.Errorf("ValidateEventDate() of a future date failed: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// FeedbackWindow is how long after an event ends its attendees can leave feedback
+const FeedbackWindow = 14 * 24 * time.Hour
+
+// MaxFeedbackCommentLength caps the length of feedback comments
+const MaxFeedbackCommentLength = 2000
+
+// Feedback is the rating an attendee gave an event after it ended
+type Feedback struct {
+	ID             int64     `json:"id"`
+	EventID        int64     `json:"event_id"`
+	RegistrationID int64     `json:"registration_id"`
+	UserID         int64     `json:"user_id"`
+	Rating         int       `json:"rating"`
+	Comment        string    `json:"comment,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// FeedbackRequest represents the request body for leaving feedback on an event
+type FeedbackRequest struct {
+	Rating  int    `json:"rating" binding:"required"`
+	Comment string `json:"comment"`
+}
+
+// RatingSummary aggregates the ratings of one or more events. Distribution
+// counts the ratings given for each score from 1 to 5.
+type RatingSummary struct {
+	Count        int         `json:"count"`
+	Average      *float64    `json:"average"`
+	Distribution map[int]int `json:"distribution"`
+}
+
+// EventFeedback is the feedback on an event as shown to its organizer
+type EventFeedback struct {
+	EventID  int64         `json:"event_id"`
+	Summary  RatingSummary `json:"summary"`
+	Feedback []Feedback    `json:"feedback"`
+}
+
+// EventRatingSummary is the rating summary of a single event
+type EventRatingSummary struct {
+	EventID    int64         `json:"event_id"`
+	EventTitle string        `json:"event_title"`
+	EventDate  time.Time     `json:"event_date"`
+	Summary    RatingSummary `json:"summary"`
+}
+
+// OrganizerFeedback summarizes the ratings across the events of an organizer
+type OrganizerFeedback struct {
+	Overall RatingSummary        `json:"overall"`
+	Events  []EventRatingSummary `json:"events"`
+}
+
+// Validate performs validation on the feedback request
+func (r *FeedbackRequest) Validate() error {
+	if r.Rating < 1 || r.Rating > 5 {
+		return errors.New("rating must be between 1 and 5")
+	}
+	r.Comment = strings.TrimSpace(r.Comment)
+	if len(r.Comment) > MaxFeedbackCommentLength {
+		return errors.New("comment is too long")
+	}
+	return nil
+}
+
+// NewRatingSummary returns an empty summary with every score in its distribution
+func NewRatingSummary() RatingSummary {
+	return RatingSummary{Distribution: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+}
+
+// Add counts ratings of the given score in the summary
+func (s *RatingSummary) Add(rating, count int) {
+	s.Count += count
+	s.Distribution[rating] += count
+
+	total := 0
+	for score, n := range s.Distribution {
+		total += score * n
+	}
+	if s.Count > 0 {
+		average := float64(total) / float64(s.Count)
+		s.Average = &average
+	}
+}
+
+// FeedbackOpenAt reports whether attendees can leave feedback on the event at
+// the given time, which is during FeedbackWindow after it ended
+func (e *Event) FeedbackOpenAt(now time.Time) bool {
+	return e.HasEnded(now) && now.Before(e.EndDate.Add(FeedbackWindow))
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// NotificationChannel is where notifications of a kind are delivered
+type NotificationChannel string
+
+const (
+	// ChannelEmail notifications are only emailed
+	ChannelEmail NotificationChannel = "email"
+	// ChannelInApp notifications are only put into the inbox
+	ChannelInApp NotificationChannel = "in_app"
+	// ChannelBoth notifications are emailed and put into the inbox
+	ChannelBoth NotificationChannel = "both"
+)
+
+// DefaultNotificationChannel is used for the kinds a user didn't pick a channel for
+const DefaultNotificationChannel = ChannelBoth
+
+// IsValid reports whether c is a known channel
+func (c NotificationChannel) IsValid() bool {
+	return c == ChannelEmail || c == ChannelInApp || c == ChannelBoth
+}
+
+// Email reports whether notifications sent through c are emailed
+func (c NotificationChannel) Email() bool {
+	return c == ChannelEmail || c == ChannelBoth
+}
+
+// InApp reports whether notifications sent through c are put into the inbox
+func (c NotificationChannel) InApp() bool {
+	return c == ChannelInApp || c == ChannelBoth
+}
+
+// InboxNotification is a notification in the in-app inbox of a user
+type InboxNotification struct {
+	ID             int64      `json:"id"`
+	Kind           string     `json:"kind"`
+	Title          string     `json:"title"`
+	Body           string     `json:"body"`
+	EventID        *int64     `json:"event_id,omitempty"`
+	RegistrationID *int64     `json:"registration_id,omitempty"`
+	Read           bool       `json:"read"`
+	ReadAt         *time.Time `json:"read_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Default and largest number of inbox notifications listed at once
+const (
+	DefaultNotificationsLimit = 20
+	MaxNotificationsLimit     = 100
+)
+
+// NotificationFilter narrows down the inbox notifications listed, newest first
+type NotificationFilter struct {
+	UnreadOnly bool
+	BeforeID   int64 // Only notifications older than this one, for paging
+	Limit      int
+}
+
+// NotificationPreference is the channel a user picked for a kind of notification
+type NotificationPreference struct {
+	Kind    string              `json:"kind"`
+	Channel NotificationChannel `json:"channel"`
+}
+
+// NotificationPreferencesRequest represents the request body for changing
+// notification preferences; kinds that aren't listed keep their channel
+type NotificationPreferencesRequest struct {
+	Preferences map[string]NotificationChannel `json:"preferences" binding:"required"`
+}
+
+// Validate checks the channels; the kinds are checked by the service
+func (r *NotificationPreferencesRequest) Validate() error {
+	for kind, channel := range r.Preferences {
+		if !channel.IsValid() {
+			return fmt.Errorf("channel of %s must be email, in_app or both", kind)
+		}
+	}
+	return nil
+}
+
+// CurrentUser is the signed in user along with the state of their inbox
+type CurrentUser struct {
+	User
+	UnreadNotifications int `json:"unread_notifications"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// DefaultJobRunsLimit is the number of job runs listed when no limit is given
+const DefaultJobRunsLimit = 50
+
+// MaxJobRunsLimit caps the number of job runs listed at once
+const MaxJobRunsLimit = 500
+
+// JobRun is a run of a background job
+type JobRun struct {
+	ID         int64      `json:"id"`
+	JobName    string     `json:"job_name"`         // Kind of a scheduled job, or name of a recurring one
+	JobID      *int64     `json:"job_id,omitempty"` // Only set for scheduled jobs
+	Attempt    int        `json:"attempt"`
+	WorkerID   string     `json:"worker_id"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMs *int64     `json:"duration_ms,omitempty"`
+}
+
+// JobRunFilter narrows down the job runs listed, newest first
+type JobRunFilter struct {
+	JobName  string
+	Status   string
+	BeforeID int64 // Only runs older than this one, for paging
+	Limit    int
+}
+
+// RecurringJob is a job repeated on a cron schedule
+type RecurringJob struct {
+	Name        string     `json:"name"`
+	Schedule    string     `json:"schedule"`
+	NextRunAt   time.Time  `json:"next_run_at"`
+	Attempts    int        `json:"attempts"` // Failed attempts since the last success
+	LockedBy    string     `json:"locked_by,omitempty"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	LastRunAt   *time.Time `json:"last_run_at,omitempty"`
+	LastStatus  string     `json:"last_status,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// JobsOverview shows the state of the background jobs
+type JobsOverview struct {
+	Recurring []RecurringJob `json:"recurring"`
+	Scheduled map[string]int `json:"scheduled"` // Number of scheduled jobs by status
+}
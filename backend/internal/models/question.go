@@ -0,0 +1,203 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuestionType represents the kind of answer a registration question expects
+type QuestionType string
+
+const (
+	// QuestionText expects free text
+	QuestionText QuestionType = "text"
+	// QuestionSingleChoice expects exactly one of the options
+	QuestionSingleChoice QuestionType = "single_choice"
+	// QuestionMultiChoice expects any number of the options
+	QuestionMultiChoice QuestionType = "multi_choice"
+	// QuestionCheckbox expects true or false; a required checkbox must be checked
+	QuestionCheckbox QuestionType = "checkbox"
+)
+
+// MaxQuestionsPerEvent caps how many questions an event can ask
+const MaxQuestionsPerEvent = 50
+
+// Question is a custom question asked when registering for an event. Changing
+// the questions of an event that already has registrations creates a new
+// version, so earlier answers keep pointing at the questions they answered.
+type Question struct {
+	ID        int64        `json:"id"`
+	EventID   int64        `json:"event_id"`
+	Version   int          `json:"version"`
+	Position  int          `json:"position"`
+	Label     string       `json:"label"`
+	Type      QuestionType `json:"type"`
+	Options   []string     `json:"options,omitempty"`
+	Required  bool         `json:"required"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// QuestionRequest describes a single question in a QuestionsRequest
+type QuestionRequest struct {
+	Label    string       `json:"label"`
+	Type     QuestionType `json:"type"`
+	Options  []string     `json:"options"`
+	Required bool         `json:"required"`
+}
+
+// QuestionsRequest represents the request body for replacing the questions of an event
+type QuestionsRequest struct {
+	Questions []QuestionRequest `json:"questions"`
+}
+
+// Answer is the answer to a question. Value is a string for text and single
+// choice questions, a list of strings for multi choice and a bool for checkboxes.
+type Answer struct {
+	QuestionID int64       `json:"question_id"`
+	Value      interface{} `json:"value"`
+}
+
+// AttendeeAnswer is an answer as shown to organizers
+type AttendeeAnswer struct {
+	QuestionID int64       `json:"question_id"`
+	Version    int         `json:"version"`
+	Label      string      `json:"label"`
+	Value      interface{} `json:"value"`
+}
+
+// Attendee is a registration with the answers given, as listed to organizers
+type Attendee struct {
+	Registration
+	TicketTypeName string           `json:"ticket_type_name,omitempty"`
+	Answers        []AttendeeAnswer `json:"answers"`
+}
+
+// Validate performs validation on the questions request
+func (r *QuestionsRequest) Validate() error {
+	if len(r.Questions) > MaxQuestionsPerEvent {
+		return fmt.Errorf("an event can't have more than %d questions", MaxQuestionsPerEvent)
+	}
+
+	for i := range r.Questions {
+		question := &r.Questions[i]
+		question.Label = strings.TrimSpace(question.Label)
+		if question.Label == "" {
+			return errors.New("questions must have a label")
+		}
+
+		switch question.Type {
+		case QuestionText, QuestionCheckbox:
+			if len(question.Options) > 0 {
+				return fmt.Errorf("question %q can't have options", question.Label)
+			}
+		case QuestionSingleChoice, QuestionMultiChoice:
+			if len(question.Options) < 2 {
+				return fmt.Errorf("question %q needs at least two options", question.Label)
+			}
+			seen := make(map[string]bool, len(question.Options))
+			for _, option := range question.Options {
+				if option == "" || seen[option] {
+					return fmt.Errorf("options of question %q must be unique and not empty", question.Label)
+				}
+				seen[option] = true
+			}
+		default:
+			return errors.New("question type must be one of text, single_choice, multi_choice or checkbox")
+		}
+	}
+	return nil
+}
+
+// NormalizeAnswer checks an answer value against the question and returns it in
+// its canonical form, or nil when the optional question was left unanswered
+func (q *Question) NormalizeAnswer(value interface{}) (interface{}, error) {
+	switch q.Type {
+	case QuestionText, QuestionSingleChoice:
+		text, ok := value.(string)
+		if value != nil && !ok {
+			return nil, fmt.Errorf("answer to %q must be text", q.Label)
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, q.missingAnswer()
+		}
+		if q.Type == QuestionSingleChoice && !q.hasOption(text) {
+			return nil, fmt.Errorf("answer to %q must be one of its options", q.Label)
+		}
+		return text, nil
+
+	case QuestionMultiChoice:
+		items, ok := value.([]interface{})
+		if value != nil && !ok {
+			return nil, fmt.Errorf("answer to %q must be a list of options", q.Label)
+		}
+		choices := make([]string, 0, len(items))
+		for _, item := range items {
+			choice, ok := item.(string)
+			if !ok || !q.hasOption(choice) {
+				return nil, fmt.Errorf("answer to %q must only contain its options", q.Label)
+			}
+			choices = append(choices, choice)
+		}
+		if len(choices) == 0 {
+			return nil, q.missingAnswer()
+		}
+		return choices, nil
+
+	case QuestionCheckbox:
+		if value == nil && !q.Required {
+			return nil, nil
+		}
+		checked, ok := value.(bool)
+		if value != nil && !ok {
+			return nil, fmt.Errorf("answer to %q must be true or false", q.Label)
+		}
+		if !checked && q.Required {
+			return nil, fmt.Errorf("%q must be checked", q.Label)
+		}
+		return checked, nil
+	}
+
+	return nil, fmt.Errorf("question %q has an unknown type", q.Label)
+}
+
+// missingAnswer returns the error for an empty answer, which is only fine for optional questions
+func (q *Question) missingAnswer() error {
+	if q.Required {
+		return fmt.Errorf("an answer to %q is required", q.Label)
+	}
+	return nil
+}
+
+func (q *Question) hasOption(option string) bool {
+	for _, candidate := range q.Options {
+		if candidate == option {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatAnswer renders an answer value as plain text, e.g. for exports
+func FormatAnswer(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, "; ")
+	}
+	return fmt.Sprint(value)
+}
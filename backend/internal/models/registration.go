@@ -5,32 +5,102 @@ import (
 	"time"
 )
 
+// RegistrationStatus represents the state of a registration
+type RegistrationStatus string
+
+const (
+	// RegistrationConfirmed is a registration that holds a seat
+	RegistrationConfirmed RegistrationStatus = "confirmed"
+	// RegistrationPending is an application for an event that requires approval.
+	// It only holds a seat when the event reserves seats for pending registrations.
+	RegistrationPending RegistrationStatus = "pending"
+	// RegistrationApproved is an application the organizer accepted; it holds a seat
+	RegistrationApproved RegistrationStatus = "approved"
+	// RegistrationRejected is an application the organizer turned down
+	RegistrationRejected RegistrationStatus = "rejected"
+	// RegistrationCancelled is a registration that no longer holds a seat
+	RegistrationCancelled RegistrationStatus = "cancelled"
+)
+
+// registrationTransitions lists the statuses each registration status may move to
+var registrationTransitions = map[RegistrationStatus][]RegistrationStatus{
+	RegistrationPending:   {RegistrationApproved, RegistrationRejected, RegistrationCancelled},
+	RegistrationApproved:  {RegistrationCancelled},
+	RegistrationConfirmed: {RegistrationCancelled},
+}
+
+// CanTransitionTo reports whether a registration may move from this status to next
+func (s RegistrationStatus) CanTransitionTo(next RegistrationStatus) bool {
+	for _, allowed := range registrationTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 // Registration represents a registration for an event
 type Registration struct {
-	ID        int64     `json:"id"`
-	EventID   int64     `json:"event_id"`
-	UserID    int64     `json:"user_id"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	CreatedAt time.Time `json:"created_at"`
+	ID           int64              `json:"id"`
+	EventID      int64              `json:"event_id"`
+	UserID       int64              `json:"user_id"`
+	TicketTypeID *int64             `json:"ticket_type_id,omitempty"`
+	FirstName    string             `json:"first_name"`
+	LastName     string             `json:"last_name"`
+	Status       RegistrationStatus `json:"status"`
+	StatusReason string             `json:"status_reason,omitempty"`
+	SeatReserved bool               `json:"seat_reserved"`
+	GuestCount   int                `json:"guest_count"`
+	Guests       []Guest            `json:"guests,omitempty"`
+	Notes        string             `json:"notes,omitempty"`
+	CheckedInAt  *time.Time         `json:"checked_in_at,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
 }
 
-// RegistrationRequest represents the request body for creating or updating a registration
-type RegistrationRequest struct {
-	EventID   int64  `json:"event_id" binding:"required"`
+// Guest is a named person attending with the owner of a registration
+type Guest struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
-	Notes     string `json:"notes"`
+}
+
+// RegistrationDecisionRequest represents the request body for approving or rejecting a registration
+type RegistrationDecisionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// GuestsRequest represents the request body for changing the guests of a registration.
+// GuestCount is the number of people coming along; only some of them need to be named.
+type GuestsRequest struct {
+	GuestCount int     `json:"guest_count"`
+	Guests     []Guest `json:"guests"`
+}
+
+// RegistrationRequest represents the request body for creating or updating a registration
+type RegistrationRequest struct {
+	EventID      int64    `json:"event_id" binding:"required"`
+	TicketTypeID *int64   `json:"ticket_type_id"` // Required when the event has ticket types
+	FirstName    string   `json:"first_name"`
+	LastName     string   `json:"last_name"`
+	Notes        string   `json:"notes"`
+	Answers      []Answer `json:"answers"` // Answers to the custom questions of the event; updates only read them when moving to another event
+
+	// Guests coming along when registering; change them later with a GuestsRequest
+	GuestsRequest
 }
 
 // RegistrationResponse represents the response for a registration with event details
 type RegistrationResponse struct {
 	Registration
-	EventTitle       string    `json:"event_title"`
-	EventDescription string    `json:"event_description"`
-	EventLocation    string    `json:"event_location"`
-	EventDate        time.Time `json:"event_date"`
-	EventType        string    `json:"event_type"`
+	EventTitle       string      `json:"event_title"`
+	EventDescription string      `json:"event_description"`
+	EventLocation    string      `json:"event_location"`
+	EventDate        time.Time   `json:"event_date"`
+	EventLocalDate   time.Time   `json:"event_local_date"`
+	EventEndDate     time.Time   `json:"event_end_date"`
+	EventTimezone    string      `json:"event_timezone"`
+	EventType        string      `json:"event_type"`
+	EventStatus      EventStatus `json:"event_status"`
+	TicketTypeName   string      `json:"ticket_type_name,omitempty"`
 }
 
 // Validate performs validation on the registration request
@@ -38,14 +108,60 @@ func (r *RegistrationRequest) Validate() error {
 	if r.EventID <= 0 {
 		return errors.New("event ID is required")
 	}
+	return r.GuestsRequest.Validate()
+}
+
+// Validate performs validation on the guests request, defaulting the guest
+// count to the number of named guests
+func (r *GuestsRequest) Validate() error {
+	if r.GuestCount < 0 {
+		return errors.New("guest count must not be negative")
+	}
+	if r.GuestCount == 0 {
+		r.GuestCount = len(r.Guests)
+	}
+	if len(r.Guests) > r.GuestCount {
+		return errors.New("more guests are named than the guest count")
+	}
+	for _, guest := range r.Guests {
+		if guest.FirstName == "" {
+			return errors.New("guests must have a first name")
+		}
+	}
 	return nil
 }
 
+// Heads returns the number of seats the registration takes, its owner included
+func (r *Registration) Heads() int {
+	return 1 + r.GuestCount
+}
+
+// HoldsSeat reports whether the registration currently takes seats at the event
+func (r *Registration) HoldsSeat() bool {
+	switch r.Status {
+	case RegistrationConfirmed, RegistrationApproved:
+		return true
+	case RegistrationPending:
+		return r.SeatReserved
+	}
+	return false
+}
+
+// HasTicket reports whether a ticket is issued for the registration, which
+// is the case once it is confirmed or approved
+func (r *Registration) HasTicket() bool {
+	return r.Status == RegistrationConfirmed || r.Status == RegistrationApproved
+}
+
 // ToRegistration converts a RegistrationRequest to a Registration
 func (r *RegistrationRequest) ToRegistration() *Registration {
 	return &Registration{
-		EventID:   r.EventID,
-		FirstName: r.FirstName,
-		LastName:  r.LastName,
+		EventID:      r.EventID,
+		TicketTypeID: r.TicketTypeID,
+		FirstName:    r.FirstName,
+		LastName:     r.LastName,
+		GuestCount:   r.GuestCount,
+		Guests:       r.Guests,
+		Notes:        r.Notes,
 	}
 }
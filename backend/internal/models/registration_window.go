@@ -0,0 +1,89 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// RegistrationWindow limits when attendees may register for an event and when
+// they may still cancel on their own. Unset times don't restrict anything.
+type RegistrationWindow struct {
+	RegistrationOpensAt  *time.Time `json:"registration_opens_at,omitempty"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at,omitempty"`
+	CancellationDeadline *time.Time `json:"cancellation_deadline,omitempty"`
+}
+
+// RegistrationState describes whether an event currently accepts registrations
+type RegistrationState string
+
+const (
+	// RegistrationNotYetOpen means the registration window hasn't started
+	RegistrationNotYetOpen RegistrationState = "not_yet_open"
+	// RegistrationOpen means registrations are accepted
+	RegistrationOpen RegistrationState = "open"
+	// RegistrationClosed means the registration window or the event is over
+	RegistrationClosed RegistrationState = "closed"
+)
+
+// normalize stores the times of the window in UTC
+func (w *RegistrationWindow) normalize() {
+	for _, t := range []**time.Time{&w.RegistrationOpensAt, &w.RegistrationClosesAt, &w.CancellationDeadline} {
+		if *t != nil {
+			utc := (**t).UTC()
+			*t = &utc
+		}
+	}
+}
+
+// validate checks the window against the event it belongs to
+func (w *RegistrationWindow) validate(eventEnd time.Time) error {
+	if w.RegistrationOpensAt != nil && w.RegistrationClosesAt != nil && !w.RegistrationClosesAt.After(*w.RegistrationOpensAt) {
+		return errors.New("registration must close after it opens")
+	}
+	if w.RegistrationOpensAt != nil && !w.RegistrationOpensAt.Before(eventEnd) {
+		return errors.New("registration must open before the event ends")
+	}
+	if w.RegistrationClosesAt != nil && w.RegistrationClosesAt.After(eventEnd) {
+		return errors.New("registration can't close after the event ends")
+	}
+	if w.CancellationDeadline != nil && w.CancellationDeadline.After(eventEnd) {
+		return errors.New("cancellation deadline can't be after the event ends")
+	}
+	return nil
+}
+
+// Shift returns the window moved by the given duration, e.g. for another occurrence of a series
+func (w RegistrationWindow) Shift(d time.Duration) RegistrationWindow {
+	shift := func(t *time.Time) *time.Time {
+		if t == nil {
+			return nil
+		}
+		shifted := t.Add(d)
+		return &shifted
+	}
+	return RegistrationWindow{
+		RegistrationOpensAt:  shift(w.RegistrationOpensAt),
+		RegistrationClosesAt: shift(w.RegistrationClosesAt),
+		CancellationDeadline: shift(w.CancellationDeadline),
+	}
+}
+
+// RegistrationStateAt reports whether the event accepts registrations at the given instant.
+// Without an explicit closing time, registration stays open until the event ends.
+func (e *Event) RegistrationStateAt(now time.Time) RegistrationState {
+	if e.RegistrationOpensAt != nil && now.Before(*e.RegistrationOpensAt) {
+		return RegistrationNotYetOpen
+	}
+	if e.RegistrationClosesAt != nil && !now.Before(*e.RegistrationClosesAt) {
+		return RegistrationClosed
+	}
+	if e.HasEnded(now) {
+		return RegistrationClosed
+	}
+	return RegistrationOpen
+}
+
+// CanCancelAt reports whether attendees may still cancel their registration at the given instant
+func (e *Event) CanCancelAt(now time.Time) bool {
+	return e.CancellationDeadline == nil || now.Before(*e.CancellationDeadline)
+}
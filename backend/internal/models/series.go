@@ -0,0 +1,204 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Frequency represents how often a recurring event repeats
+type Frequency string
+
+const (
+	// FrequencyDaily repeats the event every N days
+	FrequencyDaily Frequency = "daily"
+	// FrequencyWeekly repeats the event every N weeks
+	FrequencyWeekly Frequency = "weekly"
+	// FrequencyMonthly repeats the event every N months on the same day of the month
+	FrequencyMonthly Frequency = "monthly"
+)
+
+// MaxOccurrences caps how many events a single series can expand into
+const MaxOccurrences = 365
+
+// maxExpansionSteps caps how many steps of a rule are tried when expanding it.
+// Monthly rules skip the months that lack their day; one on the 31st hits only
+// seven months a year, so twice the cap leaves room for more than MaxOccurrences.
+const maxExpansionSteps = MaxOccurrences * 2
+
+// exceptionDateLayout is the format of dates listed in Recurrence.Exceptions
+const exceptionDateLayout = "2006-01-02"
+
+// UpdateScope controls which occurrences of a series an update applies to
+type UpdateScope string
+
+const (
+	// ScopeThis updates only the selected occurrence
+	ScopeThis UpdateScope = "this"
+	// ScopeFuture updates the selected occurrence and every later one in the series
+	ScopeFuture UpdateScope = "future"
+)
+
+// Recurrence describes an RRULE-style repetition rule. Monthly rules repeat on
+// the day of the month of the first occurrence and skip months without that
+// day (e.g. the 31st of April), which don't count towards Count either.
+type Recurrence struct {
+	Frequency  Frequency  `json:"frequency"`
+	Interval   int        `json:"interval"` // Defaults to 1, see Normalize
+	Count      int        `json:"count,omitempty"`
+	Until      *time.Time `json:"until,omitempty"`
+	Exceptions []string   `json:"exceptions,omitempty"` // Dates (YYYY-MM-DD) to skip
+}
+
+// EventSeries represents a recurring event that expands into individual events
+type EventSeries struct {
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Location    string     `json:"location"`
+	EventType   string     `json:"event_type"`
+	StartDate   time.Time  `json:"start_date"`
+	Duration    int        `json:"duration_minutes"`
+	Timezone    string     `json:"timezone"`
+	Seats       int        `json:"seats"`
+	VenueID     *int64     `json:"venue_id,omitempty"`
+	Recurrence  Recurrence `json:"recurrence"`
+	CreatorID   int64      `json:"creator_id"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// SeriesRequest represents the request body for creating an event series.
+// The embedded event fields describe the first occurrence.
+type SeriesRequest struct {
+	EventRequest
+	Recurrence Recurrence `json:"recurrence" binding:"required"`
+}
+
+// Normalize fills in the defaults of the first occurrence and of the recurrence rule
+func (r *SeriesRequest) Normalize() {
+	r.EventRequest.Normalize()
+	r.Recurrence.Normalize()
+}
+
+// Validate performs validation on a normalized series request
+func (r *SeriesRequest) Validate() error {
+	if err := r.EventRequest.Validate(); err != nil {
+		return err
+	}
+	return r.Recurrence.Validate(r.LocalEventDate())
+}
+
+// Normalize fills in the defaults of the recurrence rule: an interval of 1
+func (r *Recurrence) Normalize() {
+	if r.Interval == 0 {
+		r.Interval = 1
+	}
+}
+
+// Validate performs validation on the recurrence rule
+func (r *Recurrence) Validate(start time.Time) error {
+	switch r.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+	default:
+		return errors.New("frequency must be one of daily, weekly or monthly")
+	}
+
+	if r.Interval < 1 {
+		return errors.New("interval must be greater than zero")
+	}
+
+	if r.Count == 0 && r.Until == nil {
+		return errors.New("either count or until is required")
+	}
+	if r.Count < 0 || r.Count > MaxOccurrences {
+		return fmt.Errorf("count must be between 1 and %d", MaxOccurrences)
+	}
+	if r.Until != nil && r.Until.Before(start) {
+		return errors.New("until must not be before the first occurrence")
+	}
+
+	for _, exception := range r.Exceptions {
+		if _, err := time.Parse(exceptionDateLayout, exception); err != nil {
+			return errors.New("exceptions must be dates in YYYY-MM-DD format")
+		}
+	}
+
+	// A series is never cut short, so a rule whose until is too far out is refused
+	occurrences := r.expand(start, MaxOccurrences+1)
+	if len(occurrences) == 0 {
+		return errors.New("recurrence does not produce any occurrences")
+	}
+	if len(occurrences) > MaxOccurrences {
+		return fmt.Errorf("recurrence produces more than %d occurrences, use an earlier until", MaxOccurrences)
+	}
+	return nil
+}
+
+// Occurrences expands the rule into the start times of the individual events,
+// at most MaxOccurrences of them. As in RRULE, exceptions are removed after
+// count has been applied, and monthly dates that don't exist are skipped.
+func (r *Recurrence) Occurrences(start time.Time) []time.Time {
+	return r.expand(start, MaxOccurrences)
+}
+
+// expand expands the rule into at most limit start times
+func (r *Recurrence) expand(start time.Time, limit int) []time.Time {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = 1
+	}
+
+	skip := make(map[string]bool, len(r.Exceptions))
+	for _, exception := range r.Exceptions {
+		skip[exception] = true
+	}
+
+	var occurrences []time.Time
+	generated := 0
+	for i := 0; i < maxExpansionSteps; i++ {
+		var next time.Time
+		switch r.Frequency {
+		case FrequencyDaily:
+			next = start.AddDate(0, 0, i*interval)
+		case FrequencyWeekly:
+			next = start.AddDate(0, 0, 7*i*interval)
+		case FrequencyMonthly:
+			next = start.AddDate(0, i*interval, 0)
+			if next.Day() != start.Day() {
+				continue
+			}
+		default:
+			return nil
+		}
+
+		if r.Until != nil && next.After(*r.Until) {
+			break
+		}
+		if r.Count > 0 && generated >= r.Count {
+			break
+		}
+		generated++
+
+		if skip[next.Format(exceptionDateLayout)] {
+			continue
+		}
+		occurrences = append(occurrences, next)
+		if len(occurrences) >= limit {
+			break
+		}
+	}
+
+	return occurrences
+}
+
+// ParseUpdateScope parses the scope of an event update, defaulting to ScopeThis
+func ParseUpdateScope(value string) (UpdateScope, error) {
+	switch UpdateScope(value) {
+	case "", ScopeThis:
+		return ScopeThis, nil
+	case ScopeFuture:
+		return ScopeFuture, nil
+	}
+	return "", errors.New("scope must be either this or future")
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// dates formats occurrences as dates for comparing them
+func dates(occurrences []time.Time) []string {
+	formatted := make([]string, len(occurrences))
+	for i, occurrence := range occurrences {
+		formatted[i] = occurrence.Format(exceptionDateLayout)
+	}
+	return formatted
+}
+
+func equalDates(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	start := time.Date(2026, time.January, 31, 19, 0, 0, 0, time.UTC)
+	until := time.Date(2026, time.February, 14, 23, 59, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		start      time.Time
+		want       []string
+	}{
+		{
+			name:       "daily with count",
+			recurrence: Recurrence{Frequency: FrequencyDaily, Interval: 1, Count: 3},
+			start:      start,
+			want:       []string{"2026-01-31", "2026-02-01", "2026-02-02"},
+		},
+		{
+			name:       "every fifth day until",
+			recurrence: Recurrence{Frequency: FrequencyDaily, Interval: 5, Until: &until},
+			start:      start,
+			want:       []string{"2026-01-31", "2026-02-05", "2026-02-10"},
+		},
+		{
+			name:       "weekly until",
+			recurrence: Recurrence{Frequency: FrequencyWeekly, Interval: 1, Until: &until},
+			start:      start,
+			want:       []string{"2026-01-31", "2026-02-07", "2026-02-14"},
+		},
+		{
+			name:       "monthly skips missing days",
+			recurrence: Recurrence{Frequency: FrequencyMonthly, Interval: 1, Count: 4},
+			start:      start,
+			want:       []string{"2026-01-31", "2026-03-31", "2026-05-31", "2026-07-31"},
+		},
+		{
+			name:       "exceptions are removed after count",
+			recurrence: Recurrence{Frequency: FrequencyDaily, Interval: 1, Count: 3, Exceptions: []string{"2026-02-01"}},
+			start:      start,
+			want:       []string{"2026-01-31", "2026-02-02"},
+		},
+		{
+			name:       "zero interval counts as one",
+			recurrence: Recurrence{Frequency: FrequencyWeekly, Count: 2},
+			start:      start,
+			want:       []string{"2026-01-31", "2026-02-07"},
+		},
+		{
+			name:       "unknown frequency",
+			recurrence: Recurrence{Frequency: "yearly", Interval: 1, Count: 2},
+			start:      start,
+			want:       []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		got := dates(tt.recurrence.Occurrences(tt.start))
+		if !equalDates(got, tt.want) {
+			t.Errorf("%s: Occurrences = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecurrenceOccurrencesKeepLocalTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	// Berlin switches to summer time on the 29th of March 2026
+	start := time.Date(2026, time.March, 28, 19, 0, 0, 0, berlin)
+	recurrence := Recurrence{Frequency: FrequencyDaily, Interval: 1, Count: 2}
+
+	for _, occurrence := range recurrence.Occurrences(start) {
+		if occurrence.Hour() != 19 {
+			t.Errorf("occurrence %v is not at 19:00 local time", occurrence)
+		}
+	}
+}
+
+func TestRecurrenceOccurrencesCap(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 10, 0, 0, 0, time.UTC)
+	until := start.AddDate(5, 0, 0)
+	recurrence := Recurrence{Frequency: FrequencyDaily, Interval: 1, Until: &until}
+
+	if got := len(recurrence.Occurrences(start)); got != MaxOccurrences {
+		t.Errorf("len(Occurrences) = %d, want the cap of %d", got, MaxOccurrences)
+	}
+
+	// Monthly rules on the 31st only hit seven months a year but still reach the cap
+	start = time.Date(2026, time.January, 31, 10, 0, 0, 0, time.UTC)
+	recurrence = Recurrence{Frequency: FrequencyMonthly, Interval: 1, Count: MaxOccurrences}
+	if got := len(recurrence.Occurrences(start)); got != MaxOccurrences {
+		t.Errorf("len(Occurrences) of a monthly rule = %d, want %d", got, MaxOccurrences)
+	}
+}
+
+func TestRecurrenceValidate(t *testing.T) {
+	start := time.Date(2026, time.January, 31, 19, 0, 0, 0, time.UTC)
+	before := start.Add(-time.Hour)
+	lastAllowed := start.AddDate(0, 0, MaxOccurrences-1)
+	tooLate := lastAllowed.AddDate(0, 0, 1)
+	farOut := start.AddDate(100, 0, 0)
+
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		valid      bool
+	}{
+		{"valid", Recurrence{Frequency: FrequencyWeekly, Interval: 1, Count: 10}, true},
+		{"unknown frequency", Recurrence{Frequency: "hourly", Interval: 1, Count: 10}, false},
+		{"zero interval", Recurrence{Frequency: FrequencyDaily, Count: 10}, false},
+		{"negative interval", Recurrence{Frequency: FrequencyDaily, Interval: -1, Count: 10}, false},
+		{"no end", Recurrence{Frequency: FrequencyDaily, Interval: 1}, false},
+		{"count over the cap", Recurrence{Frequency: FrequencyDaily, Interval: 1, Count: MaxOccurrences + 1}, false},
+		{"until before start", Recurrence{Frequency: FrequencyDaily, Interval: 1, Until: &before}, false},
+		{"until at the cap", Recurrence{Frequency: FrequencyDaily, Interval: 1, Until: &lastAllowed}, true},
+		{"until over the cap", Recurrence{Frequency: FrequencyDaily, Interval: 1, Until: &tooLate}, false},
+		{"until over the cap on the 31st", Recurrence{Frequency: FrequencyMonthly, Interval: 1, Until: &farOut}, false},
+		{"bad exception", Recurrence{Frequency: FrequencyDaily, Interval: 1, Count: 2, Exceptions: []string{"31.01.2026"}}, false},
+		{"everything excepted", Recurrence{Frequency: FrequencyDaily, Interval: 1, Count: 1, Exceptions: []string{"2026-01-31"}}, false},
+	}
+
+	for _, tt := range tests {
+		recurrence := tt.recurrence
+		err := recurrence.Validate(start)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v, want valid %v", tt.name, err, tt.valid)
+		}
+		if recurrence.Interval != tt.recurrence.Interval {
+			t.Errorf("%s: Validate() changed the interval to %d", tt.name, recurrence.Interval)
+		}
+	}
+}
+
+func TestRecurrenceNormalize(t *testing.T) {
+	recurrence := Recurrence{Frequency: FrequencyDaily, Count: 2}
+	recurrence.Normalize()
+	if recurrence.Interval != 1 {
+		t.Errorf("Interval = %d after Normalize, want 1", recurrence.Interval)
+	}
+
+	recurrence = Recurrence{Frequency: FrequencyDaily, Interval: 3, Count: 2}
+	recurrence.Normalize()
+	if recurrence.Interval != 3 {
+		t.Errorf("Interval = %d after Normalize, want it kept at 3", recurrence.Interval)
+	}
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// TicketVisibility controls whether a ticket type is listed to everyone
+type TicketVisibility string
+
+const (
+	// TicketPublic ticket types are listed on the event
+	TicketPublic TicketVisibility = "public"
+	// TicketHidden ticket types are only listed to the organizer, e.g. speaker tickets
+	// handed out by ID
+	TicketHidden TicketVisibility = "hidden"
+)
+
+// TicketType represents a tier of tickets for an event with its own capacity
+type TicketType struct {
+	ID             int64            `json:"id"`
+	EventID        int64            `json:"event_id"`
+	Name           string           `json:"name"`
+	Description    string           `json:"description"`
+	Capacity       int              `json:"capacity"`
+	SalesStart     *time.Time       `json:"sales_start,omitempty"`
+	SalesEnd       *time.Time       `json:"sales_end,omitempty"`
+	Visibility     TicketVisibility `json:"visibility"`
+	CreatedAt      time.Time        `json:"created_at"`
+	AvailableSeats int              `json:"available_seats"`
+}
+
+// TicketTypeRequest represents the request body for creating or updating a ticket type
+type TicketTypeRequest struct {
+	Name        string           `json:"name" binding:"required"`
+	Description string           `json:"description"`
+	Capacity    int              `json:"capacity" binding:"required"`
+	SalesStart  *time.Time       `json:"sales_start"`
+	SalesEnd    *time.Time       `json:"sales_end"`
+	Visibility  TicketVisibility `json:"visibility"`
+}
+
+// Validate performs validation on the ticket type request
+func (r *TicketTypeRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if r.Capacity <= 0 {
+		return errors.New("capacity must be greater than zero")
+	}
+
+	if r.SalesStart != nil {
+		utc := r.SalesStart.UTC()
+		r.SalesStart = &utc
+	}
+	if r.SalesEnd != nil {
+		utc := r.SalesEnd.UTC()
+		r.SalesEnd = &utc
+	}
+	if r.SalesStart != nil && r.SalesEnd != nil && !r.SalesEnd.After(*r.SalesStart) {
+		return errors.New("sales end must be after sales start")
+	}
+
+	switch r.Visibility {
+	case "":
+		r.Visibility = TicketPublic
+	case TicketPublic, TicketHidden:
+	default:
+		return errors.New("visibility must be either public or hidden")
+	}
+	return nil
+}
+
+// IsOnSale reports whether the ticket type can be picked at the given time
+func (t *TicketType) IsOnSale(now time.Time) bool {
+	if t.SalesStart != nil && now.Before(*t.SalesStart) {
+		return false
+	}
+	if t.SalesEnd != nil && !now.Before(*t.SalesEnd) {
+		return false
+	}
+	return true
+}
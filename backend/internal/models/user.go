@@ -13,6 +13,8 @@ type Role string
 const (
 	// RoleUser represents a regular user
 	RoleUser Role = "user"
+	// RoleAdmin represents an operator of the application
+	RoleAdmin Role = "admin"
 )
 
 // User represents a user in the system
@@ -22,6 +24,7 @@ type User struct {
 	Password  string    `json:"-"` // Don't return password in JSON
 	Email     string    `json:"email"`
 	Role      Role      `json:"role"`
+	Locale    string    `json:"locale"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -30,8 +33,12 @@ type UserRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 	Email    string `json:"email" binding:"required"`
+	Locale   string `json:"locale"` // Language of notifications, defaults to DefaultLocale
 }
 
+// DefaultLocale is the language of notifications for users who didn't pick one
+const DefaultLocale = "en"
+
 // LoginRequest represents the request body for logging in
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
@@ -55,6 +62,9 @@ func (r *UserRequest) Validate() error {
 	if r.Email == "" {
 		return errors.New("email is required")
 	}
+	if r.Locale == "" {
+		r.Locale = DefaultLocale
+	}
 	return nil
 }
 
@@ -80,5 +90,6 @@ func (r *UserRequest) ToUser() *User {
 		Password: r.Password,
 		Email:    r.Email,
 		Role:     RoleUser,
+		Locale:   r.Locale,
 	}
 }
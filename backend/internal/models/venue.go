@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Venue represents a reusable location where events take place
+type Venue struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	Address       string    `json:"address"`
+	Latitude      *float64  `json:"latitude"`
+	Longitude     *float64  `json:"longitude"`
+	Capacity      int       `json:"capacity"`
+	Accessibility string    `json:"accessibility"`
+	CreatorID     int64     `json:"creator_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// VenueRequest represents the request body for creating or updating a venue
+type VenueRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Address       string   `json:"address"`
+	Latitude      *float64 `json:"latitude"`
+	Longitude     *float64 `json:"longitude"`
+	Capacity      int      `json:"capacity" binding:"required"`
+	Accessibility string   `json:"accessibility"` // Free-text notes, e.g. "step-free access, hearing loop"
+}
+
+// Validate performs validation on the venue request
+func (r *VenueRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if r.Capacity <= 0 {
+		return errors.New("capacity must be greater than zero")
+	}
+
+	if (r.Latitude == nil) != (r.Longitude == nil) {
+		return errors.New("latitude and longitude must be provided together")
+	}
+	if r.Latitude != nil && (*r.Latitude < -90 || *r.Latitude > 90) {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	if r.Longitude != nil && (*r.Longitude < -180 || *r.Longitude > 180) {
+		return errors.New("longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+// DisplayLocation returns the human readable location of the venue
+func (v *Venue) DisplayLocation() string {
+	if v.Address == "" {
+		return v.Name
+	}
+	return v.Name + ", " + v.Address
+}
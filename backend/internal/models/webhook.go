@@ -0,0 +1,139 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/webhooks"
+)
+
+// WebhookTopic names a change webhooks can be told about
+type WebhookTopic string
+
+const (
+	// TopicEventCreated is sent when an event is created, also for each occurrence of a new series
+	TopicEventCreated WebhookTopic = "event.created"
+	// TopicEventUpdated is sent when the details or the status of an event change
+	TopicEventUpdated WebhookTopic = "event.updated"
+	// TopicEventDeleted is sent when an event is deleted; the payload is the event as it was
+	TopicEventDeleted WebhookTopic = "event.deleted"
+	// TopicRegistrationCreated is sent when somebody registers for an event
+	TopicRegistrationCreated WebhookTopic = "registration.created"
+	// TopicRegistrationCancelled is sent when a registration is cancelled, also along with its event
+	TopicRegistrationCancelled WebhookTopic = "registration.cancelled"
+	// TopicWebhookTest is only sent when testing a webhook
+	TopicWebhookTest WebhookTopic = "webhook.test"
+)
+
+// WebhookTopics lists the topics webhooks can subscribe to
+var WebhookTopics = []WebhookTopic{
+	TopicEventCreated,
+	TopicEventUpdated,
+	TopicEventDeleted,
+	TopicRegistrationCreated,
+	TopicRegistrationCancelled,
+}
+
+// DeliveryStatus is the state of a webhook delivery
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries wait for their next attempt
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered deliveries were accepted by the receiver
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead deliveries failed every attempt; they are only retried on request
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// WebhookMaxAttempts is the number of tries before a delivery is dead
+const WebhookMaxAttempts = 8
+
+// WebhookRetryDelay is the delay before the first retry; it doubles with every further one
+const WebhookRetryDelay = 30 * time.Second
+
+// Webhook sends the changes to the events of its owner to a URL
+type Webhook struct {
+	ID        int64          `json:"id"`
+	UserID    int64          `json:"user_id"`
+	EventID   *int64         `json:"event_id,omitempty"` // Only changes of this event, otherwise of every event of the owner
+	URL       string         `json:"url"`
+	Topics    []WebhookTopic `json:"topics"` // Empty for every topic
+	Active    bool           `json:"active"`
+	Secret    string         `json:"secret,omitempty"` // Only shown when the webhook is created
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// WebhookRequest represents the request body for creating a webhook
+type WebhookRequest struct {
+	URL     string         `json:"url" binding:"required"`
+	EventID *int64         `json:"event_id"`
+	Topics  []WebhookTopic `json:"topics"`
+}
+
+// Validate performs validation on the webhook request
+func (r *WebhookRequest) Validate() error {
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if err := webhooks.CheckHost(u.Hostname()); err != nil {
+		return err
+	}
+	for _, topic := range r.Topics {
+		if !topic.IsValid() {
+			return fmt.Errorf("unknown webhook topic %q", topic)
+		}
+	}
+	return nil
+}
+
+// IsValid reports whether webhooks can subscribe to the topic
+func (t WebhookTopic) IsValid() bool {
+	for _, topic := range WebhookTopics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// Wants reports whether the webhook subscribed to the topic
+func (w *Webhook) Wants(topic WebhookTopic) bool {
+	if len(w.Topics) == 0 || topic == TopicWebhookTest {
+		return true
+	}
+	for _, t := range w.Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookPayload is the JSON body of every webhook request
+type WebhookPayload struct {
+	ID        string          `json:"id"` // The same for every webhook told about one change
+	Topic     WebhookTopic    `json:"topic"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"` // The event or registration that changed
+}
+
+// WebhookDelivery is an attempt to send a change to a webhook, with its outcome
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	Topic          WebhookTopic    `json:"topic"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	DurationMs     *int64          `json:"duration_ms,omitempty"`
+	Body           json.RawMessage `json:"body"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,38 @@
+package notifications
+
+import (
+	"log/slog"
+	"os"
+)
+
+// NewSenderFromEnv creates the sender configured by the environment. With
+// SMTP_HOST set messages are emailed, otherwise they are written to
+// NOTIFICATIONS_DIR (./data/outbox by default).
+func NewSenderFromEnv() Sender {
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = "Event Poster <no-reply@event-poster.local>"
+	}
+
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		slog.Info("Notifications are emailed", "smtp_host", host, "smtp_port", port)
+		return &SMTPSender{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	}
+
+	dir := os.Getenv("NOTIFICATIONS_DIR")
+	if dir == "" {
+		dir = "./data/outbox"
+	}
+	slog.Info("SMTP_HOST is not set, notifications are written to files", "dir", dir)
+	return &FileSink{Dir: dir, From: from}
+}
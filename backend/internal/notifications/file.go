@@ -0,0 +1,34 @@
+package notifications
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileSink is a Sender that writes every message to an .eml file in Dir
+// instead of sending it. It is meant for development and tests.
+type FileSink struct {
+	Dir  string
+	From string
+
+	sent uint64
+}
+
+// Send writes the message into the sink directory
+func (s *FileSink) Send(msg *Message) error {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+
+	body, err := buildMIME(s.From, msg)
+	if err != nil {
+		return err
+	}
+
+	n := atomic.AddUint64(&s.sent, 1)
+	name := fmt.Sprintf("%s-%04d-%s.eml", time.Now().UTC().Format("20060102T150405"), n, msg.Kind)
+	return os.WriteFile(filepath.Join(s.Dir, name), body, 0644)
+}
@@ -0,0 +1,139 @@
+// Package notifications renders and delivers the messages users receive about
+// their registrations and the events they registered for.
+package notifications
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// Kind identifies what a notification is about and picks its template
+type Kind string
+
+const (
+	// KindRegistrationConfirmed tells a user their registration holds a seat
+	KindRegistrationConfirmed Kind = "registration_confirmed"
+	// KindRegistrationPending tells a user their application awaits the organizer's approval
+	KindRegistrationPending Kind = "registration_pending"
+	// KindRegistrationApproved tells a user the organizer accepted their application
+	KindRegistrationApproved Kind = "registration_approved"
+	// KindRegistrationRejected tells a user the organizer turned down their application
+	KindRegistrationRejected Kind = "registration_rejected"
+	// KindRegistrationCancelled tells a user their registration was cancelled
+	KindRegistrationCancelled Kind = "registration_cancelled"
+	// KindEventUpdated tells attendees the date, place or title of an event changed
+	KindEventUpdated Kind = "event_updated"
+	// KindEventPostponed tells attendees an event is on hold until it gets a new date
+	KindEventPostponed Kind = "event_postponed"
+	// KindEventCancelled tells attendees an event won't take place
+	KindEventCancelled Kind = "event_cancelled"
+	// KindWaitlistPromoted tells a user a seat freed up for them
+	KindWaitlistPromoted Kind = "waitlist_promoted"
+	// KindEventReminder reminds attendees that an event starts soon
+	KindEventReminder Kind = "event_reminder"
+)
+
+// Kinds lists every kind of notification
+var Kinds = []Kind{
+	KindRegistrationConfirmed,
+	KindRegistrationPending,
+	KindRegistrationApproved,
+	KindRegistrationRejected,
+	KindRegistrationCancelled,
+	KindEventUpdated,
+	KindEventPostponed,
+	KindEventCancelled,
+	KindWaitlistPromoted,
+	KindEventReminder,
+}
+
+// IsValid reports whether k is a known kind of notification
+func (k Kind) IsValid() bool {
+	for _, kind := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Data is what templates can refer to
+type Data struct {
+	RecipientName  string
+	EventID        int64
+	EventTitle     string
+	EventDate      string // Local date and time of the event, formatted for display
+	EventLocation  string
+	RegistrationID int64
+	GuestCount     int
+	Reason         string
+	Changes        []string // Fields of an updated event that changed, e.g. "date"
+
+	// How long until a reminded event starts: whole hours, or else minutes
+	StartsInHours   int
+	StartsInMinutes int
+}
+
+// Notification is a message to a single recipient before it is rendered
+type Notification struct {
+	Kind   Kind
+	UserID int64  // Account of the recipient, 0 for registrations made without one
+	To     string // Email address
+	Locale string // Falls back to DefaultLocale when there are no templates for it
+	Data   Data
+}
+
+// Message is a rendered notification ready to be sent
+type Message struct {
+	Kind    Kind
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers rendered messages, e.g. by email
+type Sender interface {
+	Send(msg *Message) error
+}
+
+// Notifier accepts notifications for delivery
+type Notifier interface {
+	Notify(n *Notification) error
+}
+
+// LogNotifier only logs notifications; it is used until another notifier is set up
+type LogNotifier struct{}
+
+// Notify logs the notification
+func (LogNotifier) Notify(n *Notification) error {
+	slog.Warn("Notification not delivered: no notifier set up", "kind", n.Kind, "to", n.To, "event_id", n.Data.EventID)
+	return nil
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultNotifier Notifier = LogNotifier{}
+)
+
+// SetDefault sets the notifier used by Notify
+func SetDefault(n Notifier) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultNotifier = n
+}
+
+// Notify hands a notification to the default notifier. Notifications are a
+// side effect of the action that caused them, so failures are only logged.
+func Notify(n *Notification) {
+	defaultMu.RLock()
+	notifier := defaultNotifier
+	defaultMu.RUnlock()
+
+	if n.To == "" {
+		return
+	}
+	if err := notifier.Notify(n); err != nil {
+		slog.Error("Failed to queue notification", "kind", n.Kind, "to", n.To, "event_id", n.Data.EventID, "error", err)
+	}
+}
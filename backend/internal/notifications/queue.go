@@ -0,0 +1,140 @@
+package notifications
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned when more notifications are waiting than the queue holds
+var ErrQueueFull = errors.New("notification queue is full")
+
+// ErrQueueClosed is returned when notifying after the queue was closed
+var ErrQueueClosed = errors.New("notification queue is closed")
+
+// QueueConfig tunes a Queue
+type QueueConfig struct {
+	Workers     int           // Messages sent in parallel
+	Size        int           // Messages that can wait for a worker
+	MaxAttempts int           // Tries per message before it is dropped
+	RetryDelay  time.Duration // Delay before the first retry; it doubles with every further one
+}
+
+// DefaultQueueConfig is a sensible configuration for a single server
+var DefaultQueueConfig = QueueConfig{
+	Workers:     2,
+	Size:        1000,
+	MaxAttempts: 5,
+	RetryDelay:  2 * time.Second,
+}
+
+// Queue is a Notifier that renders notifications right away and delivers
+// them with a Sender from background workers, retrying failed deliveries
+type Queue struct {
+	sender Sender
+	config QueueConfig
+
+	messages chan *Message
+	quit     chan struct{}
+	wg       sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewQueue creates a queue delivering with sender and starts its workers
+func NewQueue(sender Sender, config QueueConfig) *Queue {
+	if config.Workers <= 0 {
+		config.Workers = DefaultQueueConfig.Workers
+	}
+	if config.Size <= 0 {
+		config.Size = DefaultQueueConfig.Size
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultQueueConfig.MaxAttempts
+	}
+
+	q := &Queue{
+		sender:   sender,
+		config:   config,
+		messages: make(chan *Message, config.Size),
+		quit:     make(chan struct{}),
+	}
+	for i := 0; i < config.Workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Notify renders the notification and queues it for delivery. Rendering
+// errors are returned right away; delivery errors are only logged.
+func (q *Queue) Notify(n *Notification) error {
+	msg, err := Render(n)
+	if err != nil {
+		return err
+	}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	select {
+	case q.messages <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting notifications and waits for the queued ones to be
+// delivered. Retries still waiting are given up when Close is called.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.messages)
+	q.mu.Unlock()
+
+	close(q.quit)
+	q.wg.Wait()
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for msg := range q.messages {
+		q.deliver(msg)
+	}
+}
+
+// deliver sends a message, retrying with exponential backoff
+func (q *Queue) deliver(msg *Message) {
+	delay := q.config.RetryDelay
+	for attempt := 1; ; attempt++ {
+		err := q.sender.Send(msg)
+		if err == nil {
+			slog.Info("Delivered notification", "kind", msg.Kind, "to", msg.To)
+			return
+		}
+		if attempt >= q.config.MaxAttempts {
+			slog.Error("Giving up on notification", "kind", msg.Kind, "to", msg.To, "attempts", attempt, "error", err)
+			return
+		}
+
+		slog.Warn("Failed to deliver notification, retrying", "kind", msg.Kind, "to", msg.To, "attempt", attempt,
+			"retry_in", delay, "error", err)
+		select {
+		case <-time.After(delay):
+		case <-q.quit:
+			slog.Warn("Dropping notification: shutting down", "kind", msg.Kind, "to", msg.To)
+			return
+		}
+		delay *= 2
+	}
+}
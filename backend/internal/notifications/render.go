@@ -0,0 +1,83 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+)
+
+// DefaultLocale is used for recipients whose locale has no templates
+const DefaultLocale = "en"
+
+// messageTemplate holds the sources of the templates of one kind in one locale.
+// HTML bodies are wrapped into layoutHTML.
+type messageTemplate struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// compiledTemplate is a messageTemplate parsed for rendering
+type compiledTemplate struct {
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+}
+
+// compiled holds the parsed templates by locale and kind
+var compiled = compileTemplates()
+
+func compileTemplates() map[string]map[Kind]*compiledTemplate {
+	result := make(map[string]map[Kind]*compiledTemplate, len(templates))
+	for locale, kinds := range templates {
+		result[locale] = make(map[Kind]*compiledTemplate, len(kinds))
+		for kind, source := range kinds {
+			name := fmt.Sprintf("%s/%s", locale, kind)
+			result[locale][kind] = &compiledTemplate{
+				subject: template.Must(template.New(name + ".subject").Parse(source.Subject)),
+				text:    template.Must(template.New(name + ".txt").Parse(source.Text)),
+				html:    htmltemplate.Must(htmltemplate.New(name + ".html").Parse(fmt.Sprintf(layoutHTML, source.HTML))),
+			}
+		}
+	}
+	return result
+}
+
+// IsSupportedLocale reports whether there are templates for the locale
+func IsSupportedLocale(locale string) bool {
+	_, ok := templates[locale]
+	return ok
+}
+
+// Render turns a notification into a message using the templates of its
+// locale, falling back to DefaultLocale
+func Render(n *Notification) (*Message, error) {
+	tmpl, ok := compiled[n.Locale][n.Kind]
+	if !ok {
+		tmpl, ok = compiled[DefaultLocale][n.Kind]
+	}
+	if !ok {
+		return nil, fmt.Errorf("no template for %s notifications", n.Kind)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, n.Data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.text.Execute(&text, n.Data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.html.Execute(&html, n.Data); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Kind:    n.Kind,
+		To:      n.To,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
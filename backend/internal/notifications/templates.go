@@ -0,0 +1,19 @@
+package notifications
+
+// templates holds the message templates by locale. Every locale should cover
+// every Kind; missing ones fall back to DefaultLocale.
+var templates = map[string]map[Kind]messageTemplate{
+	"en": templatesEN,
+	"ru": templatesRU,
+}
+
+// layoutHTML wraps the HTML body of every message
+const layoutHTML = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #222; line-height: 1.5;">
+<div style="max-width: 560px; margin: 0 auto; padding: 16px;">
+%s
+</div>
+</body>
+</html>
+`
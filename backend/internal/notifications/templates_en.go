@@ -0,0 +1,151 @@
+package notifications
+
+// eventDetailsEN describes the event in English text messages
+const eventDetailsEN = `{{.EventTitle}}
+When: {{.EventDate}}{{if .EventLocation}}
+Where: {{.EventLocation}}{{end}}`
+
+// eventDetailsHTMLEN describes the event in English HTML messages
+const eventDetailsHTMLEN = `<p><strong>{{.EventTitle}}</strong><br>When: {{.EventDate}}{{if .EventLocation}}<br>Where: {{.EventLocation}}{{end}}</p>`
+
+// reasonEN adds the reason given, if any
+const reasonEN = `{{if .Reason}}
+Reason: {{.Reason}}
+{{end}}`
+
+const reasonHTMLEN = `{{if .Reason}}<p>Reason: {{.Reason}}</p>{{end}}`
+
+// startsInEN tells how soon a reminded event starts
+const startsInEN = `{{if .StartsInHours}}in {{.StartsInHours}} hour{{if ne .StartsInHours 1}}s{{end}}{{else}}in {{.StartsInMinutes}} minute{{if ne .StartsInMinutes 1}}s{{end}}{{end}}`
+
+var templatesEN = map[Kind]messageTemplate{
+	KindRegistrationConfirmed: {
+		Subject: `You're registered for {{.EventTitle}}`,
+		Text: `Hi {{.RecipientName}},
+
+your registration is confirmed{{if .GuestCount}} for you and {{.GuestCount}} guest(s){{end}}.
+
+` + eventDetailsEN + `
+
+Your ticket is available in your registrations.
+`,
+		HTML: `<p>Hi {{.RecipientName}},</p>
+<p>your registration is confirmed{{if .GuestCount}} for you and {{.GuestCount}} guest(s){{end}}.</p>
+` + eventDetailsHTMLEN + `
+<p>Your ticket is available in your registrations.</p>`,
+	},
+	KindRegistrationPending: {
+		Subject: `Your application for {{.EventTitle}} was received`,
+		Text: `Hi {{.RecipientName}},
+
+the organizer reviews registrations for this event. We'll let you know once they decide.
+
+` + eventDetailsEN + `
+`,
+		HTML: `<p>Hi {{.RecipientName}},</p>
+<p>the organizer reviews registrations for this event. We'll let you know once they decide.</p>
+` + eventDetailsHTMLEN,
+	},
+	KindRegistrationApproved: {
+		Subject: `You're in: {{.EventTitle}}`,
+		Text: `Hi {{.RecipientName}},
+
+the organizer approved your registration.
+
+` + eventDetailsEN + `
+` + reasonEN,
+		HTML: `<p>Hi {{.RecipientName}},</p>
+<p>the organizer approved your registration.</p>
+` + eventDetailsHTMLEN + reasonHTMLEN,
+	},
+	KindRegistrationRejected: {
+		Subject: `Your application for {{.EventTitle}}`,
+		Text: `Hi {{.RecipientName}},
+
+unfortunately the organizer couldn't accept your registration for {{.EventTitle}}.
+` + reasonEN,
+		HTML: `<p>Hi {{.RecipientName}},</p>
+<p>unfortunately the organizer couldn't accept your registration for <strong>{{.EventTitle}}</strong>.</p>
+` + reasonHTMLEN,
+	},
+	KindRegistrationCancelled: {
+		Subject: `Your registration for {{.EventTitle}} was cancelled`,
+		Text: `Hi {{.RecipientName}},
+
+your registration for the event below was cancelled and your seat was released.
+
+` + eventDetailsEN + `
+` + reasonEN,
+		HTML: `<p>Hi {{.RecipientName}},</p>
+<p>your registration for the event below was cancelled and your seat was released.</p>
+` + eventDetailsHTMLEN + reasonHTMLEN,
+	},
+	KindEventUpdated: {
+		Subject: `{{.EventTitle}} has changed`,
+		Text: `Hi {{.RecipientName}},
+
+an event you registered for has changed:
+{{range .Changes}}- {{if eq . "date"}}date and time{{else if eq . "location"}}location{{else}}title{{end}}
+{{end}}
+` + eventDetailsEN + `
+
+Your registration stays valid.
+`,
+		HTML: `<p>Hi {{.RecipientName}},</p>
+<p>an event you registered for has changed:</p>
+<ul>{{range .Changes}}<li>{{if eq . "date"}}date and time{{else if eq . "location"}}location{{else}}title{{end}}</li>{{end}}</ul>
+` + eventDetailsHTMLEN + `
+<p>Your registration stays valid.</p>`,
+	},
+	KindEventPostponed: {
+		Subject: `{{.EventTitle}} is postponed`,
+		Text: `Hi {{.RecipientName}},
+
+the event below is postponed. We'll let you know when it gets a new date; your registration stays valid.
+
+` + eventDetailsEN + `
+` + reasonEN,
+		HTML: `<p>Hi {{.RecipientName}},</p>
+<p>the event below is postponed. We'll let you know when it gets a new date; your registration stays valid.</p>
+` + eventDetailsHTMLEN + reasonHTMLEN,
+	},
+	KindEventCancelled: {
+		Subject: `{{.EventTitle}} is cancelled`,
+		Text: `Hi {{.RecipientName}},
+
+we're sorry, the event below won't take place and your registration was cancelled.
+
+` + eventDetailsEN + `
+` + reasonEN,
+		HTML: `<p>Hi {{.RecipientName}},</p>
+<p>we're sorry, the event below won't take place and your registration was cancelled.</p>
+` + eventDetailsHTMLEN + reasonHTMLEN,
+	},
+	KindWaitlistPromoted: {
+		Subject: `A seat opened up for {{.EventTitle}}`,
+		Text: `Hi {{.RecipientName}},
+
+good news: a seat opened up and you moved off the waitlist. Your registration is now confirmed.
+
+` + eventDetailsEN + `
+` + reasonEN,
+		HTML: `<p>Hi {{.RecipientName}},</p>
+<p>good news: a seat opened up and you moved off the waitlist. Your registration is now confirmed.</p>
+` + eventDetailsHTMLEN + reasonHTMLEN,
+	},
+	KindEventReminder: {
+		Subject: `Reminder: {{.EventTitle}} starts ` + startsInEN,
+		Text: `Hi {{.RecipientName}},
+
+just a reminder that the event below starts ` + startsInEN + `.
+
+` + eventDetailsEN + `
+
+Your ticket is available in your registrations.
+`,
+		HTML: `<p>Hi {{.RecipientName}},</p>
+<p>just a reminder that the event below starts ` + startsInEN + `.</p>
+` + eventDetailsHTMLEN + `
+<p>Your ticket is available in your registrations.</p>`,
+	},
+}
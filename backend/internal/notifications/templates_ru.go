@@ -0,0 +1,151 @@
+package notifications
+
+// eventDetailsRU describes the event in Russian text messages
+const eventDetailsRU = `{{.EventTitle}}
+Когда: {{.EventDate}}{{if .EventLocation}}
+Где: {{.EventLocation}}{{end}}`
+
+// eventDetailsHTMLRU describes the event in Russian HTML messages
+const eventDetailsHTMLRU = `<p><strong>{{.EventTitle}}</strong><br>Когда: {{.EventDate}}{{if .EventLocation}}<br>Где: {{.EventLocation}}{{end}}</p>`
+
+// reasonRU adds the reason given, if any
+const reasonRU = `{{if .Reason}}
+Причина: {{.Reason}}
+{{end}}`
+
+const reasonHTMLRU = `{{if .Reason}}<p>Причина: {{.Reason}}</p>{{end}}`
+
+// startsInRU tells how soon a reminded event starts
+const startsInRU = `{{if .StartsInHours}}через {{.StartsInHours}} ч.{{else}}через {{.StartsInMinutes}} мин.{{end}}`
+
+var templatesRU = map[Kind]messageTemplate{
+	KindRegistrationConfirmed: {
+		Subject: `Вы зарегистрированы: {{.EventTitle}}`,
+		Text: `Здравствуйте, {{.RecipientName}}!
+
+Ваша регистрация подтверждена{{if .GuestCount}} (вы и гостей: {{.GuestCount}}){{end}}.
+
+` + eventDetailsRU + `
+
+Билет доступен в разделе ваших регистраций.
+`,
+		HTML: `<p>Здравствуйте, {{.RecipientName}}!</p>
+<p>Ваша регистрация подтверждена{{if .GuestCount}} (вы и гостей: {{.GuestCount}}){{end}}.</p>
+` + eventDetailsHTMLRU + `
+<p>Билет доступен в разделе ваших регистраций.</p>`,
+	},
+	KindRegistrationPending: {
+		Subject: `Заявка на «{{.EventTitle}}» получена`,
+		Text: `Здравствуйте, {{.RecipientName}}!
+
+Организатор рассматривает заявки на это событие. Мы сообщим, когда будет принято решение.
+
+` + eventDetailsRU + `
+`,
+		HTML: `<p>Здравствуйте, {{.RecipientName}}!</p>
+<p>Организатор рассматривает заявки на это событие. Мы сообщим, когда будет принято решение.</p>
+` + eventDetailsHTMLRU,
+	},
+	KindRegistrationApproved: {
+		Subject: `Заявка одобрена: {{.EventTitle}}`,
+		Text: `Здравствуйте, {{.RecipientName}}!
+
+Организатор одобрил вашу регистрацию.
+
+` + eventDetailsRU + `
+` + reasonRU,
+		HTML: `<p>Здравствуйте, {{.RecipientName}}!</p>
+<p>Организатор одобрил вашу регистрацию.</p>
+` + eventDetailsHTMLRU + reasonHTMLRU,
+	},
+	KindRegistrationRejected: {
+		Subject: `Ваша заявка на «{{.EventTitle}}»`,
+		Text: `Здравствуйте, {{.RecipientName}}!
+
+К сожалению, организатор не смог принять вашу регистрацию на «{{.EventTitle}}».
+` + reasonRU,
+		HTML: `<p>Здравствуйте, {{.RecipientName}}!</p>
+<p>К сожалению, организатор не смог принять вашу регистрацию на <strong>{{.EventTitle}}</strong>.</p>
+` + reasonHTMLRU,
+	},
+	KindRegistrationCancelled: {
+		Subject: `Регистрация на «{{.EventTitle}}» отменена`,
+		Text: `Здравствуйте, {{.RecipientName}}!
+
+Ваша регистрация на событие ниже отменена, место освобождено.
+
+` + eventDetailsRU + `
+` + reasonRU,
+		HTML: `<p>Здравствуйте, {{.RecipientName}}!</p>
+<p>Ваша регистрация на событие ниже отменена, место освобождено.</p>
+` + eventDetailsHTMLRU + reasonHTMLRU,
+	},
+	KindEventUpdated: {
+		Subject: `Изменения в событии «{{.EventTitle}}»`,
+		Text: `Здравствуйте, {{.RecipientName}}!
+
+В событии, на которое вы зарегистрированы, изменилось:
+{{range .Changes}}- {{if eq . "date"}}дата и время{{else if eq . "location"}}место{{else}}название{{end}}
+{{end}}
+` + eventDetailsRU + `
+
+Ваша регистрация остаётся в силе.
+`,
+		HTML: `<p>Здравствуйте, {{.RecipientName}}!</p>
+<p>В событии, на которое вы зарегистрированы, изменилось:</p>
+<ul>{{range .Changes}}<li>{{if eq . "date"}}дата и время{{else if eq . "location"}}место{{else}}название{{end}}</li>{{end}}</ul>
+` + eventDetailsHTMLRU + `
+<p>Ваша регистрация остаётся в силе.</p>`,
+	},
+	KindEventPostponed: {
+		Subject: `Событие «{{.EventTitle}}» перенесено`,
+		Text: `Здравствуйте, {{.RecipientName}}!
+
+Событие ниже перенесено. Мы сообщим новую дату; ваша регистрация остаётся в силе.
+
+` + eventDetailsRU + `
+` + reasonRU,
+		HTML: `<p>Здравствуйте, {{.RecipientName}}!</p>
+<p>Событие ниже перенесено. Мы сообщим новую дату; ваша регистрация остаётся в силе.</p>
+` + eventDetailsHTMLRU + reasonHTMLRU,
+	},
+	KindEventCancelled: {
+		Subject: `Событие «{{.EventTitle}}» отменено`,
+		Text: `Здравствуйте, {{.RecipientName}}!
+
+К сожалению, событие ниже не состоится, ваша регистрация отменена.
+
+` + eventDetailsRU + `
+` + reasonRU,
+		HTML: `<p>Здравствуйте, {{.RecipientName}}!</p>
+<p>К сожалению, событие ниже не состоится, ваша регистрация отменена.</p>
+` + eventDetailsHTMLRU + reasonHTMLRU,
+	},
+	KindWaitlistPromoted: {
+		Subject: `Освободилось место: {{.EventTitle}}`,
+		Text: `Здравствуйте, {{.RecipientName}}!
+
+Хорошие новости: освободилось место, и ваша регистрация из листа ожидания подтверждена.
+
+` + eventDetailsRU + `
+` + reasonRU,
+		HTML: `<p>Здравствуйте, {{.RecipientName}}!</p>
+<p>Хорошие новости: освободилось место, и ваша регистрация из листа ожидания подтверждена.</p>
+` + eventDetailsHTMLRU + reasonHTMLRU,
+	},
+	KindEventReminder: {
+		Subject: `Напоминание: «{{.EventTitle}}» начнётся ` + startsInRU,
+		Text: `Здравствуйте, {{.RecipientName}}!
+
+Напоминаем, что мероприятие начнётся ` + startsInRU + `
+
+` + eventDetailsRU + `
+
+Билет доступен в разделе ваших регистраций.
+`,
+		HTML: `<p>Здравствуйте, {{.RecipientName}}!</p>
+<p>Напоминаем, что мероприятие начнётся ` + startsInRU + `</p>
+` + eventDetailsHTMLRU + `
+<p>Билет доступен в разделе ваших регистраций.</p>`,
+	},
+}
@@ -0,0 +1,310 @@
+// Package realtime fans out changes to connected clients, e.g. over
+// Server-Sent Events or WebSockets. Messages are kept for a while so clients
+// that lost their connection can resume where they left off.
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrLagging ends a subscription whose client doesn't keep up with the messages.
+// The client should reconnect and resume from the last message it received.
+var ErrLagging = errors.New("subscriber is lagging behind")
+
+// ErrHubClosed ends the subscriptions of a hub that was closed
+var ErrHubClosed = errors.New("realtime hub is closed")
+
+// HubConfig tunes a Hub
+type HubConfig struct {
+	History          int // Messages kept for clients resuming a stream
+	SubscriberBuffer int // Messages that may wait for a slow client before it is dropped
+}
+
+// DefaultHubConfig is a sensible configuration for a single server
+var DefaultHubConfig = HubConfig{
+	History:          1000,
+	SubscriberBuffer: 64,
+}
+
+// Message is a change of an event sent to subscribers
+type Message struct {
+	ID      uint64 // Increases with every message, so clients can resume after it
+	EventID int64
+	OwnerID int64 // Only the owner sees messages that aren't public
+	Public  bool
+	Name    string // Kind of change, e.g. availability
+	Data    []byte // JSON
+}
+
+// Filter selects the messages a subscriber receives
+type Filter struct {
+	EventIDs []int64  // Empty for every event
+	Names    []string // Empty for every kind of message
+	UserID   int64    // The subscriber, 0 if anonymous; sees the non-public messages of events they own
+}
+
+// Matches reports whether the filter selects msg
+func (f *Filter) Matches(msg *Message) bool {
+	if !msg.Public && (f.UserID == 0 || msg.OwnerID != f.UserID) {
+		return false
+	}
+	if len(f.Names) > 0 && !containsName(f.Names, msg.Name) {
+		return false
+	}
+	if len(f.EventIDs) == 0 {
+		return true
+	}
+	for _, id := range f.EventIDs {
+		if id == msg.EventID {
+			return true
+		}
+	}
+	return false
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Broadcaster passes published messages on to subscribers. Hub does so within
+// one process; a shared bus can implement it to reach the subscribers of
+// every instance of the server.
+type Broadcaster interface {
+	// Publish sends data as JSON; messages that aren't public only reach the owner
+	Publish(eventID, ownerID int64, public bool, name string, data interface{}) error
+	// Subscribe starts receiving the messages matching filter, first replaying
+	// those after lastID if it isn't 0; resumed reports whether that was possible
+	Subscribe(filter Filter, lastID uint64) (sub Subscriber, resumed bool, err error)
+	// Close ends every subscription
+	Close()
+}
+
+// Subscriber receives the messages matching its filter until it is closed
+type Subscriber interface {
+	// Messages delivers the messages. It is closed when the subscription ends; Err tells why.
+	Messages() <-chan *Message
+	// Err returns why the subscription ended, or nil if it was closed by its owner
+	Err() error
+	// Close ends the subscription
+	Close()
+}
+
+// subscription is a Subscriber of a Hub
+type subscription struct {
+	hub    *Hub
+	filter Filter
+	ch     chan *Message
+	err    error
+}
+
+func (s *subscription) Messages() <-chan *Message {
+	return s.ch
+}
+
+func (s *subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+func (s *subscription) Close() {
+	s.hub.remove(s, nil)
+}
+
+// Hub is the in-process Broadcaster. Publishing
+// never blocks: subscribers whose buffer is full are dropped with ErrLagging.
+type Hub struct {
+	config HubConfig
+
+	mu          sync.Mutex
+	firstID     uint64     // ID before the first message
+	nextID      uint64     // ID of the latest message
+	history     []*Message // Ring of the latest messages
+	start       int        // Index of the oldest message in history
+	subscribers map[*subscription]struct{}
+	closed      bool
+}
+
+// NewHub creates a hub
+func NewHub(config HubConfig) *Hub {
+	if config.History <= 0 {
+		config.History = DefaultHubConfig.History
+	}
+	if config.SubscriberBuffer <= 0 {
+		config.SubscriberBuffer = DefaultHubConfig.SubscriberBuffer
+	}
+
+	// IDs continue from the clock so IDs seen before a restart can't be
+	// mistaken for messages of this hub
+	firstID := uint64(time.Now().UnixNano()/int64(time.Millisecond)) * 1000
+	return &Hub{
+		config:      config,
+		firstID:     firstID,
+		nextID:      firstID,
+		subscribers: make(map[*subscription]struct{}),
+	}
+}
+
+// Publish sends data as JSON to the subscribers of an event. Messages about
+// events that aren't public only reach the owner of the event.
+func (h *Hub) Publish(eventID, ownerID int64, public bool, name string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return ErrHubClosed
+	}
+
+	h.nextID++
+	msg := &Message{ID: h.nextID, EventID: eventID, OwnerID: ownerID, Public: public, Name: name, Data: body}
+	if len(h.history) < h.config.History {
+		h.history = append(h.history, msg)
+	} else {
+		h.history[h.start] = msg
+		h.start = (h.start + 1) % len(h.history)
+	}
+
+	for sub := range h.subscribers {
+		if !sub.filter.Matches(msg) {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			h.removeLocked(sub, ErrLagging)
+		}
+	}
+	return nil
+}
+
+// Subscribe starts receiving the messages matching filter. With a lastID
+// other than 0 the messages published after it are replayed first; resumed
+// reports whether that was possible, otherwise the client missed messages and
+// has to fetch the current state again.
+func (h *Hub) Subscribe(filter Filter, lastID uint64) (Subscriber, bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, false, ErrHubClosed
+	}
+
+	var missed []*Message
+	resumed := false
+	if lastID != 0 {
+		resumed = h.canResume(lastID)
+		if resumed {
+			for i := range h.history {
+				msg := h.history[(h.start+i)%len(h.history)]
+				if msg.ID > lastID && filter.Matches(msg) {
+					missed = append(missed, msg)
+				}
+			}
+		}
+	}
+
+	// Make room for the replay, so resuming doesn't drop the subscriber right away
+	size := h.config.SubscriberBuffer
+	if len(missed) > size {
+		size = len(missed)
+	}
+	ch := make(chan *Message, size)
+	for _, msg := range missed {
+		ch <- msg
+	}
+
+	sub := &subscription{hub: h, filter: filter, ch: ch}
+	h.subscribers[sub] = struct{}{}
+	return sub, resumed, nil
+}
+
+// canResume reports whether every message after lastID is still in the history
+func (h *Hub) canResume(lastID uint64) bool {
+	if lastID < h.firstID || lastID > h.nextID {
+		// Not an ID of this hub, e.g. one from before a restart
+		return false
+	}
+	if lastID == h.nextID {
+		return true
+	}
+	return h.history[h.start].ID <= lastID+1
+}
+
+// Subscribers returns the number of open subscriptions
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+// Close ends every subscription with ErrHubClosed and stops accepting messages
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for sub := range h.subscribers {
+		h.removeLocked(sub, ErrHubClosed)
+	}
+}
+
+func (h *Hub) remove(sub *subscription, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(sub, err)
+}
+
+// removeLocked ends a subscription; h.mu must be held
+func (h *Hub) removeLocked(sub *subscription, err error) {
+	if _, ok := h.subscribers[sub]; !ok {
+		return
+	}
+	delete(h.subscribers, sub)
+	sub.err = err
+	close(sub.ch)
+}
+
+var (
+	defaultMu          sync.RWMutex
+	defaultBroadcaster Broadcaster
+)
+
+// SetDefault sets the broadcaster used by Publish and Default
+func SetDefault(b Broadcaster) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultBroadcaster = b
+}
+
+// Default returns the broadcaster set with SetDefault, or nil if there is none
+func Default() Broadcaster {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultBroadcaster
+}
+
+// Publish publishes to the default broadcaster. Live updates are a side effect of
+// the change that caused them, so failures are only logged.
+func Publish(eventID, ownerID int64, public bool, name string, data interface{}) {
+	h := Default()
+	if h == nil {
+		return
+	}
+	if err := h.Publish(eventID, ownerID, public, name, data); err != nil && err != ErrHubClosed {
+		slog.Error("Failed to publish live update", "update", name, "event_id", eventID, "error", err)
+	}
+}
@@ -0,0 +1,27 @@
+package realtime
+
+import (
+	"fmt"
+	"io"
+)
+
+// WriteSSE writes a message as a Server-Sent Event. The ID lets the client
+// resume after it with the Last-Event-ID header.
+func WriteSSE(w io.Writer, msg *Message) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Name, msg.Data)
+	return err
+}
+
+// WriteSSEData writes a Server-Sent Event without an ID, e.g. the current
+// state sent when a stream starts. Clients keep the ID of the last message.
+func WriteSSEData(w io.Writer, name string, data []byte) error {
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+	return err
+}
+
+// WriteSSEComment writes a comment, which clients ignore; it keeps idle
+// connections from being closed by proxies
+func WriteSSEComment(w io.Writer, comment string) error {
+	_, err := fmt.Fprintf(w, ": %s\n\n", comment)
+	return err
+}
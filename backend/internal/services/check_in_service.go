@@ -0,0 +1,421 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/metrics"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/tickets"
+)
+
+// CheckInService handles the business logic for tickets and checking in at the door
+type CheckInService struct {
+	eventService        *EventService
+	registrationService *RegistrationService
+}
+
+// NewCheckInService creates a new CheckInService
+func NewCheckInService() *CheckInService {
+	return &CheckInService{
+		eventService:        NewEventService(),
+		registrationService: NewRegistrationService(),
+	}
+}
+
+// GetTicketCode returns the signed ticket code of a registration. Only its
+// owner and the creator of the event can get it, and only once the
+// registration is confirmed or approved.
+func (s *CheckInService) GetTicketCode(ctx context.Context, registrationID int64, userID int64) (string, error) {
+	registration, err := s.registrationService.GetRegistrationByID(ctx, registrationID)
+	if err != nil {
+		return "", err
+	}
+
+	if registration.UserID != userID {
+		event, err := s.eventService.GetEventByID(ctx, registration.EventID)
+		if err != nil {
+			return "", err
+		}
+		if event.CreatorID != userID {
+			return "", forbidden("you don't have permission to view this ticket")
+		}
+	}
+
+	if !registration.HasTicket() {
+		return "", ErrTicketNotIssued
+	}
+
+	return tickets.Sign(registration.ID, registration.EventID), nil
+}
+
+// CheckIn verifies a scanned ticket code and marks its registration as
+// attended. A registration is only checked in once; scanning it again
+// returns the first check-in marked as a duplicate with ErrAlreadyCheckedIn.
+func (s *CheckInService) CheckIn(ctx context.Context, eventID int64, req *models.CheckInRequest, userID int64) (*models.CheckIn, error) {
+	if _, err := s.getOrganizedEvent(ctx, eventID, userID); err != nil {
+		return nil, err
+	}
+
+	code, err := tickets.Verify(req.Code)
+	if err != nil {
+		return nil, ErrInvalidTicket
+	}
+	if code.EventID != eventID {
+		return nil, ErrTicketWrongEvent
+	}
+
+	registration, err := s.registrationService.GetRegistrationWithEventDetails(ctx, code.RegistrationID)
+	if err != nil {
+		// A validly signed code of a registration that was since deleted
+		return nil, ErrTicketRevoked
+	}
+	if registration.EventID != eventID {
+		return nil, ErrTicketWrongEvent
+	}
+
+	checkIn := &models.CheckIn{
+		RegistrationID: registration.ID,
+		EventID:        registration.EventID,
+		FirstName:      registration.FirstName,
+		LastName:       registration.LastName,
+		GuestCount:     registration.GuestCount,
+		Guests:         registration.Guests,
+		TicketTypeName: registration.TicketTypeName,
+	}
+
+	// Only the first of concurrent scans of the same ticket updates the row
+	now := time.Now().UTC().Truncate(time.Second)
+	timer := metrics.QueryTimer("check_in")
+	result, err := database.DB.ExecContext(ctx, `
+		UPDATE registrations SET checked_in_at = ?, checked_in_by = ?
+		WHERE id = ? AND checked_in_at IS NULL AND `+ticketCondition,
+		now.Format(time.RFC3339), userID, registration.ID)
+	if err != nil {
+		return nil, err
+	}
+	timer.ObserveDuration()
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 1 {
+		logger.InfoContext(ctx, "Checked in", "registration_id", registration.ID, "event_id", eventID)
+		checkIn.CheckedInAt = now
+		publishCheckIn(ctx, checkIn)
+		return checkIn, nil
+	}
+
+	// Nothing was updated: either the ticket was scanned before or the
+	// registration no longer holds a ticket
+	current, err := s.registrationService.GetRegistrationByID(ctx, registration.ID)
+	if err != nil {
+		return nil, err
+	}
+	if current.CheckedInAt != nil {
+		logger.InfoContext(ctx, "Duplicate check-in scan", "registration_id", registration.ID, "event_id", eventID)
+		checkIn.CheckedInAt = *current.CheckedInAt
+		checkIn.Duplicate = true
+		return checkIn, ErrAlreadyCheckedIn
+	}
+	return nil, ErrTicketRevoked
+}
+
+// GetCheckInStats counts the ticket holders of an event and how many of them
+// have checked in
+func (s *CheckInService) GetCheckInStats(ctx context.Context, eventID int64, userID int64) (*models.CheckInStats, error) {
+	if _, err := s.getOrganizedEvent(ctx, eventID, userID); err != nil {
+		return nil, err
+	}
+
+	return countCheckIns(ctx, eventID)
+}
+
+// countCheckIns counts the ticket holders of an event and how many of them have checked in
+func countCheckIns(ctx context.Context, eventID int64) (*models.CheckInStats, error) {
+	defer metrics.QueryTimer("count_check_ins").ObserveDuration()
+
+	stats := &models.CheckInStats{EventID: eventID}
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*), `+registeredHeads+`,
+		       COUNT(checked_in_at), COALESCE(SUM(CASE WHEN checked_in_at IS NOT NULL THEN 1 + guest_count ELSE 0 END), 0)
+		FROM registrations
+		WHERE event_id = ? AND `+ticketCondition,
+		eventID).Scan(&stats.Registrations, &stats.Heads, &stats.CheckedIn, &stats.CheckedInHeads)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// getOrganizedEvent retrieves an event that the user may check in attendees of
+func (s *CheckInService) getOrganizedEvent(ctx context.Context, eventID int64, userID int64) (*models.Event, error) {
+	event, err := s.eventService.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if the user has permission to check in attendees of this event
+	if event.CreatorID != userID {
+		return nil, forbidden("you don't have permission to check in attendees of this event")
+	}
+
+	return event, nil
+}
+
+// GetManifest returns a signed snapshot of the valid tickets of an event, so
+// check-in staff can keep scanning when the venue loses its connection
+func (s *CheckInService) GetManifest(ctx context.Context, eventID int64, userID int64) (*models.SignedCheckInManifest, error) {
+	event, err := s.getOrganizedEvent(ctx, eventID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT r.id, r.first_name, r.last_name, r.guest_count, r.checked_in_at, r.checked_in_device, t.name
+		FROM registrations r
+		LEFT JOIN ticket_types t ON r.ticket_type_id = t.id
+		WHERE r.event_id = ? AND r.`+ticketCondition+`
+		ORDER BY r.last_name, r.first_name, r.id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	manifest := models.CheckInManifest{
+		EventID:     event.ID,
+		EventTitle:  event.Title,
+		GeneratedAt: time.Now().UTC().Truncate(time.Second),
+		Tickets:     []models.ManifestTicket{},
+	}
+	for rows.Next() {
+		var ticket models.ManifestTicket
+		var lastName, checkedInAt, checkedInDevice, ticketTypeName sql.NullString
+		if err := rows.Scan(
+			&ticket.RegistrationID,
+			&ticket.FirstName,
+			&lastName,
+			&ticket.GuestCount,
+			&checkedInAt,
+			&checkedInDevice,
+			&ticketTypeName,
+		); err != nil {
+			return nil, err
+		}
+
+		ticket.LastName = lastName.String
+		ticket.CodeHash = tickets.HashCode(tickets.Sign(ticket.RegistrationID, event.ID))
+		ticket.CheckedInAt = parseNullTime(checkedInAt)
+		ticket.CheckedInDevice = checkedInDevice.String
+		ticket.TicketTypeName = ticketTypeName.String
+		manifest.Tickets = append(manifest.Tickets, ticket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SignedCheckInManifest{
+		Manifest:  manifest,
+		Algorithm: tickets.ManifestAlgorithm,
+		Signature: tickets.SignManifest(payload),
+	}, nil
+}
+
+// SyncCheckIns reconciles the check-ins a device recorded while offline.
+// Records are identified by the device and their own ID, so a batch can be
+// uploaded again after a lost response without checking anybody in twice.
+// When a ticket was scanned on several devices the earliest scan wins and the
+// others are reported as duplicates.
+func (s *CheckInService) SyncCheckIns(ctx context.Context, eventID int64, req *models.CheckInSyncRequest, userID int64) (*models.CheckInSyncResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	event, err := s.getOrganizedEvent(ctx, eventID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.CheckInSyncResponse{
+		EventID: eventID,
+		Results: make([]models.CheckInSyncRecordResult, 0, len(req.Records)),
+	}
+	accepted := 0
+	for _, record := range req.Records {
+		result, err := syncCheckInRecord(ctx, event, req.DeviceID, record, userID)
+		if err != nil {
+			return nil, err
+		}
+		if result.Result == models.SyncAccepted && !result.Replayed {
+			accepted++
+			if result.RegistrationID != nil && result.CheckedInAt != nil {
+				publishSyncedCheckIn(ctx, eventID, *result.RegistrationID, *result.CheckedInAt)
+			}
+		}
+		response.Results = append(response.Results, *result)
+	}
+
+	stats, err := countCheckIns(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	response.Stats = *stats
+
+	logger.InfoContext(ctx, "Synced offline check-ins", "event_id", eventID, "device_id", req.DeviceID,
+		"records", len(req.Records), "accepted", accepted)
+	return response, nil
+}
+
+// syncCheckInRecord reconciles a single offline check-in record
+func syncCheckInRecord(ctx context.Context, event *models.Event, deviceID string, record models.CheckInRecord, userID int64) (*models.CheckInSyncRecordResult, error) {
+	eventID := event.ID
+	timer := metrics.QueryTimer("sync_check_in")
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &models.CheckInSyncRecordResult{ID: record.ID}
+
+	// A record uploaded before is answered with the outcome stored for it
+	var registrationID sql.NullInt64
+	err = tx.QueryRowContext(ctx, `
+		SELECT registration_id, result FROM checkin_records
+		WHERE event_id = ? AND device_id = ? AND record_id = ?
+	`, eventID, deviceID, record.ID).Scan(&registrationID, &result.Result)
+	if err == nil {
+		result.Replayed = true
+		if registrationID.Valid {
+			result.RegistrationID = &registrationID.Int64
+			if err := loadCheckInState(ctx, tx, result); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// Device clocks can't be trusted, so scans are kept between the opening of
+	// the doors and now; otherwise a backdated scan would always win
+	now := time.Now().UTC().Truncate(time.Second)
+	scannedAt := clampScanTime(record.ScannedAt, event, now)
+
+	result.Result, err = reconcileScan(ctx, tx, eventID, deviceID, record.Code, scannedAt, userID, result)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO checkin_records (event_id, record_id, device_id, registration_id, scanned_at, received_at, uploaded_by, result)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, eventID, record.ID, deviceID, result.RegistrationID, scannedAt.Format(time.RFC3339), now.Format(time.RFC3339), userID, result.Result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	timer.ObserveDuration()
+	return result, nil
+}
+
+// clampScanTime moves the time a device claims a scan was made into the
+// window the scan can have happened in: from the doors opening until now
+func clampScanTime(scannedAt time.Time, event *models.Event, now time.Time) time.Time {
+	scannedAt = scannedAt.UTC().Truncate(time.Second)
+	if opensAt := event.EventDate.Add(-models.CheckInOpensBefore).UTC(); scannedAt.Before(opensAt) {
+		scannedAt = opensAt
+	}
+	if scannedAt.After(now) {
+		scannedAt = now
+	}
+	return scannedAt
+}
+
+// reconcileScan applies a scan of a ticket code made at scannedAt, checking
+// in the registration unless an earlier scan already did
+func reconcileScan(ctx context.Context, tx *sql.Tx, eventID int64, deviceID, code string, scannedAt time.Time, userID int64, result *models.CheckInSyncRecordResult) (models.CheckInSyncResult, error) {
+	ticket, err := tickets.Verify(code)
+	if err != nil {
+		return models.SyncInvalid, nil
+	}
+	if ticket.EventID != eventID {
+		return models.SyncWrongEvent, nil
+	}
+
+	var registrationEventID int64
+	var hasTicket bool
+	var checkedInAt sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT event_id, `+ticketCondition+`, checked_in_at FROM registrations WHERE id = ?
+	`, ticket.RegistrationID).Scan(&registrationEventID, &hasTicket, &checkedInAt)
+	if err == sql.ErrNoRows {
+		return models.SyncRevoked, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if registrationEventID != eventID {
+		return models.SyncWrongEvent, nil
+	}
+	result.RegistrationID = &ticket.RegistrationID
+	if !hasTicket {
+		return models.SyncRevoked, nil
+	}
+
+	earlier := parseNullTime(checkedInAt)
+	outcome := models.SyncDuplicate
+	if earlier == nil || scannedAt.Before(*earlier) {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE registrations SET checked_in_at = ?, checked_in_by = ?, checked_in_device = ?
+			WHERE id = ?
+		`, scannedAt.Format(time.RFC3339), userID, deviceID, ticket.RegistrationID)
+		if err != nil {
+			return "", err
+		}
+
+		// The scan that won before turns into a duplicate of this earlier one
+		_, err = tx.ExecContext(ctx, `
+			UPDATE checkin_records SET result = ?
+			WHERE registration_id = ? AND result = ?
+		`, models.SyncDuplicate, ticket.RegistrationID, models.SyncAccepted)
+		if err != nil {
+			return "", err
+		}
+		outcome = models.SyncAccepted
+	}
+
+	return outcome, loadCheckInState(ctx, tx, result)
+}
+
+// loadCheckInState fills in when and on which device the registration of a result was checked in
+func loadCheckInState(ctx context.Context, tx *sql.Tx, result *models.CheckInSyncRecordResult) error {
+	var checkedInAt, checkedInDevice sql.NullString
+	err := tx.QueryRowContext(ctx, "SELECT checked_in_at, checked_in_device FROM registrations WHERE id = ?", *result.RegistrationID).
+		Scan(&checkedInAt, &checkedInDevice)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	result.CheckedInAt = parseNullTime(checkedInAt)
+	result.CheckedInDevice = checkedInDevice.String
+	return nil
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/models"
+)
+
+func TestClampScanTime(t *testing.T) {
+	eventDate := time.Date(2026, time.May, 9, 18, 0, 0, 0, time.UTC)
+	event := &models.Event{EventDate: eventDate}
+	opensAt := eventDate.Add(-models.CheckInOpensBefore)
+	now := eventDate.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		scannedAt time.Time
+		want      time.Time
+	}{
+		{"at the door", eventDate.Add(-10 * time.Minute), eventDate.Add(-10 * time.Minute)},
+		{"backdated", eventDate.AddDate(0, 0, -3), opensAt},
+		{"zero", time.Time{}, opensAt},
+		{"in the future", now.Add(time.Hour), now},
+		{"sub-second", eventDate.Add(1500 * time.Millisecond), eventDate.Add(time.Second)},
+		{"other time zone", eventDate.In(time.FixedZone("UTC+3", 3*60*60)), eventDate},
+	}
+
+	for _, tt := range tests {
+		got := clampScanTime(tt.scannedAt, event, now)
+		if !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("%s: clampScanTime(%v) = %v, want %v in UTC", tt.name, tt.scannedAt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+// Error is a business rule violation with a stable, machine readable code
+// that clients can rely on instead of matching on the message
+type Error struct {
+	Code    string
+	Message string
+}
+
+// Error returns the human readable message
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var (
+	// ErrEventNotFound is returned when registering for an event that doesn't exist
+	ErrEventNotFound = &Error{Code: "event_not_found", Message: "event not found"}
+	// ErrVenueNotFound is returned when an event references a venue that doesn't exist
+	ErrVenueNotFound = &Error{Code: "venue_not_found", Message: "venue not found"}
+	// ErrVenueCapacityExceeded is returned when an event has more seats than its venue holds
+	ErrVenueCapacityExceeded = &Error{Code: "venue_capacity_exceeded", Message: "number of seats exceeds the venue capacity"}
+	// ErrVenueConflict is returned when an event overlaps another event at the same venue
+	ErrVenueConflict = &Error{Code: "venue_conflict", Message: "another event is scheduled at this venue at the same time"}
+	// ErrVenueInUse is returned when deleting a venue that events still reference
+	ErrVenueInUse = &Error{Code: "venue_in_use", Message: "venue is used by existing events"}
+	// ErrEventNotEditable is returned when changing an event that was cancelled or has completed
+	ErrEventNotEditable = &Error{Code: "event_not_editable", Message: "cancelled and completed events can't be changed"}
+	// ErrInvalidStatusTransition is returned when an event can't move to the requested status
+	ErrInvalidStatusTransition = &Error{Code: "invalid_status_transition", Message: "the event can't move to the requested status"}
+	// ErrEventEnded is returned when registering for an event that is over
+	ErrEventEnded = &Error{Code: "event_ended", Message: "cannot register for a past event"}
+	// ErrEventFullyBooked is returned when every seat of an event is taken
+	ErrEventFullyBooked = &Error{Code: "event_fully_booked", Message: "event is fully booked"}
+	// ErrAlreadyRegistered is returned when a user registers for an event a second time
+	ErrAlreadyRegistered = &Error{Code: "already_registered", Message: "you have already registered for this event"}
+	// ErrEventNotEnded is returned when marking an event completed before it is over
+	ErrEventNotEnded = &Error{Code: "event_not_ended", Message: "an event can only be completed once it has ended"}
+	// ErrEventNotOpen is returned when registering for an event that isn't published
+	ErrEventNotOpen = &Error{Code: "event_not_open", Message: "event is not open for registration"}
+	// ErrTicketTypeNotFound is returned when a ticket type doesn't exist or belongs to another event
+	ErrTicketTypeNotFound = &Error{Code: "ticket_type_not_found", Message: "ticket type not found"}
+	// ErrTicketTypeRequired is returned when registering for an event with ticket types without picking one
+	ErrTicketTypeRequired = &Error{Code: "ticket_type_required", Message: "a ticket type must be selected for this event"}
+	// ErrTicketSalesClosed is returned when a ticket type is picked outside its sales window
+	ErrTicketSalesClosed = &Error{Code: "ticket_sales_closed", Message: "tickets of this type are not on sale"}
+	// ErrTicketTypeSoldOut is returned when every seat of a ticket type is taken
+	ErrTicketTypeSoldOut = &Error{Code: "ticket_type_sold_out", Message: "ticket type is sold out"}
+	// ErrTicketCapacityExceeded is returned when the ticket types of an event hold more seats than the event
+	ErrTicketCapacityExceeded = &Error{Code: "ticket_capacity_exceeded", Message: "ticket type capacities exceed the seats of the event"}
+	// ErrTicketTypeInUse is returned when deleting a ticket type that registrations still hold
+	ErrTicketTypeInUse = &Error{Code: "ticket_type_in_use", Message: "ticket type has registrations"}
+	// ErrTooManyGuests is returned when a registration brings more guests than the event allows
+	ErrTooManyGuests = &Error{Code: "too_many_guests", Message: "too many guests for this event"}
+	// ErrNotEnoughSeats is returned when some seats are left but not enough for a whole party
+	ErrNotEnoughSeats = &Error{Code: "not_enough_seats", Message: "not enough seats left for the whole party"}
+	// ErrInvalidRegistrationTransition is returned when a registration can't move to the requested status
+	ErrInvalidRegistrationTransition = &Error{Code: "invalid_registration_transition", Message: "the registration can't move to the requested status"}
+	// ErrRegistrationNotYetOpen is returned when registering before the registration window of an event opens
+	ErrRegistrationNotYetOpen = &Error{Code: "registration_not_yet_open", Message: "registration for this event has not opened yet"}
+	// ErrRegistrationClosed is returned when registering after the registration window of an event closed
+	ErrRegistrationClosed = &Error{Code: "registration_closed", Message: "registration for this event is closed"}
+	// ErrCancellationDeadlinePassed is returned when cancelling a registration after the event's cancellation deadline
+	ErrCancellationDeadlinePassed = &Error{Code: "cancellation_deadline_passed", Message: "the cancellation deadline for this event has passed"}
+	// ErrTicketNotIssued is returned when asking for the ticket of a registration that isn't confirmed or approved
+	ErrTicketNotIssued = &Error{Code: "ticket_not_issued", Message: "no ticket is issued for this registration"}
+	// ErrInvalidTicket is returned when a scanned ticket code is malformed or not signed by us
+	ErrInvalidTicket = &Error{Code: "invalid_ticket", Message: "ticket code is not valid"}
+	// ErrTicketWrongEvent is returned when a ticket of another event is scanned
+	ErrTicketWrongEvent = &Error{Code: "ticket_wrong_event", Message: "ticket is for a different event"}
+	// ErrTicketRevoked is returned when the registration of a scanned ticket no longer holds a ticket, e.g. after cancelling
+	ErrTicketRevoked = &Error{Code: "ticket_revoked", Message: "the registration of this ticket is no longer valid"}
+	// ErrAlreadyCheckedIn is returned when a ticket is scanned again after its registration was checked in
+	ErrAlreadyCheckedIn = &Error{Code: "already_checked_in", Message: "ticket was already checked in"}
+	// ErrRegistrationCheckedIn is returned when moving a registration that was already checked in to another event
+	ErrRegistrationCheckedIn = &Error{Code: "registration_checked_in", Message: "a registration that was checked in can't move to another event"}
+	// ErrTooManyNoShows is returned when a user who missed too many events registers for an event that limits no-shows
+	ErrTooManyNoShows = &Error{Code: "too_many_no_shows", Message: "you missed too many events you registered for recently"}
+	// ErrFeedbackNotOpen is returned when leaving feedback on an event that hasn't ended yet
+	ErrFeedbackNotOpen = &Error{Code: "feedback_not_open", Message: "feedback opens once the event has ended"}
+	// ErrFeedbackClosed is returned when leaving feedback after the feedback window of an event
+	ErrFeedbackClosed = &Error{Code: "feedback_closed", Message: "feedback for this event is closed"}
+	// ErrFeedbackNotAllowed is returned when a registration didn't attend the event it would rate
+	ErrFeedbackNotAllowed = &Error{Code: "feedback_not_allowed", Message: "only attendees of the event can leave feedback"}
+	// ErrFeedbackExists is returned when leaving feedback for a registration a second time
+	ErrFeedbackExists = &Error{Code: "feedback_exists", Message: "feedback was already left for this registration"}
+	// ErrNotSeriesCreator is returned when someone other than its creator changes a series
+	ErrNotSeriesCreator = &Error{Code: "not_series_creator", Message: "you don't have permission to change this series"}
+	// ErrSeriesCancelled is returned when cancelling a series a second time
+	ErrSeriesCancelled = &Error{Code: "series_cancelled", Message: "series is already cancelled"}
+	// ErrDeliveryNotDead is returned when retrying a webhook delivery that didn't fail for good
+	ErrDeliveryNotDead = &Error{Code: "delivery_not_dead", Message: "only dead webhook deliveries can be retried"}
+)
+
+// CodeForbidden is the code of errors about acting on a registration or event
+// that belongs to someone else
+const CodeForbidden = "forbidden"
+
+// forbidden reports that the user isn't allowed to do what the message describes
+func forbidden(message string) *Error {
+	return &Error{Code: CodeForbidden, Message: message}
+}
+
+// CodeInvalidAnswer is the code of errors about answers that don't fit the questions of an event
+const CodeInvalidAnswer = "invalid_answer"
+
+// invalidAnswer wraps a problem with the answers given when registering
+func invalidAnswer(err error) *Error {
+	return &Error{Code: CodeInvalidAnswer, Message: err.Error()}
+}
+
+// CodeInvalidSeries is the code of errors about a series request or its recurrence rule
+const CodeInvalidSeries = "invalid_series"
+
+// invalidSeries wraps a problem with the request for a new series
+func invalidSeries(err error) *Error {
+	return &Error{Code: CodeInvalidSeries, Message: err.Error()}
+}
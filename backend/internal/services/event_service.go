@@ -1,160 +1,201 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"log"
+	"sort"
 	"time"
 
 	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/geo"
+	"github.com/netpo4ki/event-poster/internal/metrics"
 	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/tracing"
 )
 
 // EventService handles the business logic for events
-type EventService struct{}
+type EventService struct {
+	venueService *VenueService
+}
 
 // NewEventService creates a new EventService
 func NewEventService() *EventService {
-	return &EventService{}
+	return &EventService{
+		venueService: NewVenueService(),
+	}
 }
 
-// GetAllEvents retrieves all events from the database
-func (s *EventService) GetAllEvents() ([]models.Event, error) {
-	log.Println("GetAllEvents: Retrieving all events")
+// GetAllEvents retrieves all events from the database that match the filter
+func (s *EventService) GetAllEvents(ctx context.Context, filter models.EventFilter) ([]models.Event, error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetAllEvents")
+	defer span.End()
+
+	// Bring statuses up to date first
+	s.PublishScheduledEvents(ctx)
+	s.CompleteExpiredEvents(ctx)
 
-	// Delete expired events first
-	s.DeleteExpiredEvents()
+	defer metrics.QueryTimer("list_events").ObserveDuration()
 
-	rows, err := database.DB.Query(`
-		SELECT id, title, description, location, event_type, event_date, seats, creator_id, created_at
+	// Only published events are listed publicly
+	query := `
+		SELECT ` + eventColumns + `
 		FROM events
-		ORDER BY event_date
-	`)
+		WHERE status = ?
+	`
+	args := []interface{}{models.EventPublished}
+	if filter.HappeningNow {
+		now := time.Now().UTC().Format(time.RFC3339)
+		query += " AND event_date <= ? AND end_date > ?"
+		args = append(args, now, now)
+	}
+	if filter.Near != nil {
+		// Cheap, indexed prefilter; exact distances are computed below
+		box := geo.BoundingBoxAround(*filter.Near, filter.RadiusKm)
+		query += " AND latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?"
+		args = append(args, box.MinLat, box.MaxLat, box.MinLng, box.MaxLng)
+	}
+	query += " ORDER BY event_date"
+
+	rows, err := database.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		log.Printf("GetAllEvents error: %v", err)
+		logger.ErrorContext(ctx, "Failed to list events", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
 
 	var events []models.Event
 	for rows.Next() {
-		var event models.Event
-		var eventDateStr string
-		var createdAtStr string
-		var creatorID sql.NullInt64
-		var description, location sql.NullString
-
-		if err := rows.Scan(
-			&event.ID,
-			&event.Title,
-			&description,
-			&location,
-			&event.EventType,
-			&eventDateStr,
-			&event.Seats,
-			&creatorID,
-			&createdAtStr); err != nil {
-			log.Printf("GetAllEvents scan error: %v", err)
+		event, err := scanEvent(rows)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to read listed event", "error", err)
 			return nil, err
 		}
-
-		event.EventDate, _ = time.Parse(time.RFC3339, eventDateStr)
-		event.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
-		if creatorID.Valid {
-			event.CreatorID = creatorID.Int64
-		}
-		if description.Valid {
-			event.Description = description.String
-		}
-		if location.Valid {
-			event.Location = location.String
+		if filter.Near != nil {
+			distance := geo.DistanceKm(*filter.Near, *event.Coordinates())
+			if distance > filter.RadiusKm {
+				continue
+			}
+			event.DistanceKm = &distance
 		}
-		events = append(events, event)
+		events = append(events, *event)
 	}
 
-	log.Printf("GetAllEvents: Found %d events", len(events))
+	if filter.Near != nil {
+		sort.SliceStable(events, func(i, j int) bool {
+			return *events[i].DistanceKm < *events[j].DistanceKm
+		})
+	}
+
+	logger.DebugContext(ctx, "Listed events", "count", len(events))
 	return events, nil
 }
 
 // GetEventByID retrieves a single event by ID
-func (s *EventService) GetEventByID(id int64) (*models.Event, error) {
-	var event models.Event
-	var eventDateStr string
-	var createdAtStr string
-	var creatorID sql.NullInt64
-	var description, location sql.NullString
+func (s *EventService) GetEventByID(ctx context.Context, id int64) (*models.Event, error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetEventByID")
+	defer span.End()
 
-	err := database.DB.QueryRow(`
-		SELECT id, title, description, location, event_type, event_date, seats, creator_id, created_at
+	defer metrics.QueryTimer("get_event").ObserveDuration()
+
+	row := database.DB.QueryRowContext(ctx, `
+		SELECT `+eventColumns+`
 		FROM events
 		WHERE id = ?
-	`, id).Scan(
-		&event.ID,
-		&event.Title,
-		&description,
-		&location,
-		&event.EventType,
-		&eventDateStr,
-		&event.Seats,
-		&creatorID,
-		&createdAtStr)
-
-	if err != nil {
-		return nil, err
-	}
-
-	event.EventDate, _ = time.Parse(time.RFC3339, eventDateStr)
-	event.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
-	if creatorID.Valid {
-		event.CreatorID = creatorID.Int64
-	}
-	if description.Valid {
-		event.Description = description.String
-	}
-	if location.Valid {
-		event.Location = location.String
-	}
+	`, id)
 
-	return &event, nil
+	return scanEvent(row)
 }
 
 // CreateEvent creates a new event
-func (s *EventService) CreateEvent(req *models.EventRequest, userID int64) (int64, error) {
+func (s *EventService) CreateEvent(ctx context.Context, req *models.EventRequest, userID int64) (int64, error) {
+	ctx, span := tracing.Start(ctx, "EventService.CreateEvent")
+	defer span.End()
+
+	req.Normalize()
 	if err := req.Validate(); err != nil {
-		log.Printf("CreateEvent validation error: %v", err)
+		logger.InfoContext(ctx, "Event refused", "user_id", userID, "reason", "invalid request", "error", err)
+		return 0, err
+	}
+	if err := req.ValidateEventDate(time.Now()); err != nil {
+		logger.InfoContext(ctx, "Event refused", "user_id", userID, "reason", "past date")
 		return 0, err
 	}
 
-	log.Printf("CreateEvent: Creating event %s", req.Title)
+	venue, err := s.venueService.prepareEventVenue(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.venueService.checkEventConflicts(ctx, req, req.EventDate, *req.EndDate, nil); err != nil {
+		return 0, err
+	}
+	latitude, longitude := nullCoordinates(s.venueService.locateEvent(req, venue))
+
+	logger.DebugContext(ctx, "Creating event", "user_id", userID, "title", req.Title)
 
-	result, err := database.DB.Exec(`
-		INSERT INTO events (title, description, location, event_type, event_date, seats, creator_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, req.Title, req.Description, req.Location, req.EventType, req.EventDate.Format(time.RFC3339), req.Seats, userID)
+	timer := metrics.QueryTimer("create_event")
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO events (title, description, location, event_type, event_date, end_date, timezone, seats, max_guests_per_registration,
+			max_no_shows, reminder_offsets, requires_approval, reserve_pending_seats, registration_opens_at, registration_closes_at,
+			cancellation_deadline, creator_id, venue_id, latitude, longitude, status, publish_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, req.Title, req.Description, req.Location, req.EventType, req.EventDate.UTC().Format(time.RFC3339),
+		req.EndDate.UTC().Format(time.RFC3339), req.Timezone, req.Seats, req.MaxGuests, req.MaxNoShows,
+		models.FormatReminderOffsets(req.ReminderOffsets), req.RequiresApproval, req.ReservePendingSeats,
+		nullTime(req.RegistrationOpensAt), nullTime(req.RegistrationClosesAt), nullTime(req.CancellationDeadline),
+		userID, req.VenueID, latitude, longitude, req.Status, nullTime(req.PublishAt))
 
 	if err != nil {
-		log.Printf("CreateEvent database error: %v", err)
+		logger.ErrorContext(ctx, "Failed to insert event", "user_id", userID, "error", err)
 		return 0, err
 	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
-		log.Printf("CreateEvent LastInsertId error: %v", err)
+		logger.ErrorContext(ctx, "Failed to get ID of new event", "user_id", userID, "error", err)
 		return 0, err
 	}
 
-	log.Printf("CreateEvent: Successfully created event with ID %d", id)
+	if err := syncReminders(ctx, tx, id, req.Status, req.EventDate, req.ReminderOffsets); err != nil {
+		logger.ErrorContext(ctx, "Failed to schedule reminders of new event", "event_id", id, "error", err)
+		return 0, err
+	}
+	if err := emitEventWebhook(ctx, tx, models.TopicEventCreated, id); err != nil {
+		logger.ErrorContext(ctx, "Failed to queue webhook for new event", "event_id", id, "error", err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	timer.ObserveDuration()
+
+	logger.InfoContext(ctx, "Event created", "event_id", id, "user_id", userID, "status", req.Status)
+	metrics.EventsCreated.Inc()
+	publishAvailability(ctx, id)
 	return id, nil
 }
 
-// UpdateEvent updates an existing event
-func (s *EventService) UpdateEvent(id int64, req *models.EventRequest, userID int64) error {
+// UpdateEvent updates an existing event. For events that belong to a series,
+// ScopeFuture applies the change to this and every later occurrence, shifting
+// their local dates and times of day by the same amount as this one.
+func (s *EventService) UpdateEvent(ctx context.Context, id int64, req *models.EventRequest, userID int64, scope models.UpdateScope) error {
+	ctx, span := tracing.Start(ctx, "EventService.UpdateEvent")
+	defer span.End()
+
+	req.Normalize()
 	if err := req.Validate(); err != nil {
 		return err
 	}
 
 	// Check if the event exists
-	event, err := s.GetEventByID(id)
+	event, err := s.GetEventByID(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -164,45 +205,178 @@ func (s *EventService) UpdateEvent(id int64, req *models.EventRequest, userID in
 		return errors.New("you don't have permission to update this event")
 	}
 
-	// Check if there are existing registrations
-	var registrationsCount int
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM registrations WHERE event_id = ?", id).Scan(&registrationsCount)
+	// Cancelled and completed events are final
+	if !event.Status.IsEditable() {
+		return ErrEventNotEditable
+	}
+
+	// Events that have started can still be edited, but not moved into the past
+	if !req.EventDate.Equal(event.EventDate) {
+		if err := req.ValidateEventDate(time.Now()); err != nil {
+			return err
+		}
+	}
+
+	venue, err := s.venueService.prepareEventVenue(ctx, req)
 	if err != nil {
 		return err
 	}
+	latitude, longitude := nullCoordinates(s.venueService.locateEvent(req, venue))
+
+	targets := []models.Event{*event}
+	if scope == models.ScopeFuture && event.SeriesID != nil {
+		occurrences, err := s.GetEventsBySeries(ctx, *event.SeriesID, &event.EventDate)
+		if err != nil {
+			return err
+		}
 
-	// If updating seats, ensure there are enough for existing registrations
-	if req.Seats < registrationsCount {
-		return errors.New("cannot reduce seats below the number of existing registrations")
+		// Later occurrences that were cancelled or already completed stay as they are
+		targets = targets[:0]
+		for _, occurrence := range occurrences {
+			if occurrence.Status.IsEditable() {
+				targets = append(targets, occurrence)
+			}
+		}
 	}
 
-	// Update the event
-	result, err := database.DB.Exec(`
-		UPDATE events
-		SET title = ?, description = ?, location = ?, event_type = ?, event_date = ?, seats = ?
-		WHERE id = ?
-	`, req.Title, req.Description, req.Location, req.EventType, req.EventDate.Format(time.RFC3339), req.Seats, id)
+	// The events being moved can't conflict with themselves
+	targetIDs := make([]int64, 0, len(targets))
+	recipients := make(map[int64][]recipient, len(targets))
+	for _, target := range targets {
+		targetIDs = append(targetIDs, target.ID)
+		recipients[target.ID], err = eventRecipients(ctx, target.ID)
+		if err != nil {
+			return err
+		}
+	}
 
+	timer := metrics.QueryTimer("update_event")
+	tx, err := database.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
+	duration := req.Duration()
+	for _, target := range targets {
+		startDate := models.ShiftWallClock(target.LocalEventDate(), event.LocalEventDate(), req.LocalEventDate())
+		if err := s.venueService.checkEventConflicts(ctx, req, startDate, startDate.Add(duration), targetIDs); err != nil {
+			return err
+		}
+
+		// Check the seats taken by existing registrations and their guests
+		var registrationsCount int
+		err = tx.QueryRowContext(ctx, "SELECT "+registeredHeads+" FROM registrations WHERE event_id = ? AND "+seatHoldingCondition, target.ID).Scan(&registrationsCount)
+		if err != nil {
+			return err
+		}
+
+		// If updating seats, ensure there are enough for existing registrations
+		if req.Seats < registrationsCount {
+			return errors.New("cannot reduce seats below the number of existing registrations")
+		}
+
+		// The ticket types of the event must still fit into it
+		var ticketCapacity int
+		err = tx.QueryRowContext(ctx, "SELECT COALESCE(SUM(capacity), 0) FROM ticket_types WHERE event_id = ?", target.ID).Scan(&ticketCapacity)
+		if err != nil {
+			return err
+		}
+		if req.Seats < ticketCapacity {
+			return ErrTicketCapacityExceeded
+		}
+
+		// The registration window moves along with the occurrence
+		window := req.RegistrationWindow.Shift(startDate.Sub(req.EventDate))
+
+		// Update the event
+		result, err := tx.ExecContext(ctx, `
+			UPDATE events
+			SET title = ?, description = ?, location = ?, event_type = ?, event_date = ?, end_date = ?, timezone = ?, seats = ?,
+				max_guests_per_registration = ?, max_no_shows = ?, reminder_offsets = ?, requires_approval = ?, reserve_pending_seats = ?,
+				registration_opens_at = ?, registration_closes_at = ?, cancellation_deadline = ?, venue_id = ?, latitude = ?, longitude = ?,
+				publish_at = CASE WHEN status = 'draft' THEN ? ELSE publish_at END
+			WHERE id = ?
+		`, req.Title, req.Description, req.Location, req.EventType, startDate.UTC().Format(time.RFC3339),
+			startDate.Add(duration).UTC().Format(time.RFC3339), req.Timezone, req.Seats, req.MaxGuests, req.MaxNoShows,
+			models.FormatReminderOffsets(req.ReminderOffsets), req.RequiresApproval, req.ReservePendingSeats, nullTime(window.RegistrationOpensAt), nullTime(window.RegistrationClosesAt),
+			nullTime(window.CancellationDeadline), req.VenueID, latitude, longitude,
+			nullTime(req.PublishAt), target.ID)
+
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return errors.New("event not found")
+		}
+
+		// Reminders follow the event to its new date
+		if err := syncReminders(ctx, tx, target.ID, target.Status, startDate, req.ReminderOffsets); err != nil {
+			return err
+		}
+		if err := emitEventWebhook(ctx, tx, models.TopicEventUpdated, target.ID); err != nil {
+			return err
+		}
 	}
 
-	if rowsAffected == 0 {
-		return errors.New("event not found")
+	// Keep the series template in sync so it describes the upcoming occurrences
+	if scope == models.ScopeFuture && event.SeriesID != nil {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE event_series
+			SET title = ?, description = ?, location = ?, event_type = ?, timezone = ?, seats = ?, duration_minutes = ?, venue_id = ?
+			WHERE id = ?
+		`, req.Title, req.Description, req.Location, req.EventType, req.Timezone, req.Seats, int(duration.Minutes()), req.VenueID, *event.SeriesID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
+	timer.ObserveDuration()
 
+	// Tell attendees about the changes that affect them
+	for _, target := range targets {
+		publishAvailability(ctx, target.ID)
+		updated, err := s.GetEventByID(ctx, target.ID)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to reload updated event for notifications", "event_id", target.ID, "error", err)
+			continue
+		}
+		notifyEventUpdated(ctx, updated, recipients[target.ID], eventChanges(&target, updated))
+	}
 	return nil
 }
 
+// eventChanges lists the details attendees care about that differ between two versions of an event
+func eventChanges(before, after *models.Event) []string {
+	var changes []string
+	if !before.EventDate.Equal(after.EventDate) || !before.EndDate.Equal(after.EndDate) || before.Timezone != after.Timezone {
+		changes = append(changes, "date")
+	}
+	if before.Location != after.Location || !sameID(before.VenueID, after.VenueID) {
+		changes = append(changes, "location")
+	}
+	if before.Title != after.Title {
+		changes = append(changes, "title")
+	}
+	return changes
+}
+
 // DeleteEvent deletes an event by ID
-func (s *EventService) DeleteEvent(id int64, userID int64) error {
+func (s *EventService) DeleteEvent(ctx context.Context, id int64, userID int64) error {
+	ctx, span := tracing.Start(ctx, "EventService.DeleteEvent")
+	defer span.End()
+
 	// Check if the event exists
-	event, err := s.GetEventByID(id)
+	event, err := s.GetEventByID(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -212,8 +386,24 @@ func (s *EventService) DeleteEvent(id int64, userID int64) error {
 		return errors.New("you don't have permission to delete this event")
 	}
 
+	// Attendees of an upcoming event are told it won't take place
+	var recipients []recipient
+	if !event.HasEnded(time.Now()) {
+		recipients, err = eventRecipients(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	timer := metrics.QueryTimer("delete_event")
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Delete the event (this will also delete associated registrations due to ON DELETE CASCADE)
-	result, err := database.DB.Exec("DELETE FROM events WHERE id = ?", id)
+	result, err := tx.ExecContext(ctx, "DELETE FROM events WHERE id = ?", id)
 	if err != nil {
 		return err
 	}
@@ -227,101 +417,427 @@ func (s *EventService) DeleteEvent(id int64, userID int64) error {
 		return errors.New("event not found")
 	}
 
+	// The event is gone, so webhooks get it as it was
+	if err := emitWebhook(ctx, tx, models.TopicEventDeleted, event.ID, event.CreatorID, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	timer.ObserveDuration()
+
+	if err := cancelReminders(ctx, database.DB, id); err != nil {
+		logger.ErrorContext(ctx, "Failed to cancel reminders of deleted event", "event_id", id, "error", err)
+	}
+
+	logger.InfoContext(ctx, "Event deleted", "event_id", id, "user_id", userID)
+	publishEventDeleted(ctx, event)
+
+	notifyAttendees(ctx, event, recipients, models.EventCancelled, "")
 	return nil
 }
 
-// DeleteExpiredEvents deletes events that have already passed
-func (s *EventService) DeleteExpiredEvents() error {
-	log.Println("DeleteExpiredEvents: Deleting events that have passed")
+// ChangeStatus moves an event to another lifecycle status. Cancelling keeps the
+// registrations but marks them cancelled, and attendees are told about
+// cancellations and postponements.
+func (s *EventService) ChangeStatus(ctx context.Context, id int64, req *models.StatusChangeRequest, userID int64) error {
+	ctx, span := tracing.Start(ctx, "EventService.ChangeStatus")
+	defer span.End()
 
-	// Use current time for comparison
-	now := time.Now()
+	if err := req.Validate(); err != nil {
+		return err
+	}
 
-	// Query events that have passed
-	rows, err := database.DB.Query(`
-		SELECT id, title, event_date
-		FROM events
-		WHERE event_date < ?
-	`, now.Format(time.RFC3339))
+	event, err := s.GetEventByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if the user has permission to change this event
+	if event.CreatorID != userID {
+		return errors.New("you don't have permission to change this event")
+	}
 
+	if !event.Status.CanTransitionTo(req.Status) {
+		return ErrInvalidStatusTransition
+	}
+
+	// Completing an event opens feedback and attendance, so it has to be over
+	if req.Status == models.EventCompleted && !event.HasEnded(time.Now()) {
+		return ErrEventNotEnded
+	}
+
+	// Look up who to tell before their registrations get cancelled
+	recipients, err := eventRecipients(ctx, id)
 	if err != nil {
-		log.Printf("DeleteExpiredEvents query error: %v", err)
 		return err
 	}
-	defer rows.Close()
 
-	// Collect events to be deleted
-	var expiredEvents []int64
+	timer := metrics.QueryTimer("change_event_status")
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE events
+		SET status = ?, status_reason = ?, publish_at = NULL
+		WHERE id = ?
+	`, req.Status, req.Reason, id)
+	if err != nil {
+		return err
+	}
+
+	if err := syncReminders(ctx, tx, id, req.Status, event.EventDate, event.ReminderOffsets); err != nil {
+		return err
+	}
+
+	cancelledRegistrations := 0
+	if req.Status == models.EventCancelled {
+		cancelledRegistrations, err = cancelEventRegistrations(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := emitEventWebhook(ctx, tx, models.TopicEventUpdated, id); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	timer.ObserveDuration()
+
+	logger.InfoContext(ctx, "Event status changed", "event_id", id, "old_status", event.Status, "status", req.Status,
+		"cancelled_registrations", cancelledRegistrations)
+
+	publishAvailability(ctx, id)
+	notifyAttendees(ctx, event, recipients, req.Status, req.Reason)
+	return nil
+}
+
+// cancelEventRegistrations marks every active registration of an event cancelled
+func cancelEventRegistrations(ctx context.Context, tx *sql.Tx, eventID int64) (int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM registrations WHERE event_id = ? AND "+activeRegistrationCondition, eventID)
+	if err != nil {
+		return 0, err
+	}
+	var registrationIDs []int64
 	for rows.Next() {
 		var id int64
-		var title string
-		var eventDateStr string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		registrationIDs = append(registrationIDs, id)
+	}
+	rows.Close()
 
-		if err := rows.Scan(&id, &title, &eventDateStr); err != nil {
-			log.Printf("DeleteExpiredEvents scan error: %v", err)
-			continue
+	_, err = tx.ExecContext(ctx, `
+		UPDATE registrations SET status = ?
+		WHERE event_id = ? AND `+activeRegistrationCondition,
+		models.RegistrationCancelled, eventID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range registrationIDs {
+		if err := emitRegistrationWebhook(ctx, tx, models.TopicRegistrationCancelled, id); err != nil {
+			return 0, err
 		}
+	}
+	return len(registrationIDs), nil
+}
 
-		eventDate, err := time.Parse(time.RFC3339, eventDateStr)
-		if err != nil {
-			log.Printf("DeleteExpiredEvents time parsing error: %v", err)
-			continue
+// PublishScheduledEvents publishes drafts whose publish_at time has come
+func (s *EventService) PublishScheduledEvents(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "EventService.PublishScheduledEvents")
+	defer span.End()
+
+	timer := metrics.QueryTimer("publish_scheduled_events")
+	published, err := moveEvents(ctx,
+		"status = ? AND publish_at IS NOT NULL AND publish_at <= ?",
+		"status = ?, publish_at = NULL",
+		[]interface{}{models.EventDraft, time.Now().UTC().Format(time.RFC3339)},
+		models.EventPublished)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to publish scheduled events", "error", err)
+		return err
+	}
+	timer.ObserveDuration()
+	metrics.EventsPublished.Add(float64(len(published)))
+
+	if len(published) > 0 {
+		logger.InfoContext(ctx, "Published scheduled events", "event_ids", published)
+	}
+	publishAvailability(ctx, published...)
+	return nil
+}
+
+// CompleteExpiredEvents marks published events that have ended as completed.
+// Their registrations are kept so attendance and feedback can refer to them.
+func (s *EventService) CompleteExpiredEvents(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "EventService.CompleteExpiredEvents")
+	defer span.End()
+
+	// Dates are stored in UTC, so compare against the current instant in UTC
+	now := time.Now().UTC()
+
+	// An event that has started is still running until its end date
+	timer := metrics.QueryTimer("complete_expired_events")
+	completed, err := moveEvents(ctx,
+		"status = ? AND end_date < ?",
+		"status = ?",
+		[]interface{}{models.EventPublished, now.Format(time.RFC3339)},
+		models.EventCompleted)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to complete expired events", "error", err)
+		return err
+	}
+	timer.ObserveDuration()
+	metrics.EventsCompleted.Add(float64(len(completed)))
+
+	if len(completed) > 0 {
+		logger.InfoContext(ctx, "Completed expired events", "event_ids", completed)
+	}
+	publishAvailability(ctx, completed...)
+	return nil
+}
+
+// moveEvents applies set, whose first argument is status, to the events
+// matching where, adds an event.updated webhook for each, and returns their IDs
+func moveEvents(ctx context.Context, where, set string, whereArgs []interface{}, status models.EventStatus) ([]int64, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM events WHERE "+where, whereArgs...)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
 		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if len(ids) == 0 {
+		return nil, nil
+	}
 
-		// Double check the date is actually in the past
-		if eventDate.Before(now) {
-			expiredEvents = append(expiredEvents, id)
-			log.Printf("DeleteExpiredEvents: Will delete expired event ID %d: %s (date: %s)",
-				id, title, eventDate.Format(time.RFC3339))
+	args := append([]interface{}{status}, whereArgs...)
+	if _, err := tx.ExecContext(ctx, "UPDATE events SET "+set+" WHERE "+where, args...); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if err := emitEventWebhook(ctx, tx, models.TopicEventUpdated, id); err != nil {
+			return nil, err
 		}
 	}
+	return ids, tx.Commit()
+}
 
-	// If no expired events, return early
-	if len(expiredEvents) == 0 {
-		return nil
+// GetRegistrationsCountForEvent gets the number of seats taken by the registrations
+// for an event, counting each guest as well
+func (s *EventService) GetRegistrationsCountForEvent(ctx context.Context, eventID int64) (int, error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetRegistrationsCountForEvent")
+	defer span.End()
+
+	defer metrics.QueryTimer("count_registrations").ObserveDuration()
+
+	var count int
+	err := database.DB.QueryRowContext(ctx, "SELECT "+registeredHeads+" FROM registrations WHERE event_id = ? AND "+seatHoldingCondition, eventID).Scan(&count)
+	return count, err
+}
+
+// checkInUsedCondition selects events e where at least one registration was checked in.
+// Attendance is only known for those, so only they produce no-shows.
+const checkInUsedCondition = "EXISTS (SELECT 1 FROM registrations c WHERE c.event_id = e.id AND c.checked_in_at IS NOT NULL)"
+
+// GetAttendanceForEvent compares the ticket holders of an event with who checked in
+func (s *EventService) GetAttendanceForEvent(ctx context.Context, eventID int64) (*models.EventAttendance, error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetAttendanceForEvent")
+	defer span.End()
+
+	event, err := s.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Delete the confirmed expired events
-	for _, id := range expiredEvents {
-		_, err := database.DB.Exec("DELETE FROM events WHERE id = ?", id)
-		if err != nil {
-			log.Printf("DeleteExpiredEvents delete error for event %d: %v", id, err)
+	attendance := &models.EventAttendance{
+		EventID: eventID,
+		Ended:   event.HasEnded(time.Now()),
+	}
+	err = database.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*), `+registeredHeads+`,
+		       COUNT(checked_in_at), COALESCE(SUM(CASE WHEN checked_in_at IS NOT NULL THEN 1 + guest_count ELSE 0 END), 0)
+		FROM registrations
+		WHERE event_id = ? AND `+ticketCondition,
+		eventID).Scan(&attendance.Registrations, &attendance.Heads, &attendance.CheckedIn, &attendance.CheckedInHeads)
+	if err != nil {
+		return nil, err
+	}
+
+	attendance.Tracked = attendance.CheckedIn > 0
+	if attendance.Ended && attendance.Tracked {
+		attendance.NoShows = attendance.Registrations - attendance.CheckedIn
+		attendance.NoShowHeads = attendance.Heads - attendance.CheckedInHeads
+	}
+	if attendance.Registrations > 0 {
+		attendance.AttendanceRate = float64(attendance.CheckedIn) / float64(attendance.Registrations)
+	}
+
+	return attendance, nil
+}
+
+// GetUserAttendance retrieves the attendance history of a user across the
+// events they held a ticket for
+func (s *EventService) GetUserAttendance(ctx context.Context, userID int64) (*models.UserAttendance, error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetUserAttendance")
+	defer span.End()
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT r.id, e.id, e.title, e.event_date, e.end_date, r.checked_in_at, `+checkInUsedCondition+`
+		FROM registrations r
+		JOIN events e ON r.event_id = e.id
+		WHERE r.user_id = ? AND r.`+ticketCondition+` AND e.status <> ?
+		ORDER BY e.event_date DESC
+	`, userID, models.EventCancelled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	attendance := &models.UserAttendance{
+		UserID:  userID,
+		History: []models.AttendanceRecord{},
+	}
+	for rows.Next() {
+		var record models.AttendanceRecord
+		var eventDateStr, endDateStr string
+		var checkedInAt sql.NullString
+		var checkInUsed bool
+		if err := rows.Scan(
+			&record.RegistrationID,
+			&record.EventID,
+			&record.EventTitle,
+			&eventDateStr,
+			&endDateStr,
+			&checkedInAt,
+			&checkInUsed,
+		); err != nil {
+			return nil, err
+		}
+
+		record.EventDate, _ = time.Parse(time.RFC3339, eventDateStr)
+		record.EventEndDate, _ = time.Parse(time.RFC3339, endDateStr)
+		record.CheckedInAt = parseNullTime(checkedInAt)
+
+		switch {
+		case record.CheckedInAt != nil:
+			record.Outcome = models.AttendanceAttended
+			attendance.Attended++
+		case now.Before(record.EventEndDate):
+			record.Outcome = models.AttendanceUpcoming
+		case checkInUsed:
+			record.Outcome = models.AttendanceNoShow
+			attendance.NoShows++
+		default:
+			record.Outcome = models.AttendanceUntracked
 		}
+		attendance.History = append(attendance.History, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	log.Printf("DeleteExpiredEvents: Deleted %d expired events", len(expiredEvents))
-	return nil
+	if tracked := attendance.Attended + attendance.NoShows; tracked > 0 {
+		attendance.AttendanceRate = float64(attendance.Attended) / float64(tracked)
+	}
+
+	return attendance, nil
 }
 
-// GetRegistrationsCountForEvent gets the number of registrations for an event
-func (s *EventService) GetRegistrationsCountForEvent(eventID int64) (int, error) {
+// CountNoShows counts the events that ended since the given time which the
+// user held a ticket for but never checked in to
+func (s *EventService) CountNoShows(ctx context.Context, userID int64, since time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "EventService.CountNoShows")
+	defer span.End()
+
 	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM registrations WHERE event_id = ?", eventID).Scan(&count)
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM registrations r
+		JOIN events e ON r.event_id = e.id
+		WHERE r.user_id = ? AND r.`+ticketCondition+` AND r.checked_in_at IS NULL
+		  AND e.status <> ? AND e.end_date >= ? AND e.end_date < ? AND `+checkInUsedCondition,
+		userID, models.EventCancelled, since.UTC().Format(time.RFC3339), time.Now().UTC().Format(time.RFC3339)).Scan(&count)
 	return count, err
 }
 
-// HasAvailableSeats checks if an event has available seats
-func (s *EventService) HasAvailableSeats(eventID int64) (bool, error) {
-	event, err := s.GetEventByID(eventID)
+// HasAvailableSeats checks if an event has the given number of seats available.
+// When a ticket type is given, the seats must also be left in that tier.
+func (s *EventService) HasAvailableSeats(ctx context.Context, eventID int64, ticketTypeID *int64, seats int) (bool, error) {
+	ctx, span := tracing.Start(ctx, "EventService.HasAvailableSeats")
+	defer span.End()
+
+	defer metrics.QueryTimer("count_available_seats").ObserveDuration()
+
+	event, err := s.GetEventByID(ctx, eventID)
+	if err != nil {
+		return false, err
+	}
+
+	registrationsCount, err := s.GetRegistrationsCountForEvent(ctx, eventID)
 	if err != nil {
 		return false, err
 	}
 
-	registrationsCount, err := s.GetRegistrationsCountForEvent(eventID)
+	if event.Seats-registrationsCount < seats {
+		return false, nil
+	}
+	if ticketTypeID == nil {
+		return true, nil
+	}
+
+	var capacity, ticketRegistrationsCount int
+	err = database.DB.QueryRowContext(ctx, `
+		SELECT capacity,
+			(SELECT `+registeredHeads+` FROM registrations WHERE ticket_type_id = ticket_types.id AND `+seatHoldingCondition+`)
+		FROM ticket_types
+		WHERE id = ? AND event_id = ?
+	`, *ticketTypeID, eventID).Scan(&capacity, &ticketRegistrationsCount)
 	if err != nil {
 		return false, err
 	}
 
-	return event.Seats > registrationsCount, nil
+	return capacity-ticketRegistrationsCount >= seats, nil
 }
 
 // GetEventsByUser retrieves events created by a specific user
-func (s *EventService) GetEventsByUser(userID int64) ([]models.Event, error) {
-	// Delete expired events first
-	s.DeleteExpiredEvents()
+func (s *EventService) GetEventsByUser(ctx context.Context, userID int64) ([]models.Event, error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetEventsByUser")
+	defer span.End()
+
+	// Bring statuses up to date first
+	s.PublishScheduledEvents(ctx)
+	s.CompleteExpiredEvents(ctx)
 
-	rows, err := database.DB.Query(`
-		SELECT id, title, description, location, event_type, event_date, seats, creator_id, created_at
+	defer metrics.QueryTimer("list_user_events").ObserveDuration()
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT `+eventColumns+`
 		FROM events
 		WHERE creator_id = ?
 		ORDER BY event_date
@@ -333,38 +849,166 @@ func (s *EventService) GetEventsByUser(userID int64) ([]models.Event, error) {
 
 	var events []models.Event
 	for rows.Next() {
-		var event models.Event
-		var eventDateStr string
-		var createdAtStr string
-		var creatorID sql.NullInt64
-		var description, location sql.NullString
-
-		if err := rows.Scan(
-			&event.ID,
-			&event.Title,
-			&description,
-			&location,
-			&event.EventType,
-			&eventDateStr,
-			&event.Seats,
-			&creatorID,
-			&createdAtStr); err != nil {
+		event, err := scanEvent(rows)
+		if err != nil {
 			return nil, err
 		}
+		events = append(events, *event)
+	}
 
-		event.EventDate, _ = time.Parse(time.RFC3339, eventDateStr)
-		event.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
-		if creatorID.Valid {
-			event.CreatorID = creatorID.Int64
-		}
-		if description.Valid {
-			event.Description = description.String
-		}
-		if location.Valid {
-			event.Location = location.String
+	return events, nil
+}
+
+// GetEventsBySeries retrieves the occurrences of a series, optionally only those starting at or after from
+func (s *EventService) GetEventsBySeries(ctx context.Context, seriesID int64, from *time.Time) ([]models.Event, error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetEventsBySeries")
+	defer span.End()
+
+	query := `
+		SELECT ` + eventColumns + `
+		FROM events
+		WHERE series_id = ?
+	`
+	args := []interface{}{seriesID}
+	if from != nil {
+		query += " AND event_date >= ?"
+		args = append(args, from.UTC().Format(time.RFC3339))
+	}
+	query += " ORDER BY event_date"
+
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
 		}
-		events = append(events, event)
+		events = append(events, *event)
 	}
 
 	return events, nil
 }
+
+// eventColumns is the column list understood by scanEvent. The last columns
+// summarize the feedback ratings of the event.
+const eventColumns = "id, title, description, location, event_type, event_date, end_date, timezone, seats, max_guests_per_registration, max_no_shows, reminder_offsets, " +
+	"requires_approval, reserve_pending_seats, registration_opens_at, registration_closes_at, cancellation_deadline, " +
+	"creator_id, venue_id, series_id, latitude, longitude, " +
+	"status, status_reason, publish_at, created_at, " +
+	"(SELECT ROUND(AVG(rating), 2) FROM event_feedback WHERE event_id = events.id), " +
+	"(SELECT COUNT(*) FROM event_feedback WHERE event_id = events.id)"
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEvent reads an event selected with eventColumns
+func scanEvent(row rowScanner) (*models.Event, error) {
+	var event models.Event
+	var eventDateStr, endDateStr string
+	var createdAtStr, reminderOffsets string
+	var creatorID, venueID, seriesID sql.NullInt64
+	var description, location, statusReason, publishAt sql.NullString
+	var registrationOpensAt, registrationClosesAt, cancellationDeadline sql.NullString
+	var latitude, longitude, averageRating sql.NullFloat64
+
+	if err := row.Scan(
+		&event.ID,
+		&event.Title,
+		&description,
+		&location,
+		&event.EventType,
+		&eventDateStr,
+		&endDateStr,
+		&event.Timezone,
+		&event.Seats,
+		&event.MaxGuests,
+		&event.MaxNoShows,
+		&reminderOffsets,
+		&event.RequiresApproval,
+		&event.ReservePendingSeats,
+		&registrationOpensAt,
+		&registrationClosesAt,
+		&cancellationDeadline,
+		&creatorID,
+		&venueID,
+		&seriesID,
+		&latitude,
+		&longitude,
+		&event.Status,
+		&statusReason,
+		&publishAt,
+		&createdAtStr,
+		&averageRating,
+		&event.RatingsCount); err != nil {
+		return nil, err
+	}
+
+	event.EventDate, _ = time.Parse(time.RFC3339, eventDateStr)
+	event.EndDate, _ = time.Parse(time.RFC3339, endDateStr)
+	event.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+	event.ReminderOffsets = models.ParseReminderOffsets(reminderOffsets)
+	if creatorID.Valid {
+		event.CreatorID = creatorID.Int64
+	}
+	if venueID.Valid {
+		id := venueID.Int64
+		event.VenueID = &id
+	}
+	if seriesID.Valid {
+		id := seriesID.Int64
+		event.SeriesID = &id
+	}
+	if statusReason.Valid {
+		event.StatusReason = statusReason.String
+	}
+	if publishAt.Valid {
+		if t, err := time.Parse(time.RFC3339, publishAt.String); err == nil {
+			event.PublishAt = &t
+		}
+	}
+	event.RegistrationOpensAt = parseNullTime(registrationOpensAt)
+	event.RegistrationClosesAt = parseNullTime(registrationClosesAt)
+	event.CancellationDeadline = parseNullTime(cancellationDeadline)
+	if latitude.Valid && longitude.Valid {
+		event.Latitude = &latitude.Float64
+		event.Longitude = &longitude.Float64
+	}
+	if averageRating.Valid {
+		event.AverageRating = &averageRating.Float64
+	}
+	if description.Valid {
+		event.Description = description.String
+	}
+	if location.Valid {
+		event.Location = location.String
+	}
+
+	return &event, nil
+}
+
+// nullTime converts an optional time into a nullable UTC RFC3339 column value
+func nullTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(time.RFC3339), Valid: true}
+}
+
+// parseNullTime converts a nullable RFC3339 column value into an optional time
+func parseNullTime(value sql.NullString) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value.String)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/models"
+)
+
+func TestUpdateEventRunning(t *testing.T) {
+	s := NewEventService()
+	ctx := context.Background()
+
+	organizer := createTestUser(t)
+	eventDate := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	endDate := eventDate.Add(3 * time.Hour)
+	eventID := createTestEvent(t, organizer, func(event *models.Event) {
+		event.EventDate = eventDate
+		event.EndDate = endDate
+	})
+
+	// Fixing the title of an event that has started keeps its date
+	req := &models.EventRequest{Title: "Fixed title", EventType: "meetup", EventDate: eventDate, EndDate: &endDate, Seats: 10}
+	if err := s.UpdateEvent(ctx, eventID, req, organizer, models.ScopeThis); err != nil {
+		t.Fatalf("updating a running event failed: %v", err)
+	}
+	event, err := s.GetEventByID(ctx, eventID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Title != "Fixed title" {
+		t.Errorf("title = %q, want it updated", event.Title)
+	}
+
+	// Moving it to another past date is still refused
+	earlier := eventDate.Add(-time.Hour)
+	req = &models.EventRequest{Title: "Fixed title", EventType: "meetup", EventDate: earlier, EndDate: &endDate, Seats: 10}
+	if err := s.UpdateEvent(ctx, eventID, req, organizer, models.ScopeThis); err == nil {
+		t.Error("moving an event into the past succeeded")
+	}
+}
+
+func TestChangeStatusCompleted(t *testing.T) {
+	s := NewEventService()
+	ctx := context.Background()
+
+	organizer := createTestUser(t)
+	running := createTestEvent(t, organizer, func(event *models.Event) {
+		event.EventDate = time.Now().UTC().Add(-time.Hour)
+		event.EndDate = time.Now().UTC().Add(time.Hour)
+	})
+	ended := createTestEvent(t, organizer, func(event *models.Event) {
+		event.EventDate = time.Now().UTC().Add(-3 * time.Hour)
+		event.EndDate = time.Now().UTC().Add(-time.Hour)
+	})
+
+	req := &models.StatusChangeRequest{Status: models.EventCompleted}
+	if err := s.ChangeStatus(ctx, createTestEvent(t, organizer, nil), req, organizer); err != ErrEventNotEnded {
+		t.Errorf("completing an upcoming event: err = %v, want ErrEventNotEnded", err)
+	}
+	if err := s.ChangeStatus(ctx, running, req, organizer); err != ErrEventNotEnded {
+		t.Errorf("completing a running event: err = %v, want ErrEventNotEnded", err)
+	}
+	if err := s.ChangeStatus(ctx, ended, req, organizer); err != nil {
+		t.Errorf("completing an event that has ended failed: %v", err)
+	}
+}
+
+func TestCompleteExpiredEventsWebhook(t *testing.T) {
+	s := NewEventService()
+	ctx := context.Background()
+
+	organizer := createTestUser(t)
+	_, err := database.DB.Exec(`
+		INSERT INTO webhooks (user_id, url, secret, created_at)
+		VALUES (?, ?, ?, ?)
+	`, organizer, "https://example.com/hook", "secret", time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		t.Fatalf("creating webhook: %v", err)
+	}
+	eventDate := time.Now().UTC().AddDate(0, 0, -1).Truncate(time.Second)
+	eventID := createTestEvent(t, organizer, func(event *models.Event) {
+		event.EventDate = eventDate
+		event.EndDate = eventDate.Add(time.Hour)
+	})
+
+	if err := s.CompleteExpiredEvents(ctx); err != nil {
+		t.Fatalf("completing expired events failed: %v", err)
+	}
+
+	// Subscribers hear about the completion like about any other status change
+	var queued int
+	err = database.DB.QueryRow("SELECT COUNT(*) FROM webhook_outbox WHERE event_id = ? AND topic = ?", eventID, models.TopicEventUpdated).Scan(&queued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queued != 1 {
+		t.Errorf("queued event.updated webhooks = %d, want 1", queued)
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/models"
+)
+
+// FeedbackService handles the business logic for post-event feedback
+type FeedbackService struct {
+	eventService        *EventService
+	registrationService *RegistrationService
+}
+
+// NewFeedbackService creates a new FeedbackService
+func NewFeedbackService() *FeedbackService {
+	return &FeedbackService{
+		eventService:        NewEventService(),
+		registrationService: NewRegistrationService(),
+	}
+}
+
+// SubmitFeedback stores the rating the owner of a registration gives its event.
+// Feedback is taken during models.FeedbackWindow after the event ended, once
+// per registration. When the event used check-in only those who checked in
+// can leave feedback.
+func (s *FeedbackService) SubmitFeedback(ctx context.Context, registrationID int64, req *models.FeedbackRequest, userID int64) (int64, error) {
+	if err := req.Validate(); err != nil {
+		return 0, err
+	}
+
+	registration, err := s.registrationService.GetRegistrationByID(ctx, registrationID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Check if the user has permission to leave feedback for this registration
+	if registration.UserID != userID {
+		return 0, errors.New("you don't have permission to leave feedback for this registration")
+	}
+	if !registration.HasTicket() {
+		return 0, ErrFeedbackNotAllowed
+	}
+
+	event, err := s.eventService.GetEventByID(ctx, registration.EventID)
+	if err != nil {
+		return 0, err
+	}
+	if event.Status == models.EventCancelled {
+		return 0, ErrFeedbackNotAllowed
+	}
+
+	now := time.Now()
+	if !event.HasEnded(now) {
+		return 0, ErrFeedbackNotOpen
+	}
+	if !event.FeedbackOpenAt(now) {
+		return 0, ErrFeedbackClosed
+	}
+
+	if registration.CheckedInAt == nil {
+		attendance, err := s.eventService.GetAttendanceForEvent(ctx, event.ID)
+		if err != nil {
+			return 0, err
+		}
+		if attendance.Tracked {
+			return 0, ErrFeedbackNotAllowed
+		}
+	}
+
+	result, err := database.DB.ExecContext(ctx, `
+		INSERT INTO event_feedback (event_id, registration_id, user_id, rating, comment, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (registration_id) DO NOTHING
+	`, event.ID, registration.ID, userID, req.Rating, req.Comment, now.UTC().Format(time.RFC3339))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to insert feedback", "registration_id", registration.ID, "error", err)
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rowsAffected == 0 {
+		return 0, ErrFeedbackExists
+	}
+
+	logger.InfoContext(ctx, "Feedback submitted", "registration_id", registration.ID, "event_id", event.ID, "rating", req.Rating)
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	publishToOrganizer(ctx, event.ID, models.DashboardFeedback, models.Feedback{
+		ID:             id,
+		EventID:        event.ID,
+		RegistrationID: registration.ID,
+		UserID:         userID,
+		Rating:         req.Rating,
+		Comment:        req.Comment,
+		CreatedAt:      now.UTC().Truncate(time.Second),
+	})
+	return id, nil
+}
+
+// GetEventFeedback retrieves the feedback on an event with its rating summary.
+// Only the creator of the event can see it.
+func (s *FeedbackService) GetEventFeedback(ctx context.Context, eventID int64, userID int64) (*models.EventFeedback, error) {
+	event, err := s.eventService.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if the user has permission to see the feedback on this event
+	if event.CreatorID != userID {
+		return nil, errors.New("you don't have permission to view the feedback on this event")
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, event_id, registration_id, user_id, rating, comment, created_at
+		FROM event_feedback
+		WHERE event_id = ?
+		ORDER BY created_at DESC, id DESC
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feedback := &models.EventFeedback{
+		EventID:  eventID,
+		Summary:  models.NewRatingSummary(),
+		Feedback: []models.Feedback{},
+	}
+	for rows.Next() {
+		var item models.Feedback
+		var userID sql.NullInt64
+		var comment sql.NullString
+		var createdAtStr string
+		if err := rows.Scan(&item.ID, &item.EventID, &item.RegistrationID, &userID, &item.Rating, &comment, &createdAtStr); err != nil {
+			return nil, err
+		}
+
+		item.UserID = userID.Int64
+		item.Comment = comment.String
+		item.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+		feedback.Summary.Add(item.Rating, 1)
+		feedback.Feedback = append(feedback.Feedback, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return feedback, nil
+}
+
+// GetOrganizerFeedback summarizes the ratings of every event the user created
+// that received feedback, most recent events first
+func (s *FeedbackService) GetOrganizerFeedback(ctx context.Context, userID int64) (*models.OrganizerFeedback, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT e.id, e.title, e.event_date, f.rating, COUNT(*)
+		FROM event_feedback f
+		JOIN events e ON f.event_id = e.id
+		WHERE e.creator_id = ?
+		GROUP BY e.id, f.rating
+		ORDER BY e.event_date DESC, e.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feedback := &models.OrganizerFeedback{
+		Overall: models.NewRatingSummary(),
+		Events:  []models.EventRatingSummary{},
+	}
+	for rows.Next() {
+		var eventID int64
+		var title, eventDateStr string
+		var rating, count int
+		if err := rows.Scan(&eventID, &title, &eventDateStr, &rating, &count); err != nil {
+			return nil, err
+		}
+
+		// Rows of the same event are adjacent thanks to the ordering
+		if len(feedback.Events) == 0 || feedback.Events[len(feedback.Events)-1].EventID != eventID {
+			eventDate, _ := time.Parse(time.RFC3339, eventDateStr)
+			feedback.Events = append(feedback.Events, models.EventRatingSummary{
+				EventID:    eventID,
+				EventTitle: title,
+				EventDate:  eventDate,
+				Summary:    models.NewRatingSummary(),
+			})
+		}
+		feedback.Events[len(feedback.Events)-1].Summary.Add(rating, count)
+		feedback.Overall.Add(rating, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return feedback, nil
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/notifications"
+)
+
+// InboxService handles the in-app notifications of users and where they want
+// their notifications delivered
+type InboxService struct{}
+
+// NewInboxService creates a new InboxService
+func NewInboxService() *InboxService {
+	return &InboxService{}
+}
+
+// GetNotifications lists the inbox of a user, newest first
+func (s *InboxService) GetNotifications(ctx context.Context, userID int64, filter models.NotificationFilter) ([]models.InboxNotification, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = models.DefaultNotificationsLimit
+	}
+	if filter.Limit > models.MaxNotificationsLimit {
+		filter.Limit = models.MaxNotificationsLimit
+	}
+
+	query := "SELECT " + inboxColumns + " FROM notifications WHERE user_id = ?"
+	args := []interface{}{userID}
+	if filter.UnreadOnly {
+		query += " AND read_at IS NULL"
+	}
+	if filter.BeforeID > 0 {
+		query += " AND id < ?"
+		args = append(args, filter.BeforeID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inbox := []models.InboxNotification{}
+	for rows.Next() {
+		notification, err := scanInboxNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		inbox = append(inbox, *notification)
+	}
+
+	return inbox, rows.Err()
+}
+
+// CountUnread counts the notifications a user hasn't read
+func (s *InboxService) CountUnread(ctx context.Context, userID int64) (int, error) {
+	var count int
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL", userID).Scan(&count)
+	return count, err
+}
+
+// MarkRead marks a notification of a user as read, or as unread again
+func (s *InboxService) MarkRead(ctx context.Context, id int64, userID int64, read bool) (*models.InboxNotification, error) {
+	readAt := sql.NullString{}
+	if read {
+		readAt = sql.NullString{String: time.Now().UTC().Format(time.RFC3339), Valid: true}
+	}
+
+	// Reading a notification again keeps the time it was first read
+	result, err := database.DB.ExecContext(ctx, `
+		UPDATE notifications SET read_at = CASE WHEN ? IS NULL THEN NULL ELSE COALESCE(read_at, ?) END
+		WHERE id = ? AND user_id = ?
+	`, readAt, readAt, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return scanInboxNotification(database.DB.QueryRowContext(ctx, "SELECT "+inboxColumns+" FROM notifications WHERE id = ?", id))
+}
+
+// MarkAllRead marks every unread notification of a user as read and returns how many there were
+func (s *InboxService) MarkAllRead(ctx context.Context, userID int64) (int, error) {
+	result, err := database.DB.ExecContext(ctx, "UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL",
+		time.Now().UTC().Format(time.RFC3339), userID)
+	if err != nil {
+		return 0, err
+	}
+
+	marked, err := result.RowsAffected()
+	return int(marked), err
+}
+
+// GetPreferences returns the channel of every kind of notification for a user
+func (s *InboxService) GetPreferences(ctx context.Context, userID int64) ([]models.NotificationPreference, error) {
+	rows, err := database.DB.QueryContext(ctx, "SELECT kind, channel FROM notification_preferences WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	chosen := make(map[string]models.NotificationChannel)
+	for rows.Next() {
+		var kind string
+		var channel models.NotificationChannel
+		if err := rows.Scan(&kind, &channel); err != nil {
+			return nil, err
+		}
+		chosen[kind] = channel
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	preferences := make([]models.NotificationPreference, 0, len(notifications.Kinds))
+	for _, kind := range notifications.Kinds {
+		channel, ok := chosen[string(kind)]
+		if !ok {
+			channel = models.DefaultNotificationChannel
+		}
+		preferences = append(preferences, models.NotificationPreference{Kind: string(kind), Channel: channel})
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences sets the channels of the listed kinds of notifications for a user
+func (s *InboxService) UpdatePreferences(ctx context.Context, userID int64, req *models.NotificationPreferencesRequest) ([]models.NotificationPreference, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	for kind := range req.Preferences {
+		if !notifications.Kind(kind).IsValid() {
+			return nil, fmt.Errorf("unknown notification kind %q", kind)
+		}
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for kind, channel := range req.Preferences {
+		// Kinds left on the default don't need a row
+		if channel == models.DefaultNotificationChannel {
+			_, err = tx.ExecContext(ctx, "DELETE FROM notification_preferences WHERE user_id = ? AND kind = ?", userID, kind)
+		} else {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO notification_preferences (user_id, kind, channel) VALUES (?, ?, ?)
+				ON CONFLICT (user_id, kind) DO UPDATE SET channel = excluded.channel
+			`, userID, kind, channel)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetPreferences(ctx, userID)
+}
+
+// deliverNotification sends a notification through the channels its recipient
+// picked for its kind: by email, into their inbox, or both. Recipients without
+// an account only get the email.
+func deliverNotification(ctx context.Context, n *notifications.Notification) {
+	channel := models.ChannelEmail
+	if n.UserID != 0 {
+		channel = notificationChannel(ctx, n.UserID, n.Kind)
+	}
+
+	if channel.InApp() {
+		if err := addToInbox(ctx, n); err != nil {
+			logger.ErrorContext(ctx, "Failed to add notification to inbox", "kind", n.Kind, "user_id", n.UserID, "error", err)
+		}
+	}
+	if channel.Email() {
+		notifications.Notify(n)
+	}
+}
+
+// notificationChannel looks up where a user wants notifications of a kind delivered
+func notificationChannel(ctx context.Context, userID int64, kind notifications.Kind) models.NotificationChannel {
+	var channel models.NotificationChannel
+	err := database.DB.QueryRowContext(ctx, "SELECT channel FROM notification_preferences WHERE user_id = ? AND kind = ?", userID, kind).Scan(&channel)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.ErrorContext(ctx, "Failed to look up notification preferences", "user_id", userID, "error", err)
+		}
+		return models.DefaultNotificationChannel
+	}
+	return channel
+}
+
+// addToInbox stores a notification rendered in the language of its recipient
+func addToInbox(ctx context.Context, n *notifications.Notification) error {
+	msg, err := notifications.Render(n)
+	if err != nil {
+		return err
+	}
+
+	var eventID, registrationID sql.NullInt64
+	if n.Data.EventID != 0 {
+		eventID = sql.NullInt64{Int64: n.Data.EventID, Valid: true}
+	}
+	if n.Data.RegistrationID != 0 {
+		registrationID = sql.NullInt64{Int64: n.Data.RegistrationID, Valid: true}
+	}
+
+	_, err = database.DB.ExecContext(ctx, `
+		INSERT INTO notifications (user_id, kind, title, body, event_id, registration_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, n.UserID, n.Kind, msg.Subject, msg.Text, eventID, registrationID, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// inboxColumns is the column list understood by scanInboxNotification
+const inboxColumns = "id, kind, title, body, event_id, registration_id, read_at, created_at"
+
+// scanInboxNotification reads a notification selected with inboxColumns
+func scanInboxNotification(row rowScanner) (*models.InboxNotification, error) {
+	var notification models.InboxNotification
+	var eventID, registrationID sql.NullInt64
+	var readAt sql.NullString
+	var createdAt string
+	if err := row.Scan(&notification.ID, &notification.Kind, &notification.Title, &notification.Body,
+		&eventID, &registrationID, &readAt, &createdAt); err != nil {
+		return nil, err
+	}
+
+	if eventID.Valid {
+		notification.EventID = &eventID.Int64
+	}
+	if registrationID.Valid {
+		notification.RegistrationID = &registrationID.Int64
+	}
+	notification.ReadAt = parseNullTime(readAt)
+	notification.Read = notification.ReadAt != nil
+	notification.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	return &notification, nil
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/models"
+)
+
+// JobService reports on the background jobs
+type JobService struct{}
+
+// NewJobService creates a new JobService
+func NewJobService() *JobService {
+	return &JobService{}
+}
+
+// GetJobsOverview retrieves the recurring jobs and counts the scheduled jobs by status
+func (s *JobService) GetJobsOverview(ctx context.Context) (*models.JobsOverview, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT name, schedule, next_run_at, attempts, locked_by, locked_until, last_run_at, last_status, last_error
+		FROM recurring_jobs
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overview := &models.JobsOverview{
+		Recurring: []models.RecurringJob{},
+		Scheduled: make(map[string]int),
+	}
+	for rows.Next() {
+		var job models.RecurringJob
+		var nextRunAt string
+		var lockedBy, lockedUntil, lastRunAt, lastStatus, lastError sql.NullString
+		if err := rows.Scan(&job.Name, &job.Schedule, &nextRunAt, &job.Attempts,
+			&lockedBy, &lockedUntil, &lastRunAt, &lastStatus, &lastError); err != nil {
+			return nil, err
+		}
+		job.NextRunAt, _ = time.Parse(time.RFC3339, nextRunAt)
+		job.LockedBy = lockedBy.String
+		job.LockedUntil = parseNullTime(lockedUntil)
+		job.LastRunAt = parseNullTime(lastRunAt)
+		job.LastStatus = lastStatus.String
+		job.LastError = lastError.String
+		overview.Recurring = append(overview.Recurring, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	counts, err := database.DB.QueryContext(ctx, "SELECT status, COUNT(*) FROM scheduled_jobs GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+	defer counts.Close()
+
+	for counts.Next() {
+		var status string
+		var count int
+		if err := counts.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		overview.Scheduled[status] = count
+	}
+
+	return overview, counts.Err()
+}
+
+// GetJobRuns retrieves the runs of background jobs that match the filter, newest first
+func (s *JobService) GetJobRuns(ctx context.Context, filter models.JobRunFilter) ([]models.JobRun, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = models.DefaultJobRunsLimit
+	}
+	if filter.Limit > models.MaxJobRunsLimit {
+		filter.Limit = models.MaxJobRunsLimit
+	}
+
+	query := `
+		SELECT id, job_name, job_id, attempt, worker_id, status, error, started_at, finished_at, duration_ms
+		FROM job_runs
+		WHERE 1 = 1
+	`
+	var args []interface{}
+	if filter.JobName != "" {
+		query += " AND job_name = ?"
+		args = append(args, filter.JobName)
+	}
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.BeforeID > 0 {
+		query += " AND id < ?"
+		args = append(args, filter.BeforeID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.JobRun{}
+	for rows.Next() {
+		var run models.JobRun
+		var jobID, durationMs sql.NullInt64
+		var runErr, finishedAt sql.NullString
+		var startedAt string
+		if err := rows.Scan(&run.ID, &run.JobName, &jobID, &run.Attempt, &run.WorkerID, &run.Status,
+			&runErr, &startedAt, &finishedAt, &durationMs); err != nil {
+			return nil, err
+		}
+		if jobID.Valid {
+			run.JobID = &jobID.Int64
+		}
+		if durationMs.Valid {
+			run.DurationMs = &durationMs.Int64
+		}
+		run.Error = runErr.String
+		run.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
+		run.FinishedAt = parseNullTime(finishedAt)
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/metrics"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/realtime"
+	"github.com/netpo4ki/event-poster/internal/tracing"
+)
+
+// Names of the live updates pushed to clients watching events
+const (
+	// LiveAvailability carries a models.SeatAvailability
+	LiveAvailability = "availability"
+	// LiveEventDeleted carries a models.EventRemoval
+	LiveEventDeleted = "deleted"
+)
+
+// waitlistCondition selects pending registrations that wait for a decision without holding a seat
+const waitlistCondition = "status = 'pending' AND seat_reserved = 0"
+
+// GetSeatAvailability returns the current seat availability of an event
+func (s *EventService) GetSeatAvailability(ctx context.Context, eventID int64) (*models.SeatAvailability, error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetSeatAvailability")
+	defer span.End()
+
+	event, err := s.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return seatAvailability(ctx, event)
+}
+
+// seatAvailability counts the taken and awaited seats of an event
+func seatAvailability(ctx context.Context, event *models.Event) (*models.SeatAvailability, error) {
+	defer metrics.QueryTimer("count_seat_availability").ObserveDuration()
+
+	availability := &models.SeatAvailability{
+		EventID:   event.ID,
+		Status:    event.Status,
+		Seats:     event.Seats,
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT
+			(SELECT `+registeredHeads+` FROM registrations WHERE event_id = ? AND `+seatHoldingCondition+`),
+			(SELECT `+registeredHeads+` FROM registrations WHERE event_id = ? AND `+waitlistCondition+`)
+	`, event.ID, event.ID).Scan(&availability.Registrations, &availability.Waitlist)
+	if err != nil {
+		return nil, err
+	}
+	availability.AvailableSeats = event.AvailableSeats(availability.Registrations)
+
+	rows, err := database.DB.QueryContext(ctx, "SELECT "+ticketTypeColumns+" FROM ticket_types WHERE event_id = ? AND visibility = 'public' ORDER BY id", event.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		ticketType, err := scanTicketType(rows)
+		if err != nil {
+			return nil, err
+		}
+		availability.TicketTypes = append(availability.TicketTypes, models.TicketTypeSeats{
+			ID:             ticketType.ID,
+			Name:           ticketType.Name,
+			AvailableSeats: ticketType.AvailableSeats,
+		})
+	}
+
+	return availability, rows.Err()
+}
+
+// publishAvailability pushes the seat availability of events to the clients
+// watching them. Call it after the change is committed.
+func publishAvailability(ctx context.Context, eventIDs ...int64) {
+	if realtime.Default() == nil {
+		return
+	}
+	for _, id := range eventIDs {
+		event, err := scanEvent(database.DB.QueryRowContext(ctx, "SELECT "+eventColumns+" FROM events WHERE id = ?", id))
+		if err != nil {
+			if err != sql.ErrNoRows {
+				logger.ErrorContext(ctx, "Failed to load event for live update", "event_id", id, "error", err)
+			}
+			continue
+		}
+		availability, err := seatAvailability(ctx, event)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to count seats for live update", "event_id", id, "error", err)
+			continue
+		}
+		realtime.Publish(event.ID, event.CreatorID, event.IsPubliclyVisible(), LiveAvailability, availability)
+	}
+}
+
+// publishEventDeleted tells the clients watching an event that it is gone
+func publishEventDeleted(ctx context.Context, event *models.Event) {
+	realtime.Publish(event.ID, event.CreatorID, event.IsPubliclyVisible(), LiveEventDeleted, models.EventRemoval{EventID: event.ID})
+}
+
+// publishToOrganizer pushes a message about an event to the dashboards of its
+// organizer. Call it after the change is committed.
+func publishToOrganizer(ctx context.Context, eventID int64, name string, data interface{}) {
+	if realtime.Default() == nil {
+		return
+	}
+	var creatorID sql.NullInt64
+	if err := database.DB.QueryRowContext(ctx, "SELECT creator_id FROM events WHERE id = ?", eventID).Scan(&creatorID); err != nil {
+		logger.ErrorContext(ctx, "Failed to look up the organizer for live update", "event_id", eventID, "error", err)
+		return
+	}
+	realtime.Publish(eventID, creatorID.Int64, false, name, data)
+}
+
+// publishRegistration pushes a registration to the dashboards of the organizer of its event
+func publishRegistration(ctx context.Context, registrationID int64, name string) {
+	if realtime.Default() == nil {
+		return
+	}
+	registration, err := scanRegistration(database.DB.QueryRowContext(ctx, "SELECT "+registrationColumns+" FROM registrations WHERE id = ?", registrationID))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to load registration for live update", "registration_id", registrationID, "error", err)
+		return
+	}
+	publishToOrganizer(ctx, registration.EventID, name, registration)
+}
+
+// publishCheckIn pushes a check-in with the new totals to the dashboards of the organizer
+func publishCheckIn(ctx context.Context, checkIn *models.CheckIn) {
+	if realtime.Default() == nil {
+		return
+	}
+	stats, err := countCheckIns(ctx, checkIn.EventID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to count check-ins for live update", "event_id", checkIn.EventID, "error", err)
+		return
+	}
+	publishToOrganizer(ctx, checkIn.EventID, models.DashboardCheckIn, models.CheckInUpdate{CheckIn: *checkIn, Stats: *stats})
+}
+
+// publishSyncedCheckIn pushes a check-in uploaded by an offline scanner like publishCheckIn
+func publishSyncedCheckIn(ctx context.Context, eventID, registrationID int64, checkedInAt time.Time) {
+	if realtime.Default() == nil {
+		return
+	}
+	registration, err := scanRegistration(database.DB.QueryRowContext(ctx, "SELECT "+registrationColumns+" FROM registrations WHERE id = ?", registrationID))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to load registration for live update", "registration_id", registrationID, "error", err)
+		return
+	}
+	publishCheckIn(ctx, &models.CheckIn{
+		RegistrationID: registration.ID,
+		EventID:        eventID,
+		FirstName:      registration.FirstName,
+		LastName:       registration.LastName,
+		GuestCount:     registration.GuestCount,
+		CheckedInAt:    checkedInAt,
+	})
+}
@@ -0,0 +1,13 @@
+package services
+
+import "log/slog"
+
+// logger is what the services log with. Log with the context of the request
+// being served so the lines carry its request ID, and log the IDs of users,
+// attendees and events rather than their details.
+var logger = slog.Default()
+
+// SetLogger sets the logger of the services. Call it before serving requests.
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/models"
+)
+
+// TestMain runs the tests against a fresh SQLite database in a temporary directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "event-poster-services")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	os.Setenv("DB_PATH", filepath.Join(dir, "test.db"))
+	database.InitDB()
+
+	code := m.Run()
+	database.DB.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// users counts the users created by the tests to give each a unique name
+var users atomic.Int64
+
+// createTestUser adds a user and returns its ID
+func createTestUser(t *testing.T) int64 {
+	t.Helper()
+
+	name := fmt.Sprintf("user%d", users.Add(1))
+	result, err := database.DB.Exec(`
+		INSERT INTO users (username, password, email, role, locale)
+		VALUES (?, ?, ?, ?, ?)
+	`, name, "x", name+"@example.com", models.RoleUser, "en")
+	if err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// createTestEvent adds a published event starting in a week. Change any of
+// its settings with configure before it is stored.
+func createTestEvent(t *testing.T, creatorID int64, configure func(event *models.Event)) int64 {
+	t.Helper()
+
+	eventDate := time.Now().UTC().AddDate(0, 0, 7).Truncate(time.Second)
+	event := &models.Event{
+		Title:     "Test event",
+		EventType: "meetup",
+		EventDate: eventDate,
+		EndDate:   eventDate.Add(models.DefaultEventDuration),
+		Timezone:  models.DefaultTimezone,
+		Seats:     10,
+		Status:    models.EventPublished,
+	}
+	if configure != nil {
+		configure(event)
+	}
+
+	result, err := database.DB.Exec(`
+		INSERT INTO events (title, event_type, event_date, end_date, timezone, seats, max_guests_per_registration,
+			reminder_offsets, requires_approval, reserve_pending_seats, registration_opens_at, registration_closes_at,
+			cancellation_deadline, creator_id, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, '', ?, ?, ?, ?, ?, ?, ?)
+	`, event.Title, event.EventType, event.EventDate.UTC().Format(time.RFC3339), event.EndDate.UTC().Format(time.RFC3339),
+		event.Timezone, event.Seats, event.MaxGuests, event.RequiresApproval, event.ReservePendingSeats,
+		nullTime(event.RegistrationOpensAt), nullTime(event.RegistrationClosesAt), nullTime(event.CancellationDeadline),
+		creatorID, event.Status)
+	if err != nil {
+		t.Fatalf("creating event: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// register registers a user for an event and fails the test if that doesn't work
+func register(t *testing.T, s *RegistrationService, eventID, userID int64) int64 {
+	t.Helper()
+
+	id, err := s.CreateRegistration(context.Background(), &models.RegistrationRequest{
+		EventID:   eventID,
+		FirstName: "Ada",
+		LastName:  "Lovelace",
+	}, &userID)
+	if err != nil {
+		t.Fatalf("registering user %d for event %d: %v", userID, eventID, err)
+	}
+	return id
+}
+
+// createTestTicketType adds a public ticket type that is on sale to an event
+func createTestTicketType(t *testing.T, eventID int64, capacity int) int64 {
+	t.Helper()
+
+	result, err := database.DB.Exec(`
+		INSERT INTO ticket_types (event_id, name, capacity, visibility)
+		VALUES (?, ?, ?, ?)
+	`, eventID, "Standard", capacity, models.TicketPublic)
+	if err != nil {
+		t.Fatalf("creating ticket type: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// seatsTaken counts the seats taken at an event, guests included
+func seatsTaken(t *testing.T, eventID int64) int {
+	t.Helper()
+
+	taken, err := NewEventService().GetRegistrationsCountForEvent(context.Background(), eventID)
+	if err != nil {
+		t.Fatalf("counting seats of event %d: %v", eventID, err)
+	}
+	return taken
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/notifications"
+)
+
+// recipient is a user holding a registration who gets notified about it
+type recipient struct {
+	registrationID int64
+	userID         int64
+	name           string
+	email          string
+	locale         string
+	guestCount     int
+}
+
+// eventRecipients looks up the users with an active registration for an event.
+// Look them up before changing the registrations, e.g. before cancelling them.
+func eventRecipients(ctx context.Context, eventID int64) ([]recipient, error) {
+	return queryRecipients(ctx, eventID, activeRegistrationCondition)
+}
+
+// ticketHolders looks up the users holding a ticket for an event
+func ticketHolders(ctx context.Context, eventID int64) ([]recipient, error) {
+	return queryRecipients(ctx, eventID, ticketCondition)
+}
+
+// queryRecipients looks up the users whose registrations for an event match condition
+func queryRecipients(ctx context.Context, eventID int64, condition string) ([]recipient, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT r.id, u.id, r.first_name, r.guest_count, u.username, u.email, u.locale
+		FROM registrations r
+		JOIN users u ON r.user_id = u.id
+		WHERE r.event_id = ? AND r.`+condition,
+		eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []recipient
+	for rows.Next() {
+		var r recipient
+		var firstName sql.NullString
+		var username string
+		if err := rows.Scan(&r.registrationID, &r.userID, &firstName, &r.guestCount, &username, &r.email, &r.locale); err != nil {
+			return nil, err
+		}
+		r.name = firstName.String
+		if r.name == "" {
+			r.name = username
+		}
+		recipients = append(recipients, r)
+	}
+
+	return recipients, rows.Err()
+}
+
+// eventData fills in the details of an event that every notification shows
+func eventData(event *models.Event) notifications.Data {
+	return notifications.Data{
+		EventID:       event.ID,
+		EventTitle:    event.Title,
+		EventDate:     event.LocalEventDate().Format("2006-01-02 15:04") + " (" + event.Timezone + ")",
+		EventLocation: event.Location,
+	}
+}
+
+// eventStatusKinds maps the event statuses attendees are told about to their notification
+var eventStatusKinds = map[models.EventStatus]notifications.Kind{
+	models.EventCancelled: notifications.KindEventCancelled,
+	models.EventPostponed: notifications.KindEventPostponed,
+}
+
+// notifyAttendees tells the recipients that an event moved to the given status
+func notifyAttendees(ctx context.Context, event *models.Event, recipients []recipient, status models.EventStatus, reason string) {
+	kind, ok := eventStatusKinds[status]
+	if !ok {
+		return
+	}
+
+	logger.InfoContext(ctx, "Notifying attendees of status change", "event_id", event.ID, "status", status, "attendees", len(recipients))
+	for _, r := range recipients {
+		data := eventData(event)
+		data.RecipientName = r.name
+		data.RegistrationID = r.registrationID
+		data.GuestCount = r.guestCount
+		data.Reason = reason
+		deliverNotification(ctx, &notifications.Notification{Kind: kind, UserID: r.userID, To: r.email, Locale: r.locale, Data: data})
+	}
+}
+
+// notifyEventUpdated tells the recipients which details of an event changed
+func notifyEventUpdated(ctx context.Context, event *models.Event, recipients []recipient, changes []string) {
+	if len(changes) == 0 {
+		return
+	}
+
+	logger.InfoContext(ctx, "Notifying attendees of event changes", "event_id", event.ID, "changes", changes, "attendees", len(recipients))
+	for _, r := range recipients {
+		data := eventData(event)
+		data.RecipientName = r.name
+		data.RegistrationID = r.registrationID
+		data.GuestCount = r.guestCount
+		data.Changes = changes
+		deliverNotification(ctx, &notifications.Notification{
+			Kind:   notifications.KindEventUpdated,
+			UserID: r.userID,
+			To:     r.email,
+			Locale: r.locale,
+			Data:   data,
+		})
+	}
+}
+
+// registrationStatusKinds maps registration statuses to the notification telling their owner
+var registrationStatusKinds = map[models.RegistrationStatus]notifications.Kind{
+	models.RegistrationConfirmed: notifications.KindRegistrationConfirmed,
+	models.RegistrationPending:   notifications.KindRegistrationPending,
+	models.RegistrationApproved:  notifications.KindRegistrationApproved,
+	models.RegistrationRejected:  notifications.KindRegistrationRejected,
+	models.RegistrationCancelled: notifications.KindRegistrationCancelled,
+}
+
+// notifyRegistrant tells the owner of a registration that it now has the given status.
+// Registrations made without an account have nobody to tell.
+func notifyRegistrant(ctx context.Context, registration *models.Registration, status models.RegistrationStatus, reason string) {
+	kind, ok := registrationStatusKinds[status]
+	if !ok {
+		return
+	}
+	sendRegistrantNotification(ctx, registration, kind, reason)
+}
+
+// sendRegistrantNotification sends a notification of the given kind to the owner of a registration
+func sendRegistrantNotification(ctx context.Context, registration *models.Registration, kind notifications.Kind, reason string) {
+	if registration.UserID == 0 {
+		return
+	}
+
+	var username, email, locale string
+	err := database.DB.QueryRowContext(ctx, "SELECT username, email, locale FROM users WHERE id = ?", registration.UserID).
+		Scan(&username, &email, &locale)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to look up registrant to notify", "user_id", registration.UserID, "error", err)
+		return
+	}
+
+	event, err := scanEvent(database.DB.QueryRowContext(ctx, "SELECT "+eventColumns+" FROM events WHERE id = ?", registration.EventID))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to look up event to notify registrant of", "event_id", registration.EventID, "error", err)
+		return
+	}
+
+	data := eventData(event)
+	data.RecipientName = registration.FirstName
+	if data.RecipientName == "" {
+		data.RecipientName = username
+	}
+	data.RegistrationID = registration.ID
+	data.GuestCount = registration.GuestCount
+	data.Reason = reason
+	deliverNotification(ctx, &notifications.Notification{
+		Kind:   kind,
+		UserID: registration.UserID,
+		To:     email,
+		Locale: locale,
+		Data:   data,
+	})
+}
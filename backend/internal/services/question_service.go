@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/models"
+)
+
+// QuestionService handles the business logic for custom registration questions
+type QuestionService struct {
+	eventService *EventService
+}
+
+// NewQuestionService creates a new QuestionService
+func NewQuestionService() *QuestionService {
+	return &QuestionService{
+		eventService: NewEventService(),
+	}
+}
+
+// questionColumns is the column list understood by scanQuestion
+const questionColumns = "id, event_id, version, position, label, type, options, required, created_at"
+
+// scanQuestion reads a question selected with questionColumns
+func scanQuestion(row rowScanner) (*models.Question, error) {
+	var question models.Question
+	var createdAtStr string
+	var options sql.NullString
+
+	if err := row.Scan(
+		&question.ID,
+		&question.EventID,
+		&question.Version,
+		&question.Position,
+		&question.Label,
+		&question.Type,
+		&options,
+		&question.Required,
+		&createdAtStr); err != nil {
+		return nil, err
+	}
+
+	question.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+	if options.Valid && options.String != "" {
+		if err := json.Unmarshal([]byte(options.String), &question.Options); err != nil {
+			return nil, err
+		}
+	}
+
+	return &question, nil
+}
+
+// queryRower is implemented by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// currentQuestionVersion returns the question version an event currently asks, 0 if it never had questions
+func currentQuestionVersion(ctx context.Context, q queryRower, eventID int64) (int, error) {
+	var version int
+	err := q.QueryRowContext(ctx, "SELECT question_version FROM events WHERE id = ?", eventID).Scan(&version)
+	return version, err
+}
+
+// GetQuestions retrieves the current questions of an event
+func (s *QuestionService) GetQuestions(ctx context.Context, eventID int64) ([]models.Question, error) {
+	version, err := currentQuestionVersion(ctx, database.DB, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queryQuestions(ctx, "WHERE event_id = ? AND version = ? ORDER BY position", eventID, version)
+}
+
+// GetAllQuestions retrieves the questions of every version of an event
+func (s *QuestionService) GetAllQuestions(ctx context.Context, eventID int64) ([]models.Question, error) {
+	return s.queryQuestions(ctx, "WHERE event_id = ? ORDER BY version, position", eventID)
+}
+
+func (s *QuestionService) queryQuestions(ctx context.Context, where string, args ...interface{}) ([]models.Question, error) {
+	rows, err := database.DB.QueryContext(ctx, "SELECT "+questionColumns+" FROM event_questions "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []models.Question{}
+	for rows.Next() {
+		question, err := scanQuestion(rows)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, *question)
+	}
+
+	return questions, nil
+}
+
+// SetQuestions replaces the questions of an event. While nobody has registered
+// with the current questions they are replaced in place; otherwise a new version
+// is created and the earlier one is kept for the answers already given.
+func (s *QuestionService) SetQuestions(ctx context.Context, eventID int64, req *models.QuestionsRequest, userID int64) (int, error) {
+	if err := req.Validate(); err != nil {
+		return 0, err
+	}
+
+	event, err := s.eventService.GetEventByID(ctx, eventID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Check if the user has permission to change this event
+	if event.CreatorID != userID {
+		return 0, errors.New("you don't have permission to update this event")
+	}
+	if !event.Status.IsEditable() {
+		return 0, ErrEventNotEditable
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	version, err := currentQuestionVersion(ctx, tx, eventID)
+	if err != nil {
+		return 0, err
+	}
+
+	var registrationsCount int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM registrations WHERE event_id = ? AND question_version = ?", eventID, version).
+		Scan(&registrationsCount)
+	if err != nil {
+		return 0, err
+	}
+
+	if version == 0 || registrationsCount > 0 {
+		version++
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM event_questions WHERE event_id = ? AND version = ?", eventID, version)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE events SET question_version = ? WHERE id = ?", version, eventID)
+	if err != nil {
+		return 0, err
+	}
+
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+	for i, question := range req.Questions {
+		var options sql.NullString
+		if len(question.Options) > 0 {
+			encoded, err := json.Marshal(question.Options)
+			if err != nil {
+				return 0, err
+			}
+			options = sql.NullString{String: string(encoded), Valid: true}
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO event_questions (event_id, version, position, label, type, options, required, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, eventID, version, i+1, question.Label, question.Type, options, question.Required, createdAt)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	logger.InfoContext(ctx, "Registration questions set", "event_id", eventID, "questions", len(req.Questions), "version", version)
+	return version, nil
+}
+
+// validatedAnswer is an answer that was checked against its question
+type validatedAnswer struct {
+	questionID int64
+	value      interface{}
+}
+
+// validateAnswers checks the answers given for an event against its current
+// questions. It returns the question version answered and the answers to store.
+func (s *QuestionService) validateAnswers(ctx context.Context, eventID int64, answers []models.Answer) (int, []validatedAnswer, error) {
+	version, err := currentQuestionVersion(ctx, database.DB, eventID)
+	if err != nil {
+		return 0, nil, err
+	}
+	questions, err := s.queryQuestions(ctx, "WHERE event_id = ? AND version = ? ORDER BY position", eventID, version)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	given := make(map[int64]interface{}, len(answers))
+	for _, answer := range answers {
+		if _, duplicate := given[answer.QuestionID]; duplicate {
+			return 0, nil, invalidAnswer(fmt.Errorf("question %d was answered more than once", answer.QuestionID))
+		}
+		given[answer.QuestionID] = answer.Value
+	}
+
+	var validated []validatedAnswer
+	for _, question := range questions {
+		value, err := question.NormalizeAnswer(given[question.ID])
+		if err != nil {
+			return 0, nil, invalidAnswer(err)
+		}
+		delete(given, question.ID)
+
+		if value != nil {
+			validated = append(validated, validatedAnswer{questionID: question.ID, value: value})
+		}
+	}
+
+	for questionID := range given {
+		return 0, nil, invalidAnswer(fmt.Errorf("question %d is not asked by this event", questionID))
+	}
+
+	return version, validated, nil
+}
+
+// saveAnswers stores the validated answers of a registration
+func saveAnswers(ctx context.Context, tx *sql.Tx, registrationID int64, answers []validatedAnswer) error {
+	for _, answer := range answers {
+		encoded, err := json.Marshal(answer.value)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO registration_answers (registration_id, question_id, value)
+			VALUES (?, ?, ?)
+		`, registrationID, answer.questionID, string(encoded))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceAnswers stores the answers of a registration in place of the current
+// ones, together with the question version they were given for (0 for none)
+func replaceAnswers(ctx context.Context, tx *sql.Tx, registrationID int64, version int, answers []validatedAnswer) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM registration_answers WHERE registration_id = ?", registrationID); err != nil {
+		return err
+	}
+
+	var nullVersion sql.NullInt64
+	if version > 0 {
+		nullVersion = sql.NullInt64{Int64: int64(version), Valid: true}
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE registrations SET question_version = ? WHERE id = ?", nullVersion, registrationID); err != nil {
+		return err
+	}
+
+	return saveAnswers(ctx, tx, registrationID, answers)
+}
@@ -1,49 +1,55 @@
 package services
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
-	"log"
 	"time"
 
 	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/metrics"
 	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/notifications"
+	"github.com/netpo4ki/event-poster/internal/tracing"
 )
 
 // RegistrationService handles the business logic for registrations
 type RegistrationService struct {
-	eventService *EventService
+	eventService      *EventService
+	ticketTypeService *TicketTypeService
+	questionService   *QuestionService
 }
 
 // NewRegistrationService creates a new RegistrationService
 func NewRegistrationService() *RegistrationService {
 	return &RegistrationService{
-		eventService: NewEventService(),
+		eventService:      NewEventService(),
+		ticketTypeService: NewTicketTypeService(),
+		questionService:   NewQuestionService(),
 	}
 }
 
-// GetAllRegistrations retrieves all registrations from the database
-func (s *RegistrationService) GetAllRegistrations(eventID *int64) ([]models.Registration, error) {
-	var query string
-	var args []interface{}
+// GetAllRegistrations retrieves the registrations a user may see: their own
+// and those for the events they organize
+func (s *RegistrationService) GetAllRegistrations(ctx context.Context, eventID *int64, userID int64) ([]models.Registration, error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.GetAllRegistrations")
+	defer span.End()
+
+	query := `
+		SELECT ` + registrationColumns + `
+		FROM registrations
+		WHERE (user_id = ? OR event_id IN (SELECT id FROM events WHERE creator_id = ?))
+	`
+	args := []interface{}{userID, userID}
 
 	if eventID != nil {
-		query = `
-			SELECT id, event_id, user_id, first_name, last_name, created_at
-			FROM registrations
-			WHERE event_id = ?
-			ORDER BY created_at
-		`
+		query += " AND event_id = ?"
 		args = append(args, *eventID)
-	} else {
-		query = `
-			SELECT id, event_id, user_id, first_name, last_name, created_at
-			FROM registrations
-			ORDER BY created_at
-		`
 	}
+	query += " ORDER BY created_at"
 
-	rows, err := database.DB.Query(query, args...)
+	rows, err := database.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -51,91 +57,218 @@ func (s *RegistrationService) GetAllRegistrations(eventID *int64) ([]models.Regi
 
 	var registrations []models.Registration
 	for rows.Next() {
-		var registration models.Registration
-		var createdAtStr string
-		var userID sql.NullInt64
-
-		if err := rows.Scan(
-			&registration.ID,
-			&registration.EventID,
-			&userID,
-			&registration.FirstName,
-			&registration.LastName,
-			&createdAtStr,
-		); err != nil {
+		registration, err := scanRegistration(rows)
+		if err != nil {
 			return nil, err
 		}
-
-		registration.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
-		if userID.Valid {
-			registration.UserID = userID.Int64
-		}
-		registrations = append(registrations, registration)
+		registrations = append(registrations, *registration)
 	}
 
 	return registrations, nil
 }
 
 // GetRegistrationByID retrieves a single registration by ID
-func (s *RegistrationService) GetRegistrationByID(id int64) (*models.Registration, error) {
-	var registration models.Registration
-	var createdAtStr string
-	var userID sql.NullInt64
+func (s *RegistrationService) GetRegistrationByID(ctx context.Context, id int64) (*models.Registration, error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.GetRegistrationByID")
+	defer span.End()
+
+	defer metrics.QueryTimer("get_registration").ObserveDuration()
 
-	err := database.DB.QueryRow(`
-		SELECT id, event_id, user_id, first_name, last_name, created_at
+	row := database.DB.QueryRowContext(ctx, `
+		SELECT `+registrationColumns+`
 		FROM registrations
 		WHERE id = ?
-	`, id).Scan(
+	`, id)
+
+	return scanRegistration(row)
+}
+
+// activeRegistrationCondition selects registrations that haven't been cancelled or rejected
+const activeRegistrationCondition = "status IN ('pending', 'confirmed', 'approved')"
+
+// seatHoldingCondition selects registrations that take seats: confirmed and
+// approved ones, and pending ones whose event reserved their seats
+const seatHoldingCondition = "(status IN ('confirmed', 'approved') OR (status = 'pending' AND seat_reserved = 1))"
+
+// ticketCondition selects registrations that a ticket is issued for
+const ticketCondition = "status IN ('confirmed', 'approved')"
+
+// registeredHeads sums the seats taken by the selected registrations, guests included
+const registeredHeads = "COALESCE(SUM(1 + guest_count), 0)"
+
+// registrationColumns is the column list understood by scanRegistration
+const registrationColumns = "id, event_id, user_id, ticket_type_id, first_name, last_name, status, status_reason, seat_reserved, " +
+	"guest_count, notes, created_at, checked_in_at"
+
+// scanRegistration reads a registration selected with registrationColumns
+func scanRegistration(row rowScanner) (*models.Registration, error) {
+	var registration models.Registration
+	var createdAtStr string
+	var userID, ticketTypeID sql.NullInt64
+	var notes, statusReason, checkedInAt sql.NullString
+
+	if err := row.Scan(
 		&registration.ID,
 		&registration.EventID,
 		&userID,
+		&ticketTypeID,
 		&registration.FirstName,
 		&registration.LastName,
+		&registration.Status,
+		&statusReason,
+		&registration.SeatReserved,
+		&registration.GuestCount,
+		&notes,
 		&createdAtStr,
-	)
-
-	if err != nil {
+		&checkedInAt,
+	); err != nil {
 		return nil, err
 	}
 
 	registration.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+	registration.CheckedInAt = parseNullTime(checkedInAt)
 	if userID.Valid {
 		registration.UserID = userID.Int64
 	}
+	if ticketTypeID.Valid {
+		registration.TicketTypeID = &ticketTypeID.Int64
+	}
+	registration.Notes = notes.String
+	registration.StatusReason = statusReason.String
 
 	return &registration, nil
 }
 
+// getGuests retrieves the named guests of a registration
+func (s *RegistrationService) getGuests(ctx context.Context, registrationID int64) ([]models.Guest, error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.getGuests")
+	defer span.End()
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT first_name, last_name
+		FROM registration_guests
+		WHERE registration_id = ?
+		ORDER BY id
+	`, registrationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var guests []models.Guest
+	for rows.Next() {
+		var guest models.Guest
+		var lastName sql.NullString
+		if err := rows.Scan(&guest.FirstName, &lastName); err != nil {
+			return nil, err
+		}
+		guest.LastName = lastName.String
+		guests = append(guests, guest)
+	}
+
+	return guests, nil
+}
+
+// replaceGuests stores the named guests of a registration in place of the current ones
+func replaceGuests(ctx context.Context, tx *sql.Tx, registrationID int64, guests []models.Guest) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM registration_guests WHERE registration_id = ?", registrationID); err != nil {
+		return err
+	}
+
+	for _, guest := range guests {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO registration_guests (registration_id, first_name, last_name)
+			VALUES (?, ?, ?)
+		`, registrationID, guest.FirstName, guest.LastName)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetRegistrationWithEventDetails retrieves a registration with event details
-func (s *RegistrationService) GetRegistrationWithEventDetails(id int64) (*models.RegistrationResponse, error) {
-	registration, err := s.GetRegistrationByID(id)
+func (s *RegistrationService) GetRegistrationWithEventDetails(ctx context.Context, id int64) (*models.RegistrationResponse, error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.GetRegistrationWithEventDetails")
+	defer span.End()
+
+	registration, err := s.GetRegistrationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	registration.Guests, err = s.getGuests(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	event, err := s.eventService.GetEventByID(registration.EventID)
+	event, err := s.eventService.GetEventByID(ctx, registration.EventID)
 	if err != nil {
 		return nil, err
 	}
 
 	response := &models.RegistrationResponse{
-		Registration: *registration,
-		EventTitle:   event.Title,
-		EventDate:    event.EventDate,
-		EventType:    event.EventType,
+		Registration:     *registration,
+		EventTitle:       event.Title,
+		EventDescription: event.Description,
+		EventLocation:    event.Location,
+		EventDate:        event.EventDate,
+		EventLocalDate:   event.LocalEventDate(),
+		EventEndDate:     event.EndDate,
+		EventTimezone:    event.Timezone,
+		EventType:        event.EventType,
+		EventStatus:      event.Status,
+	}
+
+	if registration.TicketTypeID != nil {
+		ticketType, err := s.ticketTypeService.GetTicketTypeByID(ctx, *registration.TicketTypeID)
+		if err != nil {
+			return nil, err
+		}
+		response.TicketTypeName = ticketType.Name
 	}
 
 	return response, nil
 }
 
+// GetRegistrationForUser retrieves a registration with event details for its
+// registrant or the organizer of its event. Anyone else gets sql.ErrNoRows, so
+// other people's registrations can't be found by guessing IDs.
+func (s *RegistrationService) GetRegistrationForUser(ctx context.Context, id int64, userID int64) (*models.RegistrationResponse, error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.GetRegistrationForUser")
+	defer span.End()
+
+	registration, err := s.GetRegistrationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if registration.UserID != userID {
+		event, err := s.eventService.GetEventByID(ctx, registration.EventID)
+		if err != nil {
+			return nil, err
+		}
+		if event.CreatorID != userID {
+			return nil, sql.ErrNoRows
+		}
+	}
+
+	return s.GetRegistrationWithEventDetails(ctx, id)
+}
+
 // GetUserRegistrations retrieves all registrations for a user
-func (s *RegistrationService) GetUserRegistrations(userID int64) ([]models.RegistrationResponse, error) {
-	rows, err := database.DB.Query(`
-		SELECT r.id, r.event_id, r.user_id, r.first_name, r.last_name, r.created_at,
-		       e.title, e.event_type, e.event_date, e.description, e.location
+func (s *RegistrationService) GetUserRegistrations(ctx context.Context, userID int64) ([]models.RegistrationResponse, error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.GetUserRegistrations")
+	defer span.End()
+
+	defer metrics.QueryTimer("list_user_registrations").ObserveDuration()
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT r.id, r.event_id, r.user_id, r.ticket_type_id, r.first_name, r.last_name, r.status, r.status_reason, r.seat_reserved,
+		       r.guest_count, r.notes, r.created_at, e.title, e.event_type, e.event_date, e.end_date, e.timezone, e.status, e.description, e.location, t.name
 		FROM registrations r
 		JOIN events e ON r.event_id = e.id
+		LEFT JOIN ticket_types t ON r.ticket_type_id = t.id
 		WHERE r.user_id = ?
 		ORDER BY r.created_at DESC
 	`, userID)
@@ -149,22 +282,32 @@ func (s *RegistrationService) GetUserRegistrations(userID int64) ([]models.Regis
 		var registration models.Registration
 		var response models.RegistrationResponse
 		var createdAtStr string
-		var eventDateStr string
-		var dbUserID sql.NullInt64
-		var description, location sql.NullString
+		var eventDateStr, endDateStr string
+		var dbUserID, ticketTypeID sql.NullInt64
+		var description, location, ticketTypeName, notes, statusReason sql.NullString
 
 		if err := rows.Scan(
 			&registration.ID,
 			&registration.EventID,
 			&dbUserID,
+			&ticketTypeID,
 			&registration.FirstName,
 			&registration.LastName,
+			&registration.Status,
+			&statusReason,
+			&registration.SeatReserved,
+			&registration.GuestCount,
+			&notes,
 			&createdAtStr,
 			&response.EventTitle,
 			&response.EventType,
 			&eventDateStr,
+			&endDateStr,
+			&response.EventTimezone,
+			&response.EventStatus,
 			&description,
 			&location,
+			&ticketTypeName,
 		); err != nil {
 			return nil, err
 		}
@@ -177,8 +320,13 @@ func (s *RegistrationService) GetUserRegistrations(userID int64) ([]models.Regis
 		}
 		registration.CreatedAt = parsedCreatedAt
 
-		// Parse the event date timestamp
+		// Parse the event date timestamp and express it in the event's time zone
 		response.EventDate, _ = time.Parse(time.RFC3339, eventDateStr)
+		response.EventEndDate, _ = time.Parse(time.RFC3339, endDateStr)
+		response.EventLocalDate = response.EventDate
+		if loc, err := time.LoadLocation(response.EventTimezone); err == nil {
+			response.EventLocalDate = response.EventDate.In(loc)
+		}
 
 		if dbUserID.Valid {
 			registration.UserID = dbUserID.Int64
@@ -192,6 +340,13 @@ func (s *RegistrationService) GetUserRegistrations(userID int64) ([]models.Regis
 			response.EventLocation = location.String
 		}
 
+		if ticketTypeID.Valid {
+			registration.TicketTypeID = &ticketTypeID.Int64
+			response.TicketTypeName = ticketTypeName.String
+		}
+		registration.Notes = notes.String
+		registration.StatusReason = statusReason.String
+
 		response.Registration = registration
 		registrations = append(registrations, response)
 	}
@@ -199,13 +354,163 @@ func (s *RegistrationService) GetUserRegistrations(userID int64) ([]models.Regis
 	return registrations, nil
 }
 
-// CheckExistingRegistration checks if a user has already registered for an event
-func (s *RegistrationService) CheckExistingRegistration(eventID, userID int64) (bool, error) {
+// GetAttendees retrieves the registrations for an event together with their
+// guests and answers. Only the creator of the event may list its attendees.
+func (s *RegistrationService) GetAttendees(ctx context.Context, eventID int64, userID int64) ([]models.Attendee, error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.GetAttendees")
+	defer span.End()
+
+	defer metrics.QueryTimer("list_attendees").ObserveDuration()
+
+	event, err := s.eventService.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if the user has permission to see the attendees of this event
+	if event.CreatorID != userID {
+		return nil, forbidden("you don't have permission to view the attendees of this event")
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT r.id, r.event_id, r.user_id, r.ticket_type_id, r.first_name, r.last_name, r.status, r.status_reason, r.seat_reserved,
+		       r.guest_count, r.notes, r.created_at, r.checked_in_at, t.name
+		FROM registrations r
+		LEFT JOIN ticket_types t ON r.ticket_type_id = t.id
+		WHERE r.event_id = ?
+		ORDER BY r.created_at, r.id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attendees := []models.Attendee{}
+	positions := make(map[int64]int)
+	for rows.Next() {
+		var registration models.Registration
+		var createdAtStr string
+		var userID, ticketTypeID sql.NullInt64
+		var notes, ticketTypeName, statusReason, checkedInAt sql.NullString
+
+		if err := rows.Scan(
+			&registration.ID,
+			&registration.EventID,
+			&userID,
+			&ticketTypeID,
+			&registration.FirstName,
+			&registration.LastName,
+			&registration.Status,
+			&statusReason,
+			&registration.SeatReserved,
+			&registration.GuestCount,
+			&notes,
+			&createdAtStr,
+			&checkedInAt,
+			&ticketTypeName,
+		); err != nil {
+			return nil, err
+		}
+
+		registration.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+		registration.CheckedInAt = parseNullTime(checkedInAt)
+		if userID.Valid {
+			registration.UserID = userID.Int64
+		}
+		if ticketTypeID.Valid {
+			registration.TicketTypeID = &ticketTypeID.Int64
+		}
+		registration.Notes = notes.String
+		registration.StatusReason = statusReason.String
+
+		positions[registration.ID] = len(attendees)
+		attendees = append(attendees, models.Attendee{
+			Registration:   registration,
+			TicketTypeName: ticketTypeName.String,
+			Answers:        []models.AttendeeAnswer{},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	guestRows, err := database.DB.QueryContext(ctx, `
+		SELECT g.registration_id, g.first_name, g.last_name
+		FROM registration_guests g
+		JOIN registrations r ON g.registration_id = r.id
+		WHERE r.event_id = ?
+		ORDER BY g.id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer guestRows.Close()
+
+	for guestRows.Next() {
+		var registrationID int64
+		var guest models.Guest
+		var lastName sql.NullString
+		if err := guestRows.Scan(&registrationID, &guest.FirstName, &lastName); err != nil {
+			return nil, err
+		}
+		guest.LastName = lastName.String
+
+		pos, ok := positions[registrationID]
+		if !ok {
+			continue
+		}
+		attendee := &attendees[pos]
+		attendee.Guests = append(attendee.Guests, guest)
+	}
+
+	answerRows, err := database.DB.QueryContext(ctx, `
+		SELECT a.registration_id, q.id, q.version, q.label, a.value
+		FROM registration_answers a
+		JOIN registrations r ON a.registration_id = r.id
+		JOIN event_questions q ON a.question_id = q.id
+		WHERE r.event_id = ?
+		ORDER BY q.version, q.position
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer answerRows.Close()
+
+	for answerRows.Next() {
+		var registrationID int64
+		var answer models.AttendeeAnswer
+		var value string
+		if err := answerRows.Scan(&registrationID, &answer.QuestionID, &answer.Version, &answer.Label, &value); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(value), &answer.Value); err != nil {
+			return nil, err
+		}
+
+		pos, ok := positions[registrationID]
+		if !ok {
+			continue
+		}
+		attendee := &attendees[pos]
+		attendee.Answers = append(attendee.Answers, answer)
+	}
+
+	return attendees, nil
+}
+
+// CheckExistingRegistration checks if a user has already registered for an event.
+// Rejected applications count as well, so they can't simply be sent again.
+func (s *RegistrationService) CheckExistingRegistration(ctx context.Context, eventID, userID int64) (bool, error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.CheckExistingRegistration")
+	defer span.End()
+
+	defer metrics.QueryTimer("check_existing_registration").ObserveDuration()
+
 	var count int
-	err := database.DB.QueryRow(`
-		SELECT COUNT(*) FROM registrations 
-		WHERE event_id = ? AND user_id = ?
-	`, eventID, userID).Scan(&count)
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM registrations
+		WHERE event_id = ? AND user_id = ? AND status <> ?
+	`, eventID, userID, models.RegistrationCancelled).Scan(&count)
 
 	if err != nil {
 		return false, err
@@ -214,124 +519,336 @@ func (s *RegistrationService) CheckExistingRegistration(eventID, userID int64) (
 	return count > 0, nil
 }
 
+// Reasons a registration is refused, as logged and counted in the metrics
+const (
+	refusedInvalid      = "invalid"
+	refusedNotFound     = "event_not_found"
+	refusedNotPublished = "event_not_published"
+	refusedPast         = "past"
+	refusedNotYetOpen   = "not_yet_open"
+	refusedClosed       = "closed"
+	refusedNoShows      = "no_shows"
+	refusedTicketType   = "ticket_type"
+	refusedGuests       = "too_many_guests"
+	refusedAnswers      = "invalid_answers"
+	refusedFull         = "full"
+	refusedDuplicate    = "duplicate"
+)
+
+// refuseRegistration logs and counts a registration turned away for reason
+func refuseRegistration(ctx context.Context, eventID int64, reason string, args ...interface{}) {
+	metrics.RegistrationsRejected.WithLabelValues(reason).Inc()
+	logger.InfoContext(ctx, "Registration refused", append([]interface{}{"event_id", eventID, "reason", reason}, args...)...)
+}
+
+// checkRegistrationOpen ensures an event takes registrations from the user at
+// the given time, refusing them for unpublished and past events, outside the
+// registration window and for users who missed too many events
+func (s *RegistrationService) checkRegistrationOpen(ctx context.Context, event *models.Event, userID *int64, now time.Time) error {
+	ctx, span := tracing.Start(ctx, "RegistrationService.checkRegistrationOpen")
+	defer span.End()
+
+	// Only published events accept registrations
+	if event.Status != models.EventPublished {
+		refuseRegistration(ctx, event.ID, refusedNotPublished, "status", event.Status)
+		return ErrEventNotOpen
+	}
+
+	// Check if the event has ended; registration stays open while it is running
+	if event.HasEnded(now) {
+		refuseRegistration(ctx, event.ID, refusedPast)
+		return ErrEventEnded
+	}
+
+	// Registrations are only taken within the registration window of the event
+	switch event.RegistrationStateAt(now) {
+	case models.RegistrationNotYetOpen:
+		refuseRegistration(ctx, event.ID, refusedNotYetOpen, "opens_at", event.RegistrationOpensAt)
+		return ErrRegistrationNotYetOpen
+	case models.RegistrationClosed:
+		refuseRegistration(ctx, event.ID, refusedClosed)
+		return ErrRegistrationClosed
+	}
+
+	// Events may turn away users who keep registering without showing up
+	if event.MaxNoShows > 0 && userID != nil {
+		noShows, err := s.eventService.CountNoShows(ctx, *userID, now.Add(-models.NoShowLookback))
+		if err != nil {
+			return err
+		}
+		if noShows >= event.MaxNoShows {
+			refuseRegistration(ctx, event.ID, refusedNoShows, "user_id", *userID, "no_shows", noShows, "max_no_shows", event.MaxNoShows)
+			return ErrTooManyNoShows
+		}
+	}
+
+	return nil
+}
+
 // CreateRegistration creates a new registration
-func (s *RegistrationService) CreateRegistration(req *models.RegistrationRequest, userID *int64) (int64, error) {
+func (s *RegistrationService) CreateRegistration(ctx context.Context, req *models.RegistrationRequest, userID *int64) (int64, error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.CreateRegistration")
+	defer span.End()
+
 	if err := req.Validate(); err != nil {
-		log.Printf("CreateRegistration validation error: %v", err)
+		refuseRegistration(ctx, req.EventID, refusedInvalid, "error", err)
 		return 0, err
 	}
 
-	log.Printf("CreateRegistration: Validating event ID %d", req.EventID)
-
 	// Check if the event exists
-	event, err := s.eventService.GetEventByID(req.EventID)
+	event, err := s.eventService.GetEventByID(ctx, req.EventID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			log.Printf("CreateRegistration: Event not found with ID %d", req.EventID)
-			return 0, errors.New("event not found")
+			refuseRegistration(ctx, req.EventID, refusedNotFound)
+			return 0, ErrEventNotFound
 		}
-		log.Printf("CreateRegistration: Error checking event: %v", err)
+		logger.ErrorContext(ctx, "Failed to load event for registration", "event_id", req.EventID, "error", err)
 		return 0, err
 	}
 
-	// Check if there are available seats
-	hasSeats, err := s.eventService.HasAvailableSeats(req.EventID)
-	if err != nil {
-		log.Printf("CreateRegistration: Error checking available seats: %v", err)
+	now := time.Now()
+	if err := s.checkRegistrationOpen(ctx, event, userID, now); err != nil {
 		return 0, err
 	}
 
-	if !hasSeats {
-		log.Printf("CreateRegistration: Event %d is fully booked", req.EventID)
-		return 0, errors.New("event is fully booked")
+	// Events with ticket types need a tier that is on sale
+	if _, err := s.ticketTypeService.resolveTicketType(ctx, event, req.TicketTypeID, now); err != nil {
+		refuseRegistration(ctx, req.EventID, refusedTicketType, "error", err)
+		return 0, err
 	}
 
-	// Check if the event date has passed
-	if event.EventDate.Before(time.Now()) {
-		log.Printf("CreateRegistration: Event %d date has passed", req.EventID)
-		return 0, errors.New("cannot register for a past event")
+	if req.GuestCount > event.MaxGuests {
+		refuseRegistration(ctx, req.EventID, refusedGuests, "guests", req.GuestCount, "max_guests", event.MaxGuests)
+		return 0, ErrTooManyGuests
+	}
+
+	// Answers must fit the questions the event currently asks
+	questionVersion, answers, err := s.questionService.validateAnswers(ctx, req.EventID, req.Answers)
+	if err != nil {
+		refuseRegistration(ctx, req.EventID, refusedAnswers, "error", err)
+		return 0, err
+	}
+	var nullQuestionVersion sql.NullInt64
+	if questionVersion > 0 {
+		nullQuestionVersion = sql.NullInt64{Int64: int64(questionVersion), Valid: true}
 	}
 
+	status, seatReserved := initialStatus(event)
+
 	// Check if the user has already registered for this event
 	if userID != nil {
-		alreadyRegistered, err := s.CheckExistingRegistration(req.EventID, *userID)
+		alreadyRegistered, err := s.CheckExistingRegistration(ctx, req.EventID, *userID)
 		if err != nil {
-			log.Printf("CreateRegistration: Error checking existing registration: %v", err)
+			logger.ErrorContext(ctx, "Failed to check for an existing registration", "event_id", req.EventID, "error", err)
 			return 0, err
 		}
 
 		if alreadyRegistered {
-			log.Printf("CreateRegistration: User %d already registered for event %d", *userID, req.EventID)
-			return 0, errors.New("you have already registered for this event")
+			refuseRegistration(ctx, req.EventID, refusedDuplicate, "user_id", *userID)
+			return 0, ErrAlreadyRegistered
 		}
 	}
 
-	log.Printf("CreateRegistration: Creating registration for %s %s for event %d",
-		req.FirstName, req.LastName, req.EventID)
+	logger.DebugContext(ctx, "Creating registration", "event_id", req.EventID,
+		"first_name", req.FirstName, "last_name", req.LastName)
+
+	// Create the registration together with its guests
+	timer := metrics.QueryTimer("create_registration")
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
 
-	// Create the registration
 	var result sql.Result
-	currentTime := time.Now().Format(time.RFC3339)
+	currentTime := time.Now().UTC().Format(time.RFC3339)
 
 	if userID != nil {
-		result, err = database.DB.Exec(`
-			INSERT INTO registrations (event_id, user_id, first_name, last_name, created_at)
-			VALUES (?, ?, ?, ?, ?)
-		`, req.EventID, userID, req.FirstName, req.LastName, currentTime)
+		result, err = tx.ExecContext(ctx, `
+			INSERT INTO registrations (event_id, user_id, ticket_type_id, first_name, last_name, status, seat_reserved, guest_count, notes,
+				question_version, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, req.EventID, userID, req.TicketTypeID, req.FirstName, req.LastName, status, seatReserved, req.GuestCount, req.Notes,
+			nullQuestionVersion, currentTime)
 	} else {
-		result, err = database.DB.Exec(`
-			INSERT INTO registrations (event_id, first_name, last_name, created_at)
-			VALUES (?, ?, ?, ?)
-		`, req.EventID, req.FirstName, req.LastName, currentTime)
+		result, err = tx.ExecContext(ctx, `
+			INSERT INTO registrations (event_id, ticket_type_id, first_name, last_name, status, seat_reserved, guest_count, notes,
+				question_version, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, req.EventID, req.TicketTypeID, req.FirstName, req.LastName, status, seatReserved, req.GuestCount, req.Notes,
+			nullQuestionVersion, currentTime)
 	}
 
 	if err != nil {
-		log.Printf("CreateRegistration database error: %v", err)
+		logger.ErrorContext(ctx, "Failed to insert registration", "event_id", req.EventID, "error", err)
 		return 0, err
 	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
-		log.Printf("CreateRegistration LastInsertId error: %v", err)
+		logger.ErrorContext(ctx, "Failed to get ID of new registration", "event_id", req.EventID, "error", err)
+		return 0, err
+	}
+
+	// Check if there are available seats for the whole party. Checked after the
+	// insert, within its transaction, so concurrent registrations can't take the same seats.
+	if seatReserved {
+		if err := checkPartyFits(ctx, tx, id, req.EventID, req.TicketTypeID, 1+req.GuestCount); err != nil {
+			refuseRegistration(ctx, req.EventID, refusedFull, "error", err)
+			return 0, err
+		}
+	}
+
+	if err := replaceGuests(ctx, tx, id, req.Guests); err != nil {
+		logger.ErrorContext(ctx, "Failed to save guests of new registration", "registration_id", id, "error", err)
 		return 0, err
 	}
+	if err := saveAnswers(ctx, tx, id, answers); err != nil {
+		logger.ErrorContext(ctx, "Failed to save answers of new registration", "registration_id", id, "error", err)
+		return 0, err
+	}
+	if err := emitRegistrationWebhook(ctx, tx, models.TopicRegistrationCreated, id); err != nil {
+		logger.ErrorContext(ctx, "Failed to queue webhook for new registration", "registration_id", id, "error", err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	timer.ObserveDuration()
+
+	logger.InfoContext(ctx, "Registration created", "registration_id", id, "event_id", req.EventID, "status", status)
+	metrics.RegistrationsCreated.WithLabelValues(string(status)).Inc()
+	publishAvailability(ctx, req.EventID)
+	publishRegistration(ctx, id, models.DashboardRegistrationCreated)
+
+	registration := req.ToRegistration()
+	registration.ID = id
+	registration.Status = status
+	if userID != nil {
+		registration.UserID = *userID
+	}
+	notifyRegistrant(ctx, registration, status, "")
 
-	log.Printf("CreateRegistration: Successfully created registration with ID %d", id)
 	return id, nil
 }
 
-// UpdateRegistration updates an existing registration
-func (s *RegistrationService) UpdateRegistration(id int64, req *models.RegistrationRequest, userID int64) error {
+// initialStatus returns the status a new registration for the event starts with.
+// Events that require approval take applications, which only hold seats while
+// pending when the event reserves them.
+func initialStatus(event *models.Event) (models.RegistrationStatus, bool) {
+	if event.RequiresApproval {
+		return models.RegistrationPending, event.ReservePendingSeats
+	}
+	return models.RegistrationConfirmed, true
+}
+
+// UpdateRegistration updates an existing registration. Moving it to another
+// event registers it there anew: it gets the status a new registration would
+// and has to be approved again if the event requires it.
+
+func (s *RegistrationService) UpdateRegistration(ctx context.Context, id int64, req *models.RegistrationRequest, userID int64) error {
+	ctx, span := tracing.Start(ctx, "RegistrationService.UpdateRegistration")
+	defer span.End()
+
 	if err := req.Validate(); err != nil {
 		return err
 	}
 
 	// Check if the registration exists
-	registration, err := s.GetRegistrationByID(id)
+	registration, err := s.GetRegistrationByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
 	// Check if the user has permission to update this registration
 	if registration.UserID != userID {
-		return errors.New("you don't have permission to update this registration")
+		return forbidden("you don't have permission to update this registration")
+	}
+	if registration.Status == models.RegistrationCancelled || registration.Status == models.RegistrationRejected {
+		return errors.New("cannot change a cancelled or rejected registration")
 	}
 
 	// Check if the event exists
-	_, err = s.eventService.GetEventByID(req.EventID)
+	event, err := s.eventService.GetEventByID(ctx, req.EventID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return errors.New("event not found")
+			return ErrEventNotFound
 		}
 		return err
 	}
 
-	// Update the registration
-	result, err := database.DB.Exec(`
+	// Keep the current ticket type unless another one or another event is picked
+	if req.TicketTypeID == nil && req.EventID == registration.EventID {
+		req.TicketTypeID = registration.TicketTypeID
+	}
+	movesEvent := req.EventID != registration.EventID
+	moves := movesEvent || !sameID(req.TicketTypeID, registration.TicketTypeID)
+
+	// Moving to another event is registering for it, so the same rules apply
+	now := time.Now()
+	moved := *registration
+	if movesEvent {
+		// Attendance belongs to the event the registration was checked in at
+		if registration.CheckedInAt != nil {
+			return ErrRegistrationCheckedIn
+		}
+
+		// Leaving an event cancels the registration there, so its deadline applies
+		currentEvent, err := s.eventService.GetEventByID(ctx, registration.EventID)
+		if err != nil {
+			return err
+		}
+		if !currentEvent.CanCancelAt(now) {
+			return ErrCancellationDeadlinePassed
+		}
+		moved.Status, moved.SeatReserved = initialStatus(event)
+
+		if err := s.checkRegistrationOpen(ctx, event, &userID, now); err != nil {
+			return err
+		}
+		if registration.GuestCount > event.MaxGuests {
+			return ErrTooManyGuests
+		}
+		alreadyRegistered, err := s.CheckExistingRegistration(ctx, req.EventID, userID)
+		if err != nil {
+			return err
+		}
+		if alreadyRegistered {
+			return ErrAlreadyRegistered
+		}
+	}
+	if moves {
+		if _, err := s.ticketTypeService.resolveTicketType(ctx, event, req.TicketTypeID, now); err != nil {
+			return err
+		}
+	}
+
+	// Answers belong to the questions of one event, so a registration moving
+	// to another event answers the questions of that event instead
+	var questionVersion int
+	var answers []validatedAnswer
+	if movesEvent {
+		questionVersion, answers, err = s.questionService.validateAnswers(ctx, req.EventID, req.Answers)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Update the registration, guarding against a concurrent status change or
+	// check-in of a registration that moves to another event
+	result, err := tx.ExecContext(ctx, `
 		UPDATE registrations
-		SET event_id = ?, first_name = ?, last_name = ?
-		WHERE id = ?
-	`, req.EventID, req.FirstName, req.LastName, id)
+		SET event_id = ?, ticket_type_id = ?, first_name = ?, last_name = ?, notes = ?, status = ?, seat_reserved = ?
+		WHERE id = ? AND status = ? AND (event_id = ? OR checked_in_at IS NULL)
+	`, req.EventID, req.TicketTypeID, req.FirstName, req.LastName, req.Notes, moved.Status, moved.SeatReserved,
+		id, registration.Status, req.EventID)
 
 	if err != nil {
 		return err
@@ -343,39 +860,309 @@ func (s *RegistrationService) UpdateRegistration(id int64, req *models.Registrat
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("registration not found")
+		return ErrInvalidRegistrationTransition
 	}
 
+	if movesEvent {
+		if err := replaceAnswers(ctx, tx, id, questionVersion, answers); err != nil {
+			return err
+		}
+	}
+
+	// The whole party must fit where it moved to, checked within the move so
+	// a concurrent registration can't take the same seats
+	if moves && moved.HoldsSeat() {
+		if err := checkPartyFits(ctx, tx, id, req.EventID, req.TicketTypeID, registration.Heads()); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if moved.Status != registration.Status {
+		logger.InfoContext(ctx, "Registration status changed", "registration_id", id, "event_id", req.EventID,
+			"old_status", registration.Status, "status", moved.Status)
+		moved.EventID, moved.FirstName, moved.LastName = req.EventID, req.FirstName, req.LastName
+		notifyRegistrant(ctx, &moved, moved.Status, "")
+	}
+
+	// The seat moves along when the registration moves to another event or ticket type
+	if req.EventID != registration.EventID {
+		publishAvailability(ctx, registration.EventID, req.EventID)
+	} else if !sameID(req.TicketTypeID, registration.TicketTypeID) {
+		publishAvailability(ctx, req.EventID)
+	}
 	return nil
 }
 
-// DeleteRegistration deletes a registration by ID
-func (s *RegistrationService) DeleteRegistration(id int64, userID int64) error {
+// UpdateGuests changes the guests a registration brings along. The owner keeps
+// their seat; only the difference in guests has to fit into the event.
+func (s *RegistrationService) UpdateGuests(ctx context.Context, id int64, req *models.GuestsRequest, userID int64) error {
+	ctx, span := tracing.Start(ctx, "RegistrationService.UpdateGuests")
+	defer span.End()
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	registration, err := s.GetRegistrationByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if the user has permission to update this registration
+	if registration.UserID != userID {
+		return forbidden("you don't have permission to update this registration")
+	}
+	if registration.Status == models.RegistrationCancelled || registration.Status == models.RegistrationRejected {
+		return errors.New("cannot change the guests of a cancelled or rejected registration")
+	}
+
+	event, err := s.eventService.GetEventByID(ctx, registration.EventID)
+	if err != nil {
+		return err
+	}
+	if event.HasEnded(time.Now()) {
+		return errors.New("cannot change the guests of a past event")
+	}
+	if req.GuestCount > event.MaxGuests {
+		return ErrTooManyGuests
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE registrations SET guest_count = ? WHERE id = ?", req.GuestCount, id)
+	if err != nil {
+		return err
+	}
+
+	// Only additional guests of a registration that holds seats need free ones,
+	// checked within the update so a concurrent registration can't take them
+	if req.GuestCount > registration.GuestCount && registration.HoldsSeat() {
+		if err := checkPartyFits(ctx, tx, id, event.ID, registration.TicketTypeID, 1+req.GuestCount); err != nil {
+			return err
+		}
+	}
+	if err := replaceGuests(ctx, tx, id, req.Guests); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if req.GuestCount != registration.GuestCount {
+		publishAvailability(ctx, registration.EventID)
+	}
+	return nil
+}
+
+// DeleteRegistration cancels a registration by ID. The registration is kept
+// with the cancelled status so organizers can still see it.
+func (s *RegistrationService) DeleteRegistration(ctx context.Context, id int64, userID int64) error {
+	ctx, span := tracing.Start(ctx, "RegistrationService.DeleteRegistration")
+	defer span.End()
+
 	// Check if the registration exists
-	registration, err := s.GetRegistrationByID(id)
+	registration, err := s.GetRegistrationByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
 	// Check if the user has permission to delete this registration
 	if registration.UserID != userID {
-		return errors.New("you don't have permission to delete this registration")
+		return forbidden("you don't have permission to delete this registration")
 	}
 
-	// Delete the registration
-	result, err := database.DB.Exec("DELETE FROM registrations WHERE id = ?", id)
+	// Attendees can't drop out on their own after the cancellation deadline
+	event, err := s.eventService.GetEventByID(ctx, registration.EventID)
 	if err != nil {
 		return err
 	}
+	if !event.CanCancelAt(time.Now()) {
+		return ErrCancellationDeadlinePassed
+	}
 
-	rowsAffected, err := result.RowsAffected()
+	return s.changeStatus(ctx, registration, models.RegistrationCancelled, "")
+}
+
+// ApproveRegistration accepts a pending registration. Unless the event reserved
+// its seats while pending, the party must still fit into the event.
+func (s *RegistrationService) ApproveRegistration(ctx context.Context, id int64, req *models.RegistrationDecisionRequest, userID int64) error {
+	ctx, span := tracing.Start(ctx, "RegistrationService.ApproveRegistration")
+	defer span.End()
+
+	registration, err := s.getRegistrationForOrganizer(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if !registration.Status.CanTransitionTo(models.RegistrationApproved) {
+		return ErrInvalidRegistrationTransition
+	}
+
+	return s.changeStatus(ctx, registration, models.RegistrationApproved, req.Reason)
+}
+
+// RejectRegistration turns down a pending registration, freeing any seat it reserved
+func (s *RegistrationService) RejectRegistration(ctx context.Context, id int64, req *models.RegistrationDecisionRequest, userID int64) error {
+	ctx, span := tracing.Start(ctx, "RegistrationService.RejectRegistration")
+	defer span.End()
+
+	registration, err := s.getRegistrationForOrganizer(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.changeStatus(ctx, registration, models.RegistrationRejected, req.Reason)
+}
+
+// getRegistrationForOrganizer retrieves a registration that the user organizes the event of
+func (s *RegistrationService) getRegistrationForOrganizer(ctx context.Context, id int64, userID int64) (*models.Registration, error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.getRegistrationForOrganizer")
+	defer span.End()
+
+	registration, err := s.GetRegistrationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := s.eventService.GetEventByID(ctx, registration.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if the user has permission to decide on registrations for this event
+	if event.CreatorID != userID {
+		return nil, forbidden("you don't have permission to manage registrations for this event")
+	}
+
+	return registration, nil
+}
+
+// changeStatus moves a registration to another status and lets its owner know
+func (s *RegistrationService) changeStatus(ctx context.Context, registration *models.Registration, status models.RegistrationStatus, reason string) error {
+	ctx, span := tracing.Start(ctx, "RegistrationService.changeStatus")
+	defer span.End()
+
+	if !registration.Status.CanTransitionTo(status) {
+		return ErrInvalidRegistrationTransition
+	}
+
+	timer := metrics.QueryTimer("change_registration_status")
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Guard against a concurrent change by only moving from the status that was read
+	result, err := tx.ExecContext(ctx, `
+		UPDATE registrations SET status = ?, status_reason = ?
+		WHERE id = ? AND status = ?
+	`, status, reason, registration.ID, registration.Status)
 	if err != nil {
 		return err
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
 	if rowsAffected == 0 {
-		return errors.New("registration not found")
+		return ErrInvalidRegistrationTransition
 	}
 
+	// A registration that only takes its seat now, e.g. when approved without
+	// a reserved seat, must still fit. Checked after the update so concurrent
+	// changes can't hand out the same seats.
+	changed := *registration
+	changed.Status = status
+	promoted := changed.HoldsSeat() && !registration.HoldsSeat()
+	if promoted {
+		if err := checkPartyFits(ctx, tx, registration.ID, registration.EventID, registration.TicketTypeID, registration.Heads()); err != nil {
+			return err
+		}
+	}
+
+	if status == models.RegistrationCancelled {
+		if err := emitRegistrationWebhook(ctx, tx, models.TopicRegistrationCancelled, registration.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	timer.ObserveDuration()
+
+	logger.InfoContext(ctx, "Registration status changed", "registration_id", registration.ID, "event_id", registration.EventID,
+		"old_status", registration.Status, "status", status)
+	publishAvailability(ctx, registration.EventID)
+	if status == models.RegistrationCancelled {
+		publishRegistration(ctx, registration.ID, models.DashboardRegistrationCancelled)
+	}
+	// Approving a waitlisted registration is news of a seat, not just of the decision
+	if promoted && status == models.RegistrationApproved {
+		sendRegistrantNotification(ctx, registration, notifications.KindWaitlistPromoted, reason)
+	} else {
+		notifyRegistrant(ctx, registration, status, reason)
+	}
+	return nil
+}
+
+// checkPartyFits ensures the party of a registration fits into the event and,
+// when picked, its ticket type. It runs in the transaction that just wrote the
+// registration, which keeps concurrent writers out until it commits, and leaves
+// the registration itself out of the seats already taken.
+func checkPartyFits(ctx context.Context, tx *sql.Tx, registrationID, eventID int64, ticketTypeID *int64, heads int) error {
+	if ticketTypeID != nil {
+		var capacity, taken int
+		err := tx.QueryRowContext(ctx, `
+			SELECT capacity,
+				(SELECT `+registeredHeads+` FROM registrations WHERE ticket_type_id = ticket_types.id AND id <> ? AND `+seatHoldingCondition+`)
+			FROM ticket_types
+			WHERE id = ? AND event_id = ?
+		`, registrationID, *ticketTypeID, eventID).Scan(&capacity, &taken)
+		if err != nil {
+			return err
+		}
+		if left := capacity - taken; left < heads {
+			if left > 0 {
+				return ErrNotEnoughSeats
+			}
+			return ErrTicketTypeSoldOut
+		}
+	}
+
+	var seats, taken int
+	err := tx.QueryRowContext(ctx, `
+		SELECT seats,
+			(SELECT `+registeredHeads+` FROM registrations WHERE event_id = events.id AND id <> ? AND `+seatHoldingCondition+`)
+		FROM events
+		WHERE id = ?
+	`, registrationID, eventID).Scan(&seats, &taken)
+	if err != nil {
+		return err
+	}
+	if left := seats - taken; left < heads {
+		if left > 0 {
+			return ErrNotEnoughSeats
+		}
+		return ErrEventFullyBooked
+	}
 	return nil
 }
+
+// sameID reports whether two optional IDs, e.g. of ticket types, are equal
+func sameID(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
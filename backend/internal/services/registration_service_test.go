@@ -0,0 +1,478 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/notifications"
+)
+
+func TestGetRegistrationForUser(t *testing.T) {
+	s := NewRegistrationService()
+	ctx := context.Background()
+
+	organizer := createTestUser(t)
+	attendee := createTestUser(t)
+	stranger := createTestUser(t)
+	registrationID := register(t, s, createTestEvent(t, organizer, nil), attendee)
+
+	for _, userID := range []int64{attendee, organizer} {
+		registration, err := s.GetRegistrationForUser(ctx, registrationID, userID)
+		if err != nil {
+			t.Fatalf("GetRegistrationForUser as user %d failed: %v", userID, err)
+		}
+		if registration.ID != registrationID {
+			t.Errorf("GetRegistrationForUser as user %d returned registration %d, want %d", userID, registration.ID, registrationID)
+		}
+	}
+
+	if _, err := s.GetRegistrationForUser(ctx, registrationID, stranger); err != sql.ErrNoRows {
+		t.Errorf("GetRegistrationForUser as another user: err = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestCreateRegistrationFull(t *testing.T) {
+	s := NewRegistrationService()
+	ctx := context.Background()
+
+	organizer := createTestUser(t)
+	eventID := createTestEvent(t, organizer, func(event *models.Event) {
+		event.Seats = 3
+		event.MaxGuests = 2
+	})
+	register(t, s, eventID, createTestUser(t))
+
+	// Two seats are left, not enough for a party of three
+	userID := createTestUser(t)
+	req := &models.RegistrationRequest{EventID: eventID, FirstName: "Grace", GuestsRequest: models.GuestsRequest{GuestCount: 2}}
+	if _, err := s.CreateRegistration(ctx, req, &userID); err != ErrNotEnoughSeats {
+		t.Errorf("registering a party of three: err = %v, want ErrNotEnoughSeats", err)
+	}
+
+	register(t, s, eventID, createTestUser(t))
+	register(t, s, eventID, createTestUser(t))
+	if _, err := s.CreateRegistration(ctx, &models.RegistrationRequest{EventID: eventID, FirstName: "Grace"}, &userID); err == nil {
+		t.Error("registering for a fully booked event succeeded")
+	}
+	if taken := seatsTaken(t, eventID); taken != 3 {
+		t.Errorf("seats taken = %d, want 3", taken)
+	}
+}
+
+func TestCreateRegistrationTicketTypeSoldOut(t *testing.T) {
+	s := NewRegistrationService()
+	ctx := context.Background()
+
+	eventID := createTestEvent(t, createTestUser(t), nil)
+	ticketTypeID := createTestTicketType(t, eventID, 1)
+
+	for i, want := range []error{nil, ErrTicketTypeSoldOut} {
+		userID := createTestUser(t)
+		req := &models.RegistrationRequest{EventID: eventID, TicketTypeID: &ticketTypeID, FirstName: "Grace"}
+		if _, err := s.CreateRegistration(ctx, req, &userID); err != want {
+			t.Errorf("registration %d: err = %v, want %v", i+1, err, want)
+		}
+	}
+}
+
+func TestCreateRegistrationConcurrent(t *testing.T) {
+	s := NewRegistrationService()
+
+	eventID := createTestEvent(t, createTestUser(t), func(event *models.Event) {
+		event.Seats = 2
+	})
+	userIDs := make([]int64, 8)
+	for i := range userIDs {
+		userIDs[i] = createTestUser(t)
+	}
+
+	// Registrations racing for the last seats may fail for either reason, but
+	// never take more seats than the event has
+	var wg sync.WaitGroup
+	for _, userID := range userIDs {
+		wg.Add(1)
+		go func(userID int64) {
+			defer wg.Done()
+			s.CreateRegistration(context.Background(), &models.RegistrationRequest{EventID: eventID, FirstName: "Grace"}, &userID)
+		}(userID)
+	}
+	wg.Wait()
+
+	if taken := seatsTaken(t, eventID); taken > 2 {
+		t.Errorf("seats taken = %d, want at most 2", taken)
+	}
+}
+
+func TestUpdateGuests(t *testing.T) {
+	s := NewRegistrationService()
+	ctx := context.Background()
+
+	eventID := createTestEvent(t, createTestUser(t), func(event *models.Event) {
+		event.Seats = 4
+		event.MaxGuests = 3
+	})
+	userID := createTestUser(t)
+	registrationID := register(t, s, eventID, userID)
+	register(t, s, eventID, createTestUser(t))
+
+	// The owner keeps their seat, so two more guests fit but three don't
+	if err := s.UpdateGuests(ctx, registrationID, &models.GuestsRequest{GuestCount: 3}, userID); err != ErrNotEnoughSeats {
+		t.Errorf("adding three guests: err = %v, want ErrNotEnoughSeats", err)
+	}
+	if err := s.UpdateGuests(ctx, registrationID, &models.GuestsRequest{GuestCount: 2}, userID); err != nil {
+		t.Errorf("adding two guests failed: %v", err)
+	}
+	if taken := seatsTaken(t, eventID); taken != 4 {
+		t.Errorf("seats taken = %d, want 4", taken)
+	}
+
+	// Fewer guests always fit
+	if err := s.UpdateGuests(ctx, registrationID, &models.GuestsRequest{GuestCount: 1}, userID); err != nil {
+		t.Errorf("removing a guest failed: %v", err)
+	}
+	if taken := seatsTaken(t, eventID); taken != 3 {
+		t.Errorf("seats taken = %d, want 3", taken)
+	}
+}
+
+func TestUpdateGuestsConcurrent(t *testing.T) {
+	s := NewRegistrationService()
+
+	eventID := createTestEvent(t, createTestUser(t), func(event *models.Event) {
+		event.Seats = 10
+		event.MaxGuests = 4
+	})
+	type party struct{ registrationID, userID int64 }
+	parties := make([]party, 5)
+	for i := range parties {
+		parties[i].userID = createTestUser(t)
+		parties[i].registrationID = register(t, s, eventID, parties[i].userID)
+	}
+
+	// Each party asking for four guests would take 25 seats together
+	var wg sync.WaitGroup
+	for _, p := range parties {
+		wg.Add(1)
+		go func(p party) {
+			defer wg.Done()
+			s.UpdateGuests(context.Background(), p.registrationID, &models.GuestsRequest{GuestCount: 4}, p.userID)
+		}(p)
+	}
+	wg.Wait()
+
+	if taken := seatsTaken(t, eventID); taken > 10 {
+		t.Errorf("seats taken = %d, want at most 10", taken)
+	}
+}
+
+func TestApproveRegistration(t *testing.T) {
+	s := NewRegistrationService()
+	ctx := context.Background()
+
+	organizer := createTestUser(t)
+	eventID := createTestEvent(t, organizer, func(event *models.Event) {
+		event.Seats = 1
+		event.RequiresApproval = true
+	})
+
+	// Pending registrations don't hold seats, so both apply but only one fits
+	first := register(t, s, eventID, createTestUser(t))
+	second := register(t, s, eventID, createTestUser(t))
+	if taken := seatsTaken(t, eventID); taken != 0 {
+		t.Errorf("seats taken by pending registrations = %d, want 0", taken)
+	}
+
+	decision := &models.RegistrationDecisionRequest{}
+	if err := s.ApproveRegistration(ctx, first, decision, organizer); err != nil {
+		t.Fatalf("approving the first registration failed: %v", err)
+	}
+	if err := s.ApproveRegistration(ctx, second, decision, organizer); err == nil {
+		t.Error("approving a registration for a fully booked event succeeded")
+	}
+
+	registration, err := s.GetRegistrationByID(ctx, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registration.Status != models.RegistrationPending {
+		t.Errorf("status of the refused approval = %s, want it still pending", registration.Status)
+	}
+	if taken := seatsTaken(t, eventID); taken != 1 {
+		t.Errorf("seats taken = %d, want 1", taken)
+	}
+}
+
+func TestApproveRegistrationReservedSeat(t *testing.T) {
+	s := NewRegistrationService()
+	ctx := context.Background()
+
+	organizer := createTestUser(t)
+	eventID := createTestEvent(t, organizer, func(event *models.Event) {
+		event.Seats = 1
+		event.RequiresApproval = true
+		event.ReservePendingSeats = true
+	})
+
+	// The pending registration holds the only seat, which it keeps when approved
+	registrationID := register(t, s, eventID, createTestUser(t))
+	userID := createTestUser(t)
+	if _, err := s.CreateRegistration(ctx, &models.RegistrationRequest{EventID: eventID, FirstName: "Grace"}, &userID); err == nil {
+		t.Error("registering while a pending registration holds the last seat succeeded")
+	}
+	if err := s.ApproveRegistration(ctx, registrationID, &models.RegistrationDecisionRequest{}, organizer); err != nil {
+		t.Errorf("approving a registration with a reserved seat failed: %v", err)
+	}
+	if taken := seatsTaken(t, eventID); taken != 1 {
+		t.Errorf("seats taken = %d, want 1", taken)
+	}
+}
+
+func TestApproveRegistrationNotification(t *testing.T) {
+	s := NewRegistrationService()
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name    string
+		reserve bool
+		want    notifications.Kind
+	}{
+		{"waitlisted", false, notifications.KindWaitlistPromoted},
+		{"reserved seat", true, notifications.KindRegistrationApproved},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			organizer := createTestUser(t)
+			eventID := createTestEvent(t, organizer, func(event *models.Event) {
+				event.RequiresApproval = true
+				event.ReservePendingSeats = tc.reserve
+			})
+			registrationID := register(t, s, eventID, createTestUser(t))
+			if err := s.ApproveRegistration(ctx, registrationID, &models.RegistrationDecisionRequest{}, organizer); err != nil {
+				t.Fatalf("approving the registration failed: %v", err)
+			}
+
+			var kind notifications.Kind
+			err := database.DB.QueryRow(
+				"SELECT kind FROM notifications WHERE registration_id = ? ORDER BY id DESC LIMIT 1", registrationID,
+			).Scan(&kind)
+			if err != nil {
+				t.Fatalf("looking up the notification: %v", err)
+			}
+			if kind != tc.want {
+				t.Errorf("notification kind = %s, want %s", kind, tc.want)
+			}
+		})
+	}
+}
+
+func TestApproveRegistrationConcurrent(t *testing.T) {
+	s := NewRegistrationService()
+
+	organizer := createTestUser(t)
+	eventID := createTestEvent(t, organizer, func(event *models.Event) {
+		event.Seats = 1
+		event.RequiresApproval = true
+	})
+	registrationIDs := make([]int64, 5)
+	for i := range registrationIDs {
+		registrationIDs[i] = register(t, s, eventID, createTestUser(t))
+	}
+
+	var wg sync.WaitGroup
+	for _, id := range registrationIDs {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			s.ApproveRegistration(context.Background(), id, &models.RegistrationDecisionRequest{}, organizer)
+		}(id)
+	}
+	wg.Wait()
+
+	if taken := seatsTaken(t, eventID); taken > 1 {
+		t.Errorf("seats taken = %d, want at most 1", taken)
+	}
+}
+
+// move moves a registration to another event like its owner would
+func move(s *RegistrationService, registrationID, eventID, userID int64) error {
+	return s.UpdateRegistration(context.Background(), registrationID, &models.RegistrationRequest{
+		EventID:   eventID,
+		FirstName: "Ada",
+		LastName:  "Lovelace",
+	}, userID)
+}
+
+func TestUpdateRegistrationMoveStatus(t *testing.T) {
+	s := NewRegistrationService()
+	ctx := context.Background()
+
+	organizer := createTestUser(t)
+	open := createTestEvent(t, organizer, nil)
+	approval := createTestEvent(t, organizer, func(event *models.Event) {
+		event.RequiresApproval = true
+	})
+	reserving := createTestEvent(t, organizer, func(event *models.Event) {
+		event.RequiresApproval = true
+		event.ReservePendingSeats = true
+	})
+
+	userID := createTestUser(t)
+	registrationID := register(t, s, open, userID)
+
+	tests := []struct {
+		eventID      int64
+		status       models.RegistrationStatus
+		seatReserved bool
+		seatsTaken   int
+	}{
+		// A confirmed registration has to be approved at an event that requires it
+		{approval, models.RegistrationPending, false, 0},
+		{reserving, models.RegistrationPending, true, 1},
+		// A pending one is confirmed at an event that doesn't
+		{open, models.RegistrationConfirmed, true, 1},
+	}
+
+	for _, tt := range tests {
+		if err := move(s, registrationID, tt.eventID, userID); err != nil {
+			t.Fatalf("moving to event %d failed: %v", tt.eventID, err)
+		}
+		registration, err := s.GetRegistrationByID(ctx, registrationID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if registration.Status != tt.status || registration.SeatReserved != tt.seatReserved {
+			t.Errorf("after moving to event %d: status = %s, seat reserved = %v, want %s, %v",
+				tt.eventID, registration.Status, registration.SeatReserved, tt.status, tt.seatReserved)
+		}
+		if taken := seatsTaken(t, tt.eventID); taken != tt.seatsTaken {
+			t.Errorf("seats taken at event %d = %d, want %d", tt.eventID, taken, tt.seatsTaken)
+		}
+	}
+}
+
+func TestUpdateRegistrationMoveFull(t *testing.T) {
+	s := NewRegistrationService()
+	ctx := context.Background()
+
+	organizer := createTestUser(t)
+	from := createTestEvent(t, organizer, nil)
+	full := createTestEvent(t, organizer, func(event *models.Event) {
+		event.Seats = 1
+	})
+	register(t, s, full, createTestUser(t))
+
+	userID := createTestUser(t)
+	registrationID := register(t, s, from, userID)
+	if err := move(s, registrationID, full, userID); err == nil {
+		t.Error("moving to a fully booked event succeeded")
+	}
+
+	registration, err := s.GetRegistrationByID(ctx, registrationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registration.EventID != from {
+		t.Errorf("registration moved to event %d although it was full", registration.EventID)
+	}
+	if taken := seatsTaken(t, full); taken != 1 {
+		t.Errorf("seats taken at the full event = %d, want 1", taken)
+	}
+
+	// Pending registrations that don't reserve seats move into full events
+	approval := createTestEvent(t, organizer, func(event *models.Event) {
+		event.Seats = 1
+		event.RequiresApproval = true
+	})
+	if err := s.ApproveRegistration(ctx, register(t, s, approval, createTestUser(t)), &models.RegistrationDecisionRequest{}, organizer); err != nil {
+		t.Fatal(err)
+	}
+	if err := move(s, registrationID, approval, userID); err != nil {
+		t.Errorf("moving to a full event that requires approval failed: %v", err)
+	}
+}
+
+func TestUpdateRegistrationMoveCheckedIn(t *testing.T) {
+	s := NewRegistrationService()
+	ctx := context.Background()
+
+	organizer := createTestUser(t)
+	from := createTestEvent(t, organizer, nil)
+	to := createTestEvent(t, organizer, nil)
+	userID := createTestUser(t)
+	registrationID := register(t, s, from, userID)
+
+	_, err := database.DB.Exec("UPDATE registrations SET checked_in_at = ?, checked_in_by = ? WHERE id = ?",
+		time.Now().UTC().Format(time.RFC3339), organizer, registrationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := move(s, registrationID, to, userID); err != ErrRegistrationCheckedIn {
+		t.Errorf("moving a checked in registration: err = %v, want ErrRegistrationCheckedIn", err)
+	}
+
+	// Its details can still be changed
+	req := &models.RegistrationRequest{EventID: from, FirstName: "Augusta", LastName: "King"}
+	if err := s.UpdateRegistration(ctx, registrationID, req, userID); err != nil {
+		t.Errorf("renaming a checked in registration failed: %v", err)
+	}
+}
+
+func TestRegistrationPermissions(t *testing.T) {
+	s := NewRegistrationService()
+	ctx := context.Background()
+
+	organizer := createTestUser(t)
+	eventID := createTestEvent(t, organizer, func(event *models.Event) {
+		event.RequiresApproval = true
+	})
+	attendee := createTestUser(t)
+	stranger := createTestUser(t)
+	registrationID := register(t, s, eventID, attendee)
+
+	errs := map[string]error{
+		"update":  move(s, registrationID, eventID, stranger),
+		"guests":  s.UpdateGuests(ctx, registrationID, &models.GuestsRequest{}, stranger),
+		"cancel":  s.DeleteRegistration(ctx, registrationID, stranger),
+		"approve": s.ApproveRegistration(ctx, registrationID, &models.RegistrationDecisionRequest{}, attendee),
+	}
+	for action, err := range errs {
+		var serviceErr *Error
+		if !errors.As(err, &serviceErr) || serviceErr.Code != CodeForbidden {
+			t.Errorf("%s by someone else: err = %v, want a %s error", action, err, CodeForbidden)
+		}
+	}
+}
+
+func TestUpdateRegistrationMoveCancellationDeadline(t *testing.T) {
+	s := NewRegistrationService()
+	ctx := context.Background()
+
+	organizer := createTestUser(t)
+	deadline := time.Now().Add(-time.Minute)
+	from := createTestEvent(t, organizer, func(event *models.Event) {
+		event.CancellationDeadline = &deadline
+	})
+	to := createTestEvent(t, organizer, nil)
+	userID := createTestUser(t)
+	registrationID := register(t, s, from, userID)
+
+	if err := move(s, registrationID, to, userID); err != ErrCancellationDeadlinePassed {
+		t.Errorf("moving after the cancellation deadline: err = %v, want ErrCancellationDeadlinePassed", err)
+	}
+	registration, err := s.GetRegistrationByID(ctx, registrationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registration.EventID != from {
+		t.Errorf("registration moved to event %d after the cancellation deadline", registration.EventID)
+	}
+	if taken := seatsTaken(t, to); taken != 0 {
+		t.Errorf("seats taken at the event moved to = %d, want 0", taken)
+	}
+}
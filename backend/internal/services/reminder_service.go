@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/jobs"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/notifications"
+)
+
+// JobEventReminder is the kind of the jobs reminding the attendees of an event
+const JobEventReminder = "event_reminder"
+
+// reminderPayload is what a reminder job needs to know about its event
+type reminderPayload struct {
+	EventID       int64     `json:"event_id"`
+	EventDate     time.Time `json:"event_date"`
+	OffsetMinutes int       `json:"offset_minutes"`
+}
+
+// reminderKeyPrefix starts the keys of every reminder job of an event
+func reminderKeyPrefix(eventID int64) string {
+	return fmt.Sprintf("%s:%d:", JobEventReminder, eventID)
+}
+
+// reminderKey identifies a reminder. It includes the event date, so moving an
+// event schedules new reminders while those already sent for a date stay sent.
+func reminderKey(eventID int64, eventDate time.Time, offset int) string {
+	return fmt.Sprintf("%s%s:%d", reminderKeyPrefix(eventID), eventDate.UTC().Format(time.RFC3339), offset)
+}
+
+// syncReminders brings the reminder jobs of an event in line with its status
+// and date. Drafts and published events are reminded of, others aren't.
+// Call it in the transaction that changes the event.
+func syncReminders(ctx context.Context, db jobs.Execer, eventID int64, status models.EventStatus, eventDate time.Time, offsets []int) error {
+	if status != models.EventDraft && status != models.EventPublished {
+		return cancelReminders(ctx, db, eventID)
+	}
+
+	now := time.Now()
+	keys := make([]string, 0, len(offsets))
+	for _, offset := range offsets {
+		// Too late for this one, e.g. for an event created shortly before it starts
+		runAt := eventDate.Add(-time.Duration(offset) * time.Minute)
+		if !runAt.After(now) {
+			continue
+		}
+
+		payload, err := json.Marshal(reminderPayload{EventID: eventID, EventDate: eventDate.UTC(), OffsetMinutes: offset})
+		if err != nil {
+			return err
+		}
+		key := reminderKey(eventID, eventDate, offset)
+		if err := jobs.Schedule(ctx, db, JobEventReminder, key, string(payload), runAt); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+
+	// Reminders for an earlier date or for offsets that were removed
+	_, err := jobs.CancelPending(ctx, db, reminderKeyPrefix(eventID), keys...)
+	return err
+}
+
+// cancelReminders cancels the reminders of an event that weren't sent yet
+func cancelReminders(ctx context.Context, db jobs.Execer, eventID int64) error {
+	_, err := jobs.CancelPending(ctx, db, reminderKeyPrefix(eventID))
+	return err
+}
+
+// ReminderService sends the reminders scheduled for events
+type ReminderService struct {
+	eventService *EventService
+}
+
+// NewReminderService creates a new ReminderService
+func NewReminderService() *ReminderService {
+	return &ReminderService{
+		eventService: NewEventService(),
+	}
+}
+
+// SendReminder runs a JobEventReminder job, reminding every ticket holder of
+// the event once. Deliveries are recorded before notifying, so running a job
+// again after an interruption never reminds anyone twice.
+func (s *ReminderService) SendReminder(ctx context.Context, job *jobs.Job) error {
+	var payload reminderPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return err
+	}
+
+	event, err := s.eventService.GetEventByID(ctx, payload.EventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.InfoContext(ctx, "Skipping reminder of deleted event", "event_id", payload.EventID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if skip := reminderSkipReason(event, &payload, now); skip != "" {
+		logger.InfoContext(ctx, "Skipping reminder", "event_id", event.ID, "offset_minutes", payload.OffsetMinutes, "reason", skip)
+		return nil
+	}
+
+	recipients, err := ticketHolders(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+
+	hours, minutes := startsIn(event.EventDate.Sub(now))
+	sent := 0
+	for _, r := range recipients {
+		// Stopping between recipients is safe, the rest are reminded when the job runs again
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result, err := database.DB.ExecContext(ctx, `
+			INSERT INTO reminder_deliveries (job_id, registration_id, sent_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT DO NOTHING
+		`, job.ID, r.registrationID, now.UTC().Format(time.RFC3339))
+		if err != nil {
+			return err
+		}
+		if recorded, err := result.RowsAffected(); err != nil || recorded == 0 {
+			continue
+		}
+
+		data := eventData(event)
+		data.RecipientName = r.name
+		data.RegistrationID = r.registrationID
+		data.GuestCount = r.guestCount
+		data.StartsInHours = hours
+		data.StartsInMinutes = minutes
+		deliverNotification(ctx, &notifications.Notification{
+			Kind:   notifications.KindEventReminder,
+			UserID: r.userID,
+			To:     r.email,
+			Locale: r.locale,
+			Data:   data,
+		})
+		sent++
+	}
+
+	logger.InfoContext(ctx, "Sent reminder", "event_id", event.ID, "offset_minutes", payload.OffsetMinutes, "attendees", sent)
+	return nil
+}
+
+// reminderSkipReason tells why a reminder due at now shouldn't be sent, if it shouldn't
+func reminderSkipReason(event *models.Event, payload *reminderPayload, now time.Time) string {
+	if event.Status != models.EventPublished {
+		return "event is " + string(event.Status)
+	}
+	// Reminders are scheduled again when the event moves
+	if !event.EventDate.Equal(payload.EventDate) {
+		return "event was moved"
+	}
+	if !now.Before(event.EventDate) {
+		return "event has started"
+	}
+	// After downtime several reminders can be due at once; only the latest one is sent
+	for _, offset := range event.ReminderOffsets {
+		if offset < payload.OffsetMinutes && !event.EventDate.Add(-time.Duration(offset)*time.Minute).After(now) {
+			return fmt.Sprintf("the %d minute reminder is due as well", offset)
+		}
+	}
+	return ""
+}
+
+// startsIn splits the time until an event starts into whole hours, or into
+// minutes when it is less than an hour away
+func startsIn(d time.Duration) (hours, minutes int) {
+	minutes = int(math.Round(d.Minutes()))
+	if minutes >= 60 {
+		return int(math.Round(d.Hours())), 0
+	}
+	if minutes < 1 {
+		minutes = 1
+	}
+	return 0, minutes
+}
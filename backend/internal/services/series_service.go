@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/metrics"
+	"github.com/netpo4ki/event-poster/internal/models"
+)
+
+// SeriesService handles the business logic for recurring event series
+type SeriesService struct {
+	eventService *EventService
+	venueService *VenueService
+}
+
+// NewSeriesService creates a new SeriesService
+func NewSeriesService() *SeriesService {
+	return &SeriesService{
+		eventService: NewEventService(),
+		venueService: NewVenueService(),
+	}
+}
+
+// seriesCancelledReason is recorded on occurrences cancelled together with their series
+const seriesCancelledReason = "the event series was cancelled"
+
+// SeriesCancellation summarizes what cancelling a series affected
+type SeriesCancellation struct {
+	CancelledOccurrences   int `json:"cancelled_occurrences"`
+	CancelledRegistrations int `json:"cancelled_registrations"`
+}
+
+// CreateSeries creates a series and expands it into individual events
+func (s *SeriesService) CreateSeries(ctx context.Context, req *models.SeriesRequest, userID int64) (int64, error) {
+	req.Normalize()
+	if err := req.Validate(); err != nil {
+		logger.InfoContext(ctx, "Series refused", "user_id", userID, "reason", "invalid request", "error", err)
+		return 0, invalidSeries(err)
+	}
+	if err := req.ValidateEventDate(time.Now()); err != nil {
+		logger.InfoContext(ctx, "Series refused", "user_id", userID, "reason", "past date")
+		return 0, invalidSeries(err)
+	}
+
+	venue, err := s.venueService.prepareEventVenue(ctx, &req.EventRequest)
+	if err != nil {
+		return 0, err
+	}
+	latitude, longitude := nullCoordinates(s.venueService.locateEvent(&req.EventRequest, venue))
+
+	occurrences := req.Recurrence.Occurrences(req.LocalEventDate())
+	duration := req.Duration()
+	for _, occurrence := range occurrences {
+		if err := s.venueService.checkEventConflicts(ctx, &req.EventRequest, occurrence, occurrence.Add(duration), nil); err != nil {
+			return 0, err
+		}
+	}
+	logger.DebugContext(ctx, "Creating series", "user_id", userID, "title", req.Title, "occurrences", len(occurrences))
+
+	var count sql.NullInt64
+	if req.Recurrence.Count > 0 {
+		count = sql.NullInt64{Int64: int64(req.Recurrence.Count), Valid: true}
+	}
+	var until sql.NullString
+	if req.Recurrence.Until != nil {
+		until = sql.NullString{String: req.Recurrence.Until.UTC().Format(time.RFC3339), Valid: true}
+	}
+
+	timer := metrics.QueryTimer("create_series")
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO event_series (title, description, location, event_type, start_date, duration_minutes, timezone, seats, venue_id,
+			frequency, repeat_interval, occurrence_count, until_date, exceptions, creator_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, req.Title, req.Description, req.Location, req.EventType, req.EventDate.UTC().Format(time.RFC3339), int(duration.Minutes()), req.Timezone, req.Seats, req.VenueID,
+		req.Recurrence.Frequency, req.Recurrence.Interval, count, until, strings.Join(req.Recurrence.Exceptions, ","), userID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to insert series", "user_id", userID, "error", err)
+		return 0, err
+	}
+
+	seriesID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	eventIDs := make([]int64, 0, len(occurrences))
+	for _, occurrence := range occurrences {
+		// The registration window is given for the first occurrence and moves along with the others
+		window := req.RegistrationWindow.Shift(occurrence.Sub(req.EventDate))
+
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO events (title, description, location, event_type, event_date, end_date, timezone, seats, max_guests_per_registration,
+				max_no_shows, reminder_offsets, requires_approval, reserve_pending_seats, registration_opens_at, registration_closes_at,
+				cancellation_deadline, creator_id, venue_id, series_id, latitude, longitude, status, publish_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, req.Title, req.Description, req.Location, req.EventType, occurrence.UTC().Format(time.RFC3339),
+			occurrence.Add(duration).UTC().Format(time.RFC3339), req.Timezone, req.Seats, req.MaxGuests, req.MaxNoShows,
+			models.FormatReminderOffsets(req.ReminderOffsets), req.RequiresApproval, req.ReservePendingSeats, nullTime(window.RegistrationOpensAt), nullTime(window.RegistrationClosesAt),
+			nullTime(window.CancellationDeadline), userID, req.VenueID, seriesID, latitude, longitude,
+			req.Status, nullTime(req.PublishAt))
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to insert occurrence of series", "series_id", seriesID, "error", err)
+			return 0, err
+		}
+
+		eventID, err := result.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+		if err := syncReminders(ctx, tx, eventID, req.Status, occurrence, req.ReminderOffsets); err != nil {
+			logger.ErrorContext(ctx, "Failed to schedule reminders of new occurrence", "event_id", eventID, "error", err)
+			return 0, err
+		}
+		if err := emitEventWebhook(ctx, tx, models.TopicEventCreated, eventID); err != nil {
+			logger.ErrorContext(ctx, "Failed to queue webhook for new occurrence", "event_id", eventID, "error", err)
+			return 0, err
+		}
+		eventIDs = append(eventIDs, eventID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	timer.ObserveDuration()
+
+	logger.InfoContext(ctx, "Series created", "series_id", seriesID, "user_id", userID, "occurrences", len(eventIDs))
+	metrics.EventsCreated.Add(float64(len(eventIDs)))
+	publishAvailability(ctx, eventIDs...)
+	return seriesID, nil
+}
+
+// GetSeriesByID retrieves a single series by ID
+func (s *SeriesService) GetSeriesByID(ctx context.Context, id int64) (*models.EventSeries, error) {
+	var series models.EventSeries
+	var startDateStr, createdAtStr string
+	var description, location, until, exceptions, cancelledAt sql.NullString
+	var creatorID, venueID, count sql.NullInt64
+
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT id, title, description, location, event_type, start_date, duration_minutes, timezone, seats, venue_id,
+			frequency, repeat_interval, occurrence_count, until_date, exceptions,
+			creator_id, cancelled_at, created_at
+		FROM event_series
+		WHERE id = ?
+	`, id).Scan(
+		&series.ID,
+		&series.Title,
+		&description,
+		&location,
+		&series.EventType,
+		&startDateStr,
+		&series.Duration,
+		&series.Timezone,
+		&series.Seats,
+		&venueID,
+		&series.Recurrence.Frequency,
+		&series.Recurrence.Interval,
+		&count,
+		&until,
+		&exceptions,
+		&creatorID,
+		&cancelledAt,
+		&createdAtStr)
+	if err != nil {
+		return nil, err
+	}
+
+	series.StartDate, _ = time.Parse(time.RFC3339, startDateStr)
+	series.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+	if description.Valid {
+		series.Description = description.String
+	}
+	if location.Valid {
+		series.Location = location.String
+	}
+	if creatorID.Valid {
+		series.CreatorID = creatorID.Int64
+	}
+	if venueID.Valid {
+		id := venueID.Int64
+		series.VenueID = &id
+	}
+	if count.Valid {
+		series.Recurrence.Count = int(count.Int64)
+	}
+	if until.Valid {
+		if t, err := time.Parse(time.RFC3339, until.String); err == nil {
+			series.Recurrence.Until = &t
+		}
+	}
+	if exceptions.Valid && exceptions.String != "" {
+		series.Recurrence.Exceptions = strings.Split(exceptions.String, ",")
+	}
+	if cancelledAt.Valid {
+		if t, err := time.Parse(time.RFC3339, cancelledAt.String); err == nil {
+			series.CancelledAt = &t
+		}
+	}
+
+	return &series, nil
+}
+
+// GetSeriesOccurrences retrieves the events that belong to a series
+func (s *SeriesService) GetSeriesOccurrences(ctx context.Context, id int64) ([]models.Event, error) {
+	return s.eventService.GetEventsBySeries(ctx, id, nil)
+}
+
+// CancelSeries cancels every occurrence of a series that hasn't started yet.
+// Occurrences that already took place are kept. Registrations for the cancelled
+// occurrences are kept but marked cancelled, and reported back to the caller.
+func (s *SeriesService) CancelSeries(ctx context.Context, id int64, userID int64) (*SeriesCancellation, error) {
+	series, err := s.GetSeriesByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if the user has permission to cancel this series
+	if series.CreatorID != userID {
+		return nil, ErrNotSeriesCreator
+	}
+
+	if series.CancelledAt != nil {
+		return nil, ErrSeriesCancelled
+	}
+
+	now := time.Now()
+	upcoming, err := s.eventService.GetEventsBySeries(ctx, id, &now)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := metrics.QueryTimer("cancel_series")
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	cancellation := &SeriesCancellation{}
+	var cancelledEvents []models.Event
+	recipients := make(map[int64][]recipient)
+	for _, event := range upcoming {
+		if !event.Status.CanTransitionTo(models.EventCancelled) {
+			continue
+		}
+
+		// Look up who to tell before their registrations get cancelled
+		recipients[event.ID], err = eventRecipients(ctx, event.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err := tx.ExecContext(ctx, "UPDATE events SET status = ?, status_reason = ?, publish_at = NULL WHERE id = ?",
+			models.EventCancelled, seriesCancelledReason, event.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := cancelReminders(ctx, tx, event.ID); err != nil {
+			return nil, err
+		}
+
+		registrationsCount, err := cancelEventRegistrations(ctx, tx, event.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := emitEventWebhook(ctx, tx, models.TopicEventUpdated, event.ID); err != nil {
+			return nil, err
+		}
+
+		cancelledEvents = append(cancelledEvents, event)
+		cancellation.CancelledOccurrences++
+		cancellation.CancelledRegistrations += registrationsCount
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE event_series SET cancelled_at = ? WHERE id = ?", now.UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	timer.ObserveDuration()
+
+	for i := range cancelledEvents {
+		event := &cancelledEvents[i]
+		publishAvailability(ctx, event.ID)
+		notifyAttendees(ctx, event, recipients[event.ID], models.EventCancelled, seriesCancelledReason)
+	}
+
+	logger.InfoContext(ctx, "Series cancelled", "series_id", id, "user_id", userID,
+		"cancelled_occurrences", cancellation.CancelledOccurrences, "cancelled_registrations", cancellation.CancelledRegistrations)
+	return cancellation, nil
+}
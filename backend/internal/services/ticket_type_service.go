@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/models"
+)
+
+// TicketTypeService handles the business logic for ticket types
+type TicketTypeService struct {
+	eventService *EventService
+}
+
+// NewTicketTypeService creates a new TicketTypeService
+func NewTicketTypeService() *TicketTypeService {
+	return &TicketTypeService{
+		eventService: NewEventService(),
+	}
+}
+
+// ticketTypeColumns is the column list understood by scanTicketType. The last
+// column counts the seats taken in the ticket type.
+const ticketTypeColumns = `id, event_id, name, description, capacity, sales_start, sales_end, visibility, created_at,
+	(SELECT ` + registeredHeads + ` FROM registrations WHERE ticket_type_id = ticket_types.id AND ` + seatHoldingCondition + `)`
+
+// scanTicketType reads a ticket type selected with ticketTypeColumns
+func scanTicketType(row rowScanner) (*models.TicketType, error) {
+	var ticketType models.TicketType
+	var createdAtStr string
+	var description, salesStart, salesEnd sql.NullString
+	var takenSeats int
+
+	if err := row.Scan(
+		&ticketType.ID,
+		&ticketType.EventID,
+		&ticketType.Name,
+		&description,
+		&ticketType.Capacity,
+		&salesStart,
+		&salesEnd,
+		&ticketType.Visibility,
+		&createdAtStr,
+		&takenSeats); err != nil {
+		return nil, err
+	}
+
+	ticketType.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+	if description.Valid {
+		ticketType.Description = description.String
+	}
+	if salesStart.Valid {
+		if t, err := time.Parse(time.RFC3339, salesStart.String); err == nil {
+			ticketType.SalesStart = &t
+		}
+	}
+	if salesEnd.Valid {
+		if t, err := time.Parse(time.RFC3339, salesEnd.String); err == nil {
+			ticketType.SalesEnd = &t
+		}
+	}
+
+	ticketType.AvailableSeats = ticketType.Capacity - takenSeats
+	if ticketType.AvailableSeats < 0 {
+		ticketType.AvailableSeats = 0
+	}
+
+	return &ticketType, nil
+}
+
+// GetTicketTypes retrieves the ticket types of an event. Hidden ticket types
+// are only included when includeHidden is set.
+func (s *TicketTypeService) GetTicketTypes(ctx context.Context, eventID int64, includeHidden bool) ([]models.TicketType, error) {
+	query := `
+		SELECT ` + ticketTypeColumns + `
+		FROM ticket_types
+		WHERE event_id = ?`
+	if !includeHidden {
+		query += " AND visibility = 'public'"
+	}
+	query += " ORDER BY id"
+
+	rows, err := database.DB.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ticketTypes := []models.TicketType{}
+	for rows.Next() {
+		ticketType, err := scanTicketType(rows)
+		if err != nil {
+			return nil, err
+		}
+		ticketTypes = append(ticketTypes, *ticketType)
+	}
+
+	return ticketTypes, nil
+}
+
+// GetTicketTypeByID retrieves a single ticket type by ID
+func (s *TicketTypeService) GetTicketTypeByID(ctx context.Context, id int64) (*models.TicketType, error) {
+	row := database.DB.QueryRowContext(ctx, `
+		SELECT `+ticketTypeColumns+`
+		FROM ticket_types
+		WHERE id = ?
+	`, id)
+
+	return scanTicketType(row)
+}
+
+// CreateTicketType adds a ticket type to an event
+func (s *TicketTypeService) CreateTicketType(ctx context.Context, eventID int64, req *models.TicketTypeRequest, userID int64) (int64, error) {
+	if err := req.Validate(); err != nil {
+		return 0, err
+	}
+
+	event, err := s.eventService.GetEventByID(ctx, eventID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Check if the user has permission to change this event
+	if event.CreatorID != userID {
+		return 0, errors.New("you don't have permission to update this event")
+	}
+	if !event.Status.IsEditable() {
+		return 0, ErrEventNotEditable
+	}
+
+	if err := checkTicketCapacity(ctx, event, 0, req.Capacity); err != nil {
+		return 0, err
+	}
+
+	logger.DebugContext(ctx, "Creating ticket type", "event_id", eventID, "ticket_type", req.Name)
+
+	result, err := database.DB.ExecContext(ctx, `
+		INSERT INTO ticket_types (event_id, name, description, capacity, sales_start, sales_end, visibility, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, eventID, req.Name, req.Description, req.Capacity, nullTime(req.SalesStart), nullTime(req.SalesEnd), req.Visibility,
+		time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to insert ticket type", "event_id", eventID, "error", err)
+		return 0, err
+	}
+
+	publishAvailability(ctx, eventID)
+	return result.LastInsertId()
+}
+
+// UpdateTicketType updates an existing ticket type
+func (s *TicketTypeService) UpdateTicketType(ctx context.Context, id int64, req *models.TicketTypeRequest, userID int64) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	ticketType, err := s.GetTicketTypeByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	event, err := s.eventService.GetEventByID(ctx, ticketType.EventID)
+	if err != nil {
+		return err
+	}
+
+	// Check if the user has permission to change this event
+	if event.CreatorID != userID {
+		return errors.New("you don't have permission to update this event")
+	}
+	if !event.Status.IsEditable() {
+		return ErrEventNotEditable
+	}
+
+	taken := ticketType.Capacity - ticketType.AvailableSeats
+	if req.Capacity < taken {
+		return errors.New("cannot reduce capacity below the number of existing registrations")
+	}
+
+	if err := checkTicketCapacity(ctx, event, id, req.Capacity); err != nil {
+		return err
+	}
+
+	_, err = database.DB.ExecContext(ctx, `
+		UPDATE ticket_types
+		SET name = ?, description = ?, capacity = ?, sales_start = ?, sales_end = ?, visibility = ?
+		WHERE id = ?
+	`, req.Name, req.Description, req.Capacity, nullTime(req.SalesStart), nullTime(req.SalesEnd), req.Visibility, id)
+	if err != nil {
+		return err
+	}
+
+	publishAvailability(ctx, ticketType.EventID)
+	return nil
+}
+
+// DeleteTicketType deletes a ticket type that no registration references
+func (s *TicketTypeService) DeleteTicketType(ctx context.Context, id int64, userID int64) error {
+	ticketType, err := s.GetTicketTypeByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	event, err := s.eventService.GetEventByID(ctx, ticketType.EventID)
+	if err != nil {
+		return err
+	}
+
+	// Check if the user has permission to change this event
+	if event.CreatorID != userID {
+		return errors.New("you don't have permission to update this event")
+	}
+
+	// Cancelled registrations still reference the ticket type they held
+	var registrationsCount int
+	err = database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM registrations WHERE ticket_type_id = ?", id).Scan(&registrationsCount)
+	if err != nil {
+		return err
+	}
+	if registrationsCount > 0 {
+		return ErrTicketTypeInUse
+	}
+
+	_, err = database.DB.ExecContext(ctx, "DELETE FROM ticket_types WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	publishAvailability(ctx, ticketType.EventID)
+	return nil
+}
+
+// resolveTicketType checks the ticket type picked for a registration. Events
+// with ticket types require one that belongs to the event and is on sale; for
+// events without ticket types it returns nil.
+func (s *TicketTypeService) resolveTicketType(ctx context.Context, event *models.Event, ticketTypeID *int64, now time.Time) (*models.TicketType, error) {
+	if ticketTypeID == nil {
+		var ticketTypesCount int
+		err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM ticket_types WHERE event_id = ?", event.ID).Scan(&ticketTypesCount)
+		if err != nil {
+			return nil, err
+		}
+		if ticketTypesCount > 0 {
+			return nil, ErrTicketTypeRequired
+		}
+		return nil, nil
+	}
+
+	ticketType, err := s.GetTicketTypeByID(ctx, *ticketTypeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTicketTypeNotFound
+		}
+		return nil, err
+	}
+	if ticketType.EventID != event.ID {
+		return nil, ErrTicketTypeNotFound
+	}
+
+	if !ticketType.IsOnSale(now) {
+		return nil, ErrTicketSalesClosed
+	}
+
+	return ticketType, nil
+}
+
+// checkTicketCapacity ensures the ticket types of an event fit into its seats
+// once the ticket type with the given ID (0 for a new one) has the given capacity
+func checkTicketCapacity(ctx context.Context, event *models.Event, ticketTypeID int64, capacity int) error {
+	var otherCapacity int
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(capacity), 0) FROM ticket_types
+		WHERE event_id = ? AND id <> ?
+	`, event.ID, ticketTypeID).Scan(&otherCapacity)
+	if err != nil {
+		return err
+	}
+
+	if otherCapacity+capacity > event.Seats {
+		return ErrTicketCapacityExceeded
+	}
+	return nil
+}
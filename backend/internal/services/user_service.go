@@ -1,13 +1,18 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/netpo4ki/event-poster/internal/database"
 	"github.com/netpo4ki/event-poster/internal/middleware"
 	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/notifications"
+	"github.com/netpo4ki/event-poster/internal/tracing"
 )
 
 // UserService handles the business logic for users
@@ -19,14 +24,17 @@ func NewUserService() *UserService {
 }
 
 // Register creates a new user
-func (s *UserService) Register(req *models.UserRequest) (int64, error) {
+func (s *UserService) Register(ctx context.Context, req *models.UserRequest) (int64, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Register")
+	defer span.End()
+
 	if err := req.Validate(); err != nil {
 		return 0, err
 	}
 
 	// Check if username already exists
 	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM users WHERE username = ?", req.Username).Scan(&count)
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE username = ?", req.Username).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -35,7 +43,7 @@ func (s *UserService) Register(req *models.UserRequest) (int64, error) {
 	}
 
 	// Check if email already exists
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM users WHERE email = ?", req.Email).Scan(&count)
+	err = database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE email = ?", req.Email).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -43,16 +51,25 @@ func (s *UserService) Register(req *models.UserRequest) (int64, error) {
 		return 0, errors.New("email already exists")
 	}
 
+	if !notifications.IsSupportedLocale(req.Locale) {
+		return 0, errors.New("locale is not supported")
+	}
+
 	// Hash the password
 	if err := req.HashPassword(); err != nil {
 		return 0, err
 	}
 
+	role := models.RoleUser
+	if adminUsernames()[req.Username] {
+		role = models.RoleAdmin
+	}
+
 	// Insert the user
-	result, err := database.DB.Exec(`
-		INSERT INTO users (username, password, email, role)
-		VALUES (?, ?, ?, ?)
-	`, req.Username, req.Password, req.Email, models.RoleUser)
+	result, err := database.DB.ExecContext(ctx, `
+		INSERT INTO users (username, password, email, role, locale)
+		VALUES (?, ?, ?, ?, ?)
+	`, req.Username, req.Password, req.Email, role, req.Locale)
 	if err != nil {
 		return 0, err
 	}
@@ -60,10 +77,42 @@ func (s *UserService) Register(req *models.UserRequest) (int64, error) {
 	return result.LastInsertId()
 }
 
+// adminUsernames returns the users listed in ADMIN_USERS, a comma separated list of usernames
+func adminUsernames() map[string]bool {
+	admins := make(map[string]bool)
+	for _, username := range strings.Split(os.Getenv("ADMIN_USERS"), ",") {
+		if username = strings.TrimSpace(username); username != "" {
+			admins[username] = true
+		}
+	}
+	return admins
+}
+
+// GrantAdminRoles makes the existing users listed in ADMIN_USERS admins.
+// They get the role in tokens issued from then on.
+func (s *UserService) GrantAdminRoles(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "UserService.GrantAdminRoles")
+	defer span.End()
+
+	for username := range adminUsernames() {
+		result, err := database.DB.ExecContext(ctx, "UPDATE users SET role = ? WHERE username = ? AND role <> ?", models.RoleAdmin, username, models.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		if granted, err := result.RowsAffected(); err == nil && granted > 0 {
+			logger.Info("Granted admin role", "username", username)
+		}
+	}
+	return nil
+}
+
 // Login authenticates a user and returns a JWT token
-func (s *UserService) Login(req *models.LoginRequest) (*models.LoginResponse, error) {
+func (s *UserService) Login(ctx context.Context, req *models.LoginRequest) (*models.LoginResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Login")
+	defer span.End()
+
 	// Find the user by username
-	user, err := s.GetUserByUsername(req.Username)
+	user, err := s.GetUserByUsername(ctx, req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("invalid username or password")
@@ -89,12 +138,15 @@ func (s *UserService) Login(req *models.LoginRequest) (*models.LoginResponse, er
 }
 
 // GetUserByID retrieves a user by ID
-func (s *UserService) GetUserByID(id int64) (*models.User, error) {
+func (s *UserService) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
+
 	var user models.User
 	var createdAtStr string
 
-	err := database.DB.QueryRow(`
-		SELECT id, username, password, email, role, created_at
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT id, username, password, email, role, locale, created_at
 		FROM users
 		WHERE id = ?
 	`, id).Scan(
@@ -103,6 +155,7 @@ func (s *UserService) GetUserByID(id int64) (*models.User, error) {
 		&user.Password,
 		&user.Email,
 		&user.Role,
+		&user.Locale,
 		&createdAtStr,
 	)
 	if err != nil {
@@ -114,12 +167,15 @@ func (s *UserService) GetUserByID(id int64) (*models.User, error) {
 }
 
 // GetUserByUsername retrieves a user by username
-func (s *UserService) GetUserByUsername(username string) (*models.User, error) {
+func (s *UserService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByUsername")
+	defer span.End()
+
 	var user models.User
 	var createdAtStr string
 
-	err := database.DB.QueryRow(`
-		SELECT id, username, password, email, role, created_at
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT id, username, password, email, role, locale, created_at
 		FROM users
 		WHERE username = ?
 	`, username).Scan(
@@ -128,6 +184,7 @@ func (s *UserService) GetUserByUsername(username string) (*models.User, error) {
 		&user.Password,
 		&user.Email,
 		&user.Role,
+		&user.Locale,
 		&createdAtStr,
 	)
 	if err != nil {
@@ -139,9 +196,12 @@ func (s *UserService) GetUserByUsername(username string) (*models.User, error) {
 }
 
 // GetAllUsers retrieves all users
-func (s *UserService) GetAllUsers() ([]models.User, error) {
-	rows, err := database.DB.Query(`
-		SELECT id, username, password, email, role, created_at
+func (s *UserService) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUsers")
+	defer span.End()
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, username, password, email, role, locale, created_at
 		FROM users
 		ORDER BY created_at
 	`)
@@ -161,6 +221,7 @@ func (s *UserService) GetAllUsers() ([]models.User, error) {
 			&user.Password,
 			&user.Email,
 			&user.Role,
+			&user.Locale,
 			&createdAtStr,
 		); err != nil {
 			return nil, err
@@ -174,8 +235,11 @@ func (s *UserService) GetAllUsers() ([]models.User, error) {
 }
 
 // DeleteUser deletes a user by ID
-func (s *UserService) DeleteUser(id int64) error {
-	result, err := database.DB.Exec("DELETE FROM users WHERE id = ?", id)
+func (s *UserService) DeleteUser(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
 	if err != nil {
 		return err
 	}
@@ -193,8 +257,11 @@ func (s *UserService) DeleteUser(id int64) error {
 }
 
 // GetUserRegistrations retrieves all registrations for a user
-func (s *UserService) GetUserRegistrations(userID int64) ([]models.RegistrationResponse, error) {
-	rows, err := database.DB.Query(`
+func (s *UserService) GetUserRegistrations(ctx context.Context, userID int64) ([]models.RegistrationResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserRegistrations")
+	defer span.End()
+
+	rows, err := database.DB.QueryContext(ctx, `
 		SELECT r.id, r.event_id, r.first_name, r.last_name, r.created_at,
 			   e.title, e.event_date, e.event_type
 		FROM registrations r
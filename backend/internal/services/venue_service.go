@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/geo"
+	"github.com/netpo4ki/event-poster/internal/models"
+)
+
+// VenueService handles the business logic for venues
+type VenueService struct {
+	geocoder geo.Geocoder
+}
+
+// NewVenueService creates a new VenueService
+func NewVenueService() *VenueService {
+	return &VenueService{
+		geocoder: geo.DefaultGeocoder,
+	}
+}
+
+// venueColumns is the column list understood by scanVenue
+const venueColumns = "id, name, address, latitude, longitude, capacity, accessibility, creator_id, created_at"
+
+// scanVenue reads a venue selected with venueColumns
+func scanVenue(row rowScanner) (*models.Venue, error) {
+	var venue models.Venue
+	var createdAtStr string
+	var address, accessibility sql.NullString
+	var latitude, longitude sql.NullFloat64
+	var creatorID sql.NullInt64
+
+	if err := row.Scan(
+		&venue.ID,
+		&venue.Name,
+		&address,
+		&latitude,
+		&longitude,
+		&venue.Capacity,
+		&accessibility,
+		&creatorID,
+		&createdAtStr); err != nil {
+		return nil, err
+	}
+
+	venue.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+	if address.Valid {
+		venue.Address = address.String
+	}
+	if accessibility.Valid {
+		venue.Accessibility = accessibility.String
+	}
+	if latitude.Valid && longitude.Valid {
+		venue.Latitude = &latitude.Float64
+		venue.Longitude = &longitude.Float64
+	}
+	if creatorID.Valid {
+		venue.CreatorID = creatorID.Int64
+	}
+
+	return &venue, nil
+}
+
+// GetAllVenues retrieves all venues
+func (s *VenueService) GetAllVenues(ctx context.Context) ([]models.Venue, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT `+venueColumns+`
+		FROM venues
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var venues []models.Venue
+	for rows.Next() {
+		venue, err := scanVenue(rows)
+		if err != nil {
+			return nil, err
+		}
+		venues = append(venues, *venue)
+	}
+
+	return venues, nil
+}
+
+// GetVenueByID retrieves a single venue by ID
+func (s *VenueService) GetVenueByID(ctx context.Context, id int64) (*models.Venue, error) {
+	row := database.DB.QueryRowContext(ctx, `
+		SELECT `+venueColumns+`
+		FROM venues
+		WHERE id = ?
+	`, id)
+
+	return scanVenue(row)
+}
+
+// CreateVenue creates a new venue
+func (s *VenueService) CreateVenue(ctx context.Context, req *models.VenueRequest, userID int64) (int64, error) {
+	if err := req.Validate(); err != nil {
+		return 0, err
+	}
+
+	s.geocodeVenue(ctx, req)
+
+	logger.DebugContext(ctx, "Creating venue", "user_id", userID, "venue", req.Name)
+
+	result, err := database.DB.ExecContext(ctx, `
+		INSERT INTO venues (name, address, latitude, longitude, capacity, accessibility, creator_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, req.Name, req.Address, req.Latitude, req.Longitude, req.Capacity, req.Accessibility, userID,
+		time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to insert venue", "user_id", userID, "error", err)
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// UpdateVenue updates an existing venue
+func (s *VenueService) UpdateVenue(ctx context.Context, id int64, req *models.VenueRequest, userID int64) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	venue, err := s.GetVenueByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if the user has permission to update this venue
+	if venue.CreatorID != userID {
+		return errors.New("you don't have permission to update this venue")
+	}
+
+	// Upcoming events must still fit into the venue
+	var maxSeats sql.NullInt64
+	err = database.DB.QueryRowContext(ctx, `
+		SELECT MAX(seats) FROM events
+		WHERE venue_id = ? AND end_date > ?
+	`, id, time.Now().UTC().Format(time.RFC3339)).Scan(&maxSeats)
+	if err != nil {
+		return err
+	}
+	if maxSeats.Valid && int(maxSeats.Int64) > req.Capacity {
+		return errors.New("cannot reduce capacity below the seats of upcoming events at this venue")
+	}
+
+	s.geocodeVenue(ctx, req)
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE venues
+		SET name = ?, address = ?, latitude = ?, longitude = ?, capacity = ?, accessibility = ?
+		WHERE id = ?
+	`, req.Name, req.Address, req.Latitude, req.Longitude, req.Capacity, req.Accessibility, id)
+	if err != nil {
+		return err
+	}
+
+	// Events at the venue are searched by their own coordinates, so keep them in sync
+	if req.Latitude != nil {
+		_, err = tx.ExecContext(ctx, "UPDATE events SET latitude = ?, longitude = ? WHERE venue_id = ?", req.Latitude, req.Longitude, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// geocodeVenue fills in missing coordinates from the venue address
+func (s *VenueService) geocodeVenue(ctx context.Context, req *models.VenueRequest) {
+	if req.Latitude != nil || req.Address == "" {
+		return
+	}
+
+	point, err := s.geocoder.Geocode(req.Address)
+	if err != nil {
+		logger.WarnContext(ctx, "Could not geocode venue address", "address", req.Address, "error", err)
+		return
+	}
+	req.Latitude = &point.Lat
+	req.Longitude = &point.Lng
+}
+
+// DeleteVenue deletes a venue that no event references anymore
+func (s *VenueService) DeleteVenue(ctx context.Context, id int64, userID int64) error {
+	venue, err := s.GetVenueByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if the user has permission to delete this venue
+	if venue.CreatorID != userID {
+		return errors.New("you don't have permission to delete this venue")
+	}
+
+	var eventsCount int
+	err = database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM events WHERE venue_id = ?", id).Scan(&eventsCount)
+	if err != nil {
+		return err
+	}
+	if eventsCount > 0 {
+		return ErrVenueInUse
+	}
+
+	_, err = database.DB.ExecContext(ctx, "DELETE FROM venues WHERE id = ?", id)
+	return err
+}
+
+// FindConflicts returns events at the venue that overlap the given time range,
+// ignoring the events listed in exclude (typically the ones being updated)
+func (s *VenueService) FindConflicts(ctx context.Context, venueID int64, start, end time.Time, exclude []int64) ([]models.Event, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT `+eventColumns+`
+		FROM events
+		WHERE venue_id = ? AND event_date < ? AND end_date > ?
+		ORDER BY event_date
+	`, venueID, end.UTC().Format(time.RFC3339), start.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	excluded := make(map[int64]bool, len(exclude))
+	for _, id := range exclude {
+		excluded[id] = true
+	}
+
+	var conflicts []models.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		if !excluded[event.ID] {
+			conflicts = append(conflicts, *event)
+		}
+	}
+
+	return conflicts, nil
+}
+
+// prepareEventVenue checks that the venue requested for an event exists and can
+// hold its seats, and fills in the event location from the venue when none was given.
+// It returns the venue, or nil when the event doesn't take place at one.
+func (s *VenueService) prepareEventVenue(ctx context.Context, req *models.EventRequest) (*models.Venue, error) {
+	if req.VenueID == nil {
+		return nil, nil
+	}
+
+	venue, err := s.GetVenueByID(ctx, *req.VenueID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrVenueNotFound
+		}
+		return nil, err
+	}
+
+	if req.Seats > venue.Capacity {
+		return nil, ErrVenueCapacityExceeded
+	}
+
+	if req.Location == "" {
+		req.Location = venue.DisplayLocation()
+	}
+	return venue, nil
+}
+
+// locateEvent determines the coordinates of an event, preferring its venue and
+// falling back to geocoding the free-text location. It returns nil when unknown.
+func (s *VenueService) locateEvent(req *models.EventRequest, venue *models.Venue) *geo.Point {
+	if venue != nil && venue.Latitude != nil && venue.Longitude != nil {
+		return &geo.Point{Lat: *venue.Latitude, Lng: *venue.Longitude}
+	}
+	if req.Location == "" {
+		return nil
+	}
+
+	point, err := s.geocoder.Geocode(req.Location)
+	if err != nil {
+		return nil
+	}
+	return &point
+}
+
+// nullCoordinates converts an optional point into nullable column values
+func nullCoordinates(point *geo.Point) (sql.NullFloat64, sql.NullFloat64) {
+	if point == nil {
+		return sql.NullFloat64{}, sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: point.Lat, Valid: true}, sql.NullFloat64{Float64: point.Lng, Valid: true}
+}
+
+// checkEventConflicts rejects an event that overlaps another one at its venue,
+// unless the request explicitly accepts the overlap
+func (s *VenueService) checkEventConflicts(ctx context.Context, req *models.EventRequest, start, end time.Time, exclude []int64) error {
+	if req.VenueID == nil || req.AllowVenueConflict {
+		return nil
+	}
+
+	conflicts, err := s.FindConflicts(ctx, *req.VenueID, start, end, exclude)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return ErrVenueConflict
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/models"
+)
+
+// emitEventWebhook adds a webhook about an event to the outbox. Call it in the
+// transaction that changes the event, after the change, so the webhook is sent
+// if and only if the change is committed.
+func emitEventWebhook(ctx context.Context, tx *sql.Tx, topic models.WebhookTopic, eventID int64) error {
+	event, err := scanEvent(tx.QueryRowContext(ctx, "SELECT "+eventColumns+" FROM events WHERE id = ?", eventID))
+	if err != nil {
+		return err
+	}
+	return emitWebhook(ctx, tx, topic, event.ID, event.CreatorID, event)
+}
+
+// emitRegistrationWebhook adds a webhook about a registration to the outbox,
+// like emitEventWebhook. It goes to the webhooks of the event's organizer.
+func emitRegistrationWebhook(ctx context.Context, tx *sql.Tx, topic models.WebhookTopic, registrationID int64) error {
+	registration, err := scanRegistration(tx.QueryRowContext(ctx, "SELECT "+registrationColumns+" FROM registrations WHERE id = ?", registrationID))
+	if err != nil {
+		return err
+	}
+
+	var ownerID sql.NullInt64
+	if err := tx.QueryRowContext(ctx, "SELECT creator_id FROM events WHERE id = ?", registration.EventID).Scan(&ownerID); err != nil {
+		return err
+	}
+	return emitWebhook(ctx, tx, topic, registration.EventID, ownerID.Int64, registration)
+}
+
+// emitWebhook adds data to the outbox as a change of an event owned by ownerID.
+// Nothing is added when the owner has no webhooks.
+func emitWebhook(ctx context.Context, tx *sql.Tx, topic models.WebhookTopic, eventID, ownerID int64, data interface{}) error {
+	var subscribed bool
+	err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM webhooks WHERE user_id = ? AND active = 1)", ownerID).Scan(&subscribed)
+	if err != nil || !subscribed {
+		return err
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO webhook_outbox (topic, event_id, owner_id, payload, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, topic, eventID, ownerID, string(payload), time.Now().UTC().Format(time.RFC3339))
+	return err
+}
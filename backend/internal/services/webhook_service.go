@@ -0,0 +1,537 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/netpo4ki/event-poster/internal/database"
+	"github.com/netpo4ki/event-poster/internal/models"
+	"github.com/netpo4ki/event-poster/internal/webhooks"
+)
+
+// webhookBatchSize caps the outbox entries and deliveries handled per dispatch
+const webhookBatchSize = 100
+
+// DefaultDeliveriesLimit is the number of deliveries listed when no limit is given
+const DefaultDeliveriesLimit = 50
+
+// maxDeliveriesLimit caps the number of deliveries listed at once
+const maxDeliveriesLimit = 500
+
+// WebhookService handles webhook subscriptions and sends the webhooks added to the outbox
+type WebhookService struct {
+	eventService *EventService
+}
+
+// NewWebhookService creates a new WebhookService
+func NewWebhookService() *WebhookService {
+	return &WebhookService{
+		eventService: NewEventService(),
+	}
+}
+
+// CreateWebhook subscribes a URL to the changes of the events a user organizes,
+// or of one of them. The returned webhook holds its signing secret, which
+// isn't shown again.
+func (s *WebhookService) CreateWebhook(ctx context.Context, req *models.WebhookRequest, userID int64) (*models.Webhook, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if req.EventID != nil {
+		event, err := s.eventService.GetEventByID(ctx, *req.EventID)
+		if err != nil {
+			return nil, err
+		}
+		// Check if the user has permission to watch this event
+		if event.CreatorID != userID {
+			return nil, errors.New("you don't have permission to add webhooks to this event")
+		}
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := database.DB.ExecContext(ctx, `
+		INSERT INTO webhooks (user_id, event_id, url, secret, topics, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, userID, req.EventID, req.URL, secret, joinTopics(req.Topics), time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to insert webhook", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Webhook created", "webhook_id", id, "user_id", userID)
+	webhook, err := s.getOwnWebhook(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = secret
+	return webhook, nil
+}
+
+// GetUserWebhooks retrieves the webhooks of a user
+func (s *WebhookService) GetUserWebhooks(ctx context.Context, userID int64) ([]models.Webhook, error) {
+	rows, err := database.DB.QueryContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhookList := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhookList = append(webhookList, *webhook)
+	}
+
+	return webhookList, rows.Err()
+}
+
+// DeleteWebhook removes a webhook of a user along with its delivery log
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int64, userID int64) error {
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM webhooks WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetDeliveries retrieves the latest deliveries of a webhook, optionally only those with the given status
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookID int64, userID int64, status models.DeliveryStatus, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.getOwnWebhook(ctx, webhookID, userID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultDeliveriesLimit
+	}
+	if limit > maxDeliveriesLimit {
+		limit = maxDeliveriesLimit
+	}
+
+	query := "SELECT " + deliveryColumns + " FROM webhook_deliveries WHERE webhook_id = ?"
+	args := []interface{}{webhookID}
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// SendTestWebhook sends a webhook.test request to a webhook right away and
+// returns the delivery. A failed test is retried like any other delivery.
+func (s *WebhookService) SendTestWebhook(ctx context.Context, webhookID int64, userID int64) (*models.WebhookDelivery, error) {
+	webhook, err := s.getOwnWebhook(ctx, webhookID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	data, err := json.Marshal(map[string]interface{}{"webhook_id": webhook.ID, "event_id": webhook.EventID})
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(models.WebhookPayload{
+		ID:        fmt.Sprintf("test_%d", now.UnixNano()),
+		Topic:     models.TopicWebhookTest,
+		CreatedAt: now,
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := database.DB.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, topic, body, status, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, webhook.ID, models.TopicWebhookTest, string(body), models.DeliveryPending, now.Format(time.RFC3339), now.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	deliveryID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attemptDelivery(ctx, deliveryID, webhook.URL, s.webhookSecret(ctx, webhook.ID), models.TopicWebhookTest, body, 0); err != nil {
+		return nil, err
+	}
+	return s.getDelivery(ctx, deliveryID)
+}
+
+// RetryDelivery gives a dead delivery another round of attempts
+func (s *WebhookService) RetryDelivery(ctx context.Context, webhookID, deliveryID int64, userID int64) (*models.WebhookDelivery, error) {
+	if _, err := s.getOwnWebhook(ctx, webhookID, userID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.getDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.WebhookID != webhookID {
+		return nil, sql.ErrNoRows
+	}
+	if delivery.Status != models.DeliveryDead {
+		return nil, ErrDeliveryNotDead
+	}
+
+	_, err = database.DB.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET status = ?, attempts = 0, next_attempt_at = ?
+		WHERE id = ? AND status = ?
+	`, models.DeliveryPending, time.Now().UTC().Format(time.RFC3339), deliveryID, models.DeliveryDead)
+	if err != nil {
+		return nil, err
+	}
+	return s.getDelivery(ctx, deliveryID)
+}
+
+// DispatchWebhooks turns new outbox entries into deliveries to the matching
+// webhooks, then sends the deliveries that are due. It runs as a recurring job.
+func (s *WebhookService) DispatchWebhooks(ctx context.Context) error {
+	if err := s.fanOut(ctx); err != nil {
+		return err
+	}
+	return s.deliverDue(ctx)
+}
+
+// outboxEntry is a change waiting in the webhook outbox
+type outboxEntry struct {
+	id        int64
+	topic     models.WebhookTopic
+	eventID   int64
+	ownerID   int64
+	payload   string
+	createdAt time.Time
+}
+
+// fanOut creates a delivery of each undispatched outbox entry for every webhook that wants it
+func (s *WebhookService) fanOut(ctx context.Context) error {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, topic, event_id, owner_id, payload, created_at
+		FROM webhook_outbox
+		WHERE dispatched_at IS NULL
+		ORDER BY id
+		LIMIT ?
+	`, webhookBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var entries []outboxEntry
+	for rows.Next() {
+		var entry outboxEntry
+		var ownerID sql.NullInt64
+		var createdAtStr string
+		if err := rows.Scan(&entry.id, &entry.topic, &entry.eventID, &ownerID, &entry.payload, &createdAtStr); err != nil {
+			rows.Close()
+			return err
+		}
+		entry.ownerID = ownerID.Int64
+		entry.createdAt, _ = time.Parse(time.RFC3339, createdAtStr)
+		entries = append(entries, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := s.dispatchEntry(ctx, &entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dispatchEntry creates the deliveries of an outbox entry and marks it dispatched
+func (s *WebhookService) dispatchEntry(ctx context.Context, entry *outboxEntry) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+webhookColumns+`
+		FROM webhooks
+		WHERE user_id = ? AND active = 1 AND (event_id IS NULL OR event_id = ?)
+	`, entry.ownerID, entry.eventID)
+	if err != nil {
+		return err
+	}
+	var targets []*models.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		if webhook.Wants(entry.topic) {
+			targets = append(targets, webhook)
+		}
+	}
+	rows.Close()
+
+	body, err := json.Marshal(models.WebhookPayload{
+		ID:        fmt.Sprintf("whe_%d", entry.id),
+		Topic:     entry.topic,
+		CreatedAt: entry.createdAt,
+		Data:      json.RawMessage(entry.payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, webhook := range targets {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries (webhook_id, outbox_id, topic, body, status, next_attempt_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (webhook_id, outbox_id) DO NOTHING
+		`, webhook.ID, entry.id, entry.topic, string(body), models.DeliveryPending, now, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE webhook_outbox SET dispatched_at = ? WHERE id = ?", now, entry.id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deliverDue sends the pending deliveries whose next attempt has come
+func (s *WebhookService) deliverDue(ctx context.Context) error {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT d.id, d.topic, d.body, d.attempts, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN webhooks w ON d.webhook_id = w.id
+		WHERE d.status = ? AND d.next_attempt_at <= ? AND w.active = 1
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT ?
+	`, models.DeliveryPending, time.Now().UTC().Format(time.RFC3339), webhookBatchSize)
+	if err != nil {
+		return err
+	}
+
+	type dueDelivery struct {
+		id          int64
+		topic       models.WebhookTopic
+		body        string
+		attempts    int
+		url, secret string
+	}
+	var due []dueDelivery
+	for rows.Next() {
+		var d dueDelivery
+		if err := rows.Scan(&d.id, &d.topic, &d.body, &d.attempts, &d.url, &d.secret); err != nil {
+			rows.Close()
+			return err
+		}
+		due = append(due, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range due {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.attemptDelivery(ctx, d.id, d.url, d.secret, d.topic, []byte(d.body), d.attempts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attemptDelivery sends a delivery once and records the outcome: delivered,
+// retried later with exponential backoff, or dead after models.WebhookMaxAttempts
+func (s *WebhookService) attemptDelivery(ctx context.Context, deliveryID int64, url, secret string, topic models.WebhookTopic, body []byte, attempts int) error {
+	result := webhooks.Send(ctx, &webhooks.Request{
+		URL:        url,
+		Secret:     secret,
+		DeliveryID: deliveryID,
+		Topic:      string(topic),
+		Body:       body,
+	})
+	// Shutting down isn't the receiver's fault, so it doesn't count as an attempt
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	attempts++
+	now := time.Now().UTC()
+	status := models.DeliveryDelivered
+	var nextAttemptAt, deliveredAt sql.NullString
+	var lastError sql.NullString
+	switch {
+	case result.Err == nil:
+		deliveredAt = sql.NullString{String: now.Format(time.RFC3339), Valid: true}
+	case attempts >= models.WebhookMaxAttempts:
+		status = models.DeliveryDead
+		lastError = sql.NullString{String: result.Err.Error(), Valid: true}
+		logger.WarnContext(ctx, "Webhook delivery is dead", "delivery_id", deliveryID, "attempts", attempts, "error", result.Err)
+	default:
+		status = models.DeliveryPending
+		lastError = sql.NullString{String: result.Err.Error(), Valid: true}
+		nextAttemptAt = sql.NullString{String: now.Add(models.WebhookRetryDelay << uint(attempts-1)).Format(time.RFC3339), Valid: true}
+	}
+
+	var responseStatus sql.NullInt64
+	if result.StatusCode != 0 {
+		responseStatus = sql.NullInt64{Int64: int64(result.StatusCode), Valid: true}
+	}
+
+	_, err := database.DB.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, response_status = ?, last_error = ?, duration_ms = ?, delivered_at = ?
+		WHERE id = ?
+	`, status, attempts, nextAttemptAt, responseStatus, lastError, result.Duration.Milliseconds(), deliveredAt, deliveryID)
+	return err
+}
+
+// PruneWebhookHistory deletes dispatched outbox entries and finished
+// deliveries created before the given time
+func (s *WebhookService) PruneWebhookHistory(ctx context.Context, before time.Time) error {
+	cutoff := before.UTC().Format(time.RFC3339)
+	_, err := database.DB.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE status <> ? AND created_at < ?", models.DeliveryPending, cutoff)
+	if err != nil {
+		return err
+	}
+	_, err = database.DB.ExecContext(ctx, "DELETE FROM webhook_outbox WHERE dispatched_at IS NOT NULL AND created_at < ?", cutoff)
+	return err
+}
+
+// getOwnWebhook retrieves a webhook of a user; webhooks of others are reported as not found
+func (s *WebhookService) getOwnWebhook(ctx context.Context, id int64, userID int64) (*models.Webhook, error) {
+	return scanWebhook(database.DB.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = ? AND user_id = ?", id, userID))
+}
+
+// webhookSecret looks up the signing secret of a webhook
+func (s *WebhookService) webhookSecret(ctx context.Context, id int64) string {
+	var secret string
+	if err := database.DB.QueryRowContext(ctx, "SELECT secret FROM webhooks WHERE id = ?", id).Scan(&secret); err != nil {
+		logger.ErrorContext(ctx, "Failed to look up the secret of webhook", "webhook_id", id, "error", err)
+	}
+	return secret
+}
+
+// getDelivery retrieves a single webhook delivery
+func (s *WebhookService) getDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	return scanDelivery(database.DB.QueryRowContext(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE id = ?", id))
+}
+
+// webhookColumns is the column list understood by scanWebhook. The secret is left out on purpose.
+const webhookColumns = "id, user_id, event_id, url, topics, active, created_at"
+
+// scanWebhook reads a webhook selected with webhookColumns
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var eventID sql.NullInt64
+	var topics, createdAtStr string
+	if err := row.Scan(&webhook.ID, &webhook.UserID, &eventID, &webhook.URL, &topics, &webhook.Active, &createdAtStr); err != nil {
+		return nil, err
+	}
+	if eventID.Valid {
+		webhook.EventID = &eventID.Int64
+	}
+	webhook.Topics = splitTopics(topics)
+	webhook.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+	return &webhook, nil
+}
+
+// deliveryColumns is the column list understood by scanDelivery
+const deliveryColumns = "id, webhook_id, topic, status, attempts, next_attempt_at, response_status, last_error, duration_ms, " +
+	"body, created_at, delivered_at"
+
+// scanDelivery reads a webhook delivery selected with deliveryColumns
+func scanDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var nextAttemptAt, lastError, deliveredAt sql.NullString
+	var responseStatus, durationMs sql.NullInt64
+	var body, createdAtStr string
+	if err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Topic, &delivery.Status, &delivery.Attempts,
+		&nextAttemptAt, &responseStatus, &lastError, &durationMs, &body, &createdAtStr, &deliveredAt); err != nil {
+		return nil, err
+	}
+
+	// Only pending deliveries have a next attempt
+	if delivery.Status == models.DeliveryPending {
+		delivery.NextAttemptAt = parseNullTime(nextAttemptAt)
+	}
+	if responseStatus.Valid {
+		code := int(responseStatus.Int64)
+		delivery.ResponseStatus = &code
+	}
+	if durationMs.Valid {
+		delivery.DurationMs = &durationMs.Int64
+	}
+	delivery.LastError = lastError.String
+	delivery.Body = json.RawMessage(body)
+	delivery.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+	delivery.DeliveredAt = parseNullTime(deliveredAt)
+	return &delivery, nil
+}
+
+// joinTopics stores webhook topics as a comma separated list
+func joinTopics(topics []models.WebhookTopic) string {
+	values := make([]string, len(topics))
+	for i, topic := range topics {
+		values[i] = string(topic)
+	}
+	return strings.Join(values, ",")
+}
+
+// splitTopics reads webhook topics stored by joinTopics
+func splitTopics(value string) []models.WebhookTopic {
+	topics := []models.WebhookTopic{}
+	for _, topic := range strings.Split(value, ",") {
+		if topic != "" {
+			topics = append(topics, models.WebhookTopic(topic))
+		}
+	}
+	return topics
+}
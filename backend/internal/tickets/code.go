@@ -0,0 +1,81 @@
+package tickets
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// codePrefix marks ticket codes and their format version, so the format can
+// change later without breaking tickets that were already handed out
+const codePrefix = "EP1"
+
+// ErrInvalidCode is returned when a ticket code is malformed or its signature doesn't match
+var ErrInvalidCode = errors.New("invalid ticket code")
+
+// secretKey signs ticket codes
+var secretKey = []byte(getTicketSecret())
+
+// getTicketSecret returns the ticket signing secret from env or default
+func getTicketSecret() string {
+	secret := os.Getenv("TICKET_SECRET")
+	if secret == "" {
+		// Default secret for development - in production, use environment variable
+		return "event_poster_ticket_secret_change_in_production"
+	}
+	return secret
+}
+
+// Code identifies the registration a ticket was issued for
+type Code struct {
+	RegistrationID int64
+	EventID        int64
+}
+
+// Sign returns the ticket code for a registration, e.g. "EP1.12.3.<signature>".
+// The code carries its own HMAC-SHA256 signature, so it can be verified at the
+// door without trusting whoever presents it.
+func Sign(registrationID, eventID int64) string {
+	payload := fmt.Sprintf("%s.%d.%d", codePrefix, registrationID, eventID)
+	return payload + "." + signature(payload)
+}
+
+// Verify checks the signature of a ticket code and returns what it identifies
+func Verify(code string) (*Code, error) {
+	code = strings.TrimSpace(code)
+	separator := strings.LastIndex(code, ".")
+	if separator < 0 {
+		return nil, ErrInvalidCode
+	}
+
+	payload, sig := code[:separator], code[separator+1:]
+	if !hmac.Equal([]byte(sig), []byte(signature(payload))) {
+		return nil, ErrInvalidCode
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 3 || parts[0] != codePrefix {
+		return nil, ErrInvalidCode
+	}
+	registrationID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCode
+	}
+	eventID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCode
+	}
+
+	return &Code{RegistrationID: registrationID, EventID: eventID}, nil
+}
+
+func signature(payload string) string {
+	mac := hmac.New(sha256.New, secretKey)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
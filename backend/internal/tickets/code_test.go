@@ -0,0 +1,74 @@
+package tickets
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	code := Sign(12, 3)
+	if !strings.HasPrefix(code, codePrefix+".12.3.") {
+		t.Errorf("Sign(12, 3) = %q, want it to start with %q", code, codePrefix+".12.3.")
+	}
+
+	ticket, err := Verify(code)
+	if err != nil {
+		t.Fatalf("Verify(%q) failed: %v", code, err)
+	}
+	if ticket.RegistrationID != 12 || ticket.EventID != 3 {
+		t.Errorf("Verify(%q) = %+v, want registration 12 of event 3", code, ticket)
+	}
+
+	// Scanners may add whitespace around the code
+	if _, err := Verify(" " + code + "\n"); err != nil {
+		t.Errorf("Verify with surrounding whitespace failed: %v", err)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	code := Sign(12, 3)
+	sig := code[strings.LastIndex(code, ".")+1:]
+
+	codes := map[string]string{
+		"empty":                 "",
+		"no signature":          codePrefix + ".12.3",
+		"other registration":    codePrefix + ".13.3." + sig,
+		"other event":           codePrefix + ".12.4." + sig,
+		"truncated signature":   code[:len(code)-1],
+		"other prefix":          "EP2.12.3." + sig,
+		"signed non-ticket":     "EP1.x.3." + signature("EP1.x.3"),
+		"signed extra field":    "EP1.12.3.4." + signature("EP1.12.3.4"),
+		"signed unknown prefix": "EP2.12.3." + signature("EP2.12.3"),
+	}
+
+	for name, code := range codes {
+		if _, err := Verify(code); err != ErrInvalidCode {
+			t.Errorf("%s: Verify(%q) error = %v, want ErrInvalidCode", name, code, err)
+		}
+	}
+}
+
+func TestSignDependsOnSecret(t *testing.T) {
+	code := Sign(12, 3)
+
+	saved := secretKey
+	secretKey = []byte("another secret")
+	defer func() { secretKey = saved }()
+
+	if _, err := Verify(code); err != ErrInvalidCode {
+		t.Errorf("Verify with another secret error = %v, want ErrInvalidCode", err)
+	}
+}
+
+func TestHashCode(t *testing.T) {
+	code := Sign(12, 3)
+	if HashCode(code) != HashCode(code) {
+		t.Error("HashCode isn't deterministic")
+	}
+	if HashCode(code) == HashCode(Sign(13, 3)) {
+		t.Error("HashCode of two tickets is the same")
+	}
+	if len(HashCode(code)) != 64 {
+		t.Errorf("HashCode length = %d, want 64 hex characters", len(HashCode(code)))
+	}
+}
@@ -0,0 +1,61 @@
+package tickets
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"os"
+)
+
+// ManifestAlgorithm names the signature scheme of check-in manifests
+const ManifestAlgorithm = "ed25519"
+
+// manifestKey signs check-in manifests. Devices verify them with its public
+// key, so checking a manifest offline doesn't need any secret of the server.
+var manifestKey = getManifestKey()
+
+// getManifestKey returns the manifest signing key from env or one derived from
+// the ticket secret. MANIFEST_SIGNING_KEY holds a base64 encoded ed25519 seed.
+func getManifestKey() ed25519.PrivateKey {
+	seed := os.Getenv("MANIFEST_SIGNING_KEY")
+	if seed == "" {
+		// Derived key for development - in production, use environment variable
+		sum := sha256.Sum256(append([]byte("manifest."), secretKey...))
+		return ed25519.NewKeyFromSeed(sum[:])
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil || len(decoded) != ed25519.SeedSize {
+		log.Fatalf("MANIFEST_SIGNING_KEY must be a base64 encoded %d byte ed25519 seed", ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(decoded)
+}
+
+// HashCode returns the SHA-256 hash of a ticket code as listed in check-in
+// manifests, so offline scanners can recognize tickets without holding them
+func HashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// SignManifest signs the encoded snapshot of an event's tickets
+func SignManifest(payload []byte) string {
+	return base64.RawURLEncoding.EncodeToString(ed25519.Sign(manifestKey, payload))
+}
+
+// VerifyManifest checks the signature of an encoded manifest against the
+// public key the server publishes
+func VerifyManifest(payload []byte, signature string) bool {
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(ManifestPublicKey(), payload, sig)
+}
+
+// ManifestPublicKey returns the key devices verify check-in manifests with
+func ManifestPublicKey() ed25519.PublicKey {
+	return manifestKey.Public().(ed25519.PublicKey)
+}
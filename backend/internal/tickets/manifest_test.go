@@ -0,0 +1,46 @@
+package tickets
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+)
+
+func TestSignManifest(t *testing.T) {
+	payload := []byte(`{"event_id":3,"tickets":[{"registration_id":12}]}`)
+	signature := SignManifest(payload)
+
+	if !VerifyManifest(payload, signature) {
+		t.Fatal("VerifyManifest rejected a manifest signed by SignManifest")
+	}
+
+	// Devices only hold the public key
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		t.Fatalf("signature %q isn't unpadded base64: %v", signature, err)
+	}
+	if !ed25519.Verify(ManifestPublicKey(), payload, sig) {
+		t.Error("the signature doesn't verify with the public key alone")
+	}
+}
+
+func TestVerifyManifestRejects(t *testing.T) {
+	payload := []byte(`{"event_id":3,"tickets":[{"registration_id":12}]}`)
+	signature := SignManifest(payload)
+
+	if VerifyManifest([]byte(`{"event_id":3,"tickets":[{"registration_id":13}]}`), signature) {
+		t.Error("VerifyManifest accepted a changed manifest")
+	}
+	if VerifyManifest(payload, signature[:len(signature)-2]) {
+		t.Error("VerifyManifest accepted a truncated signature")
+	}
+	if VerifyManifest(payload, "not base64!") {
+		t.Error("VerifyManifest accepted a malformed signature")
+	}
+
+	other := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	forged := base64.RawURLEncoding.EncodeToString(ed25519.Sign(other, payload))
+	if VerifyManifest(payload, forged) {
+		t.Error("VerifyManifest accepted a manifest signed with another key")
+	}
+}
@@ -0,0 +1,239 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapDriver traces the statements run through d. Each statement run with a
+// context that carries a span, e.g. that of a request, gets a child span named
+// after its operation; statements run outside of a trace, e.g. migrations,
+// aren't traced. Queries are recorded with their placeholders, never their
+// arguments. A query span ends once the query returns, before its rows are read.
+func WrapDriver(d driver.Driver, system string) driver.Driver {
+	return &tracedDriver{driver: d, system: system}
+}
+
+type tracedDriver struct {
+	driver driver.Driver
+	system string
+}
+
+func (d *tracedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{conn: conn, system: d.system}, nil
+}
+
+// startQuery starts the span of a statement, or returns ok false when ctx isn't traced
+func startQuery(ctx context.Context, system, query string) (context.Context, trace.Span, bool) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, nil, false
+	}
+	statement := strings.Join(strings.Fields(query), " ")
+	op := operation(statement)
+	ctx, span := Tracer().Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemKey.String(system),
+			semconv.DBOperation(op),
+			semconv.DBStatement(statement),
+		))
+	return ctx, span, true
+}
+
+// operation is the first keyword of a statement, e.g. SELECT
+func operation(statement string) string {
+	keyword, _, _ := strings.Cut(statement, " ")
+	if keyword == "" {
+		return "query"
+	}
+	return strings.ToUpper(keyword)
+}
+
+func endQuery(span trace.Span, err error) {
+	if err != driver.ErrSkip {
+		RecordError(span, err)
+	}
+	span.End()
+}
+
+type tracedConn struct {
+	conn   driver.Conn
+	system string
+}
+
+func (c *tracedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{stmt: stmt, query: query, system: c.system}, nil
+}
+
+func (c *tracedConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *tracedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	var err error
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tracedTx{tx: tx, ctx: ctx, system: c.system}, nil
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span, traced := startQuery(ctx, c.system, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	if traced {
+		endQuery(span, err)
+	}
+	return result, err
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span, traced := startQuery(ctx, c.system, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if traced {
+		endQuery(span, err)
+	}
+	return rows, err
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if validator, ok := c.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// tracedTx traces the commit of a transaction, which is where SQLite waits
+// for the disk, as part of the trace the transaction was begun in
+type tracedTx struct {
+	tx     driver.Tx
+	ctx    context.Context
+	system string
+}
+
+func (t *tracedTx) Commit() error {
+	_, span, traced := startQuery(t.ctx, t.system, "COMMIT")
+	err := t.tx.Commit()
+	if traced {
+		endQuery(span, err)
+	}
+	return err
+}
+
+func (t *tracedTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+type tracedStmt struct {
+	stmt   driver.Stmt
+	query  string
+	system string
+}
+
+func (s *tracedStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *tracedStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *tracedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.stmt.Exec(args)
+}
+
+func (s *tracedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.stmt.Query(args)
+}
+
+func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span, traced := startQuery(ctx, s.system, s.query)
+	var result driver.Result
+	var err error
+	if execer, ok := s.stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.stmt.Exec(values(args))
+	}
+	if traced {
+		endQuery(span, err)
+	}
+	return result, err
+}
+
+func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span, traced := startQuery(ctx, s.system, s.query)
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.stmt.Query(values(args))
+	}
+	if traced {
+		endQuery(span, err)
+	}
+	return rows, err
+}
+
+// values drops the names of args, for statements that only take positional arguments
+func values(args []driver.NamedValue) []driver.Value {
+	vals := make([]driver.Value, len(args))
+	for i, arg := range args {
+		vals[i] = arg.Value
+	}
+	return vals
+}
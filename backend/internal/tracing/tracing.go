@@ -0,0 +1,132 @@
+// Package tracing sets up OpenTelemetry tracing: the exporter spans are sent
+// to, the propagation of traces from and to other services, and the spans of
+// the services and the database.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies the server in traces, unless OTEL_SERVICE_NAME is set
+const ServiceName = "event-poster"
+
+// instrumentationName names the tracer of the spans created here
+const instrumentationName = "github.com/netpo4ki/event-poster"
+
+// Exporter is where spans are sent
+type Exporter string
+
+const (
+	ExporterNone   Exporter = "none"   // Spans are not recorded
+	ExporterOTLP   Exporter = "otlp"   // Spans are sent to a collector over OTLP/HTTP
+	ExporterStdout Exporter = "stdout" // Spans are written to standard output, e.g. while debugging locally
+)
+
+// Config configures tracing
+type Config struct {
+	Exporter Exporter
+}
+
+// DefaultConfig doesn't record spans
+var DefaultConfig = Config{
+	Exporter: ExporterNone,
+}
+
+// ConfigFromEnv reads OTEL_TRACES_EXPORTER (otlp, stdout or none). The OTLP
+// exporter is configured with the standard OTEL_EXPORTER_OTLP_* variables,
+// e.g. OTEL_EXPORTER_OTLP_ENDPOINT, and sampling with OTEL_TRACES_SAMPLER.
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig
+
+	switch exporter := Exporter(strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER"))); exporter {
+	case "":
+	case ExporterNone, ExporterOTLP, ExporterStdout:
+		config.Exporter = exporter
+	default:
+		return config, fmt.Errorf("invalid OTEL_TRACES_EXPORTER %q, expected otlp, stdout or none", exporter)
+	}
+
+	return config, nil
+}
+
+// Setup installs the global tracer provider and propagator. Call the returned
+// function on shutdown to send the spans still buffered.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	// Trace context is taken from and passed on to other services even when
+	// spans aren't recorded here
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	// Failing to send spans, e.g. while the collector is down, doesn't fail requests
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Error("Failed to export traces", "error", err)
+	}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.Exporter {
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New()
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s exporter: %w", config.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName())))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func serviceName() string {
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		return name
+	}
+	return ServiceName
+}
+
+// Tracer creates the spans of the server. It is looked up on every use so
+// spans go to the provider installed by Setup.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a span named after the service method it times, e.g.
+// EventService.CreateEvent. End it when the method returns.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// RecordError marks a span failed with err, if there is one
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
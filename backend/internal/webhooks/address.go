@@ -0,0 +1,63 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+// ErrAddressNotAllowed is returned when a webhook would reach the server
+// itself or a private network instead of a receiver on the internet
+var ErrAddressNotAllowed = errors.New("webhook receivers must have a public address")
+
+// IsAllowedIP reports whether webhook requests may be sent to the IP. Loopback,
+// link-local, private, unspecified and multicast addresses are refused so
+// webhooks can't be used to probe the server or its network.
+func IsAllowedIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() &&
+		!ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsPrivate() &&
+		!ip.IsUnspecified()
+}
+
+// CheckHost refuses hosts that obviously point at a forbidden address: IP
+// literals outside the allowed ranges and localhost. Names are only resolved
+// when sending, where the dialer checks the address actually connected to.
+func CheckHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrAddressNotAllowed
+	}
+	if ip, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil && !IsAllowedIP(ip) {
+		return ErrAddressNotAllowed
+	}
+	return nil
+}
+
+// checkDialAddress is the Control function of the webhook dialer. It runs for
+// the resolved address right before connecting, so a name that resolves to a
+// forbidden address, even after passing validation, is never connected to.
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("webhook receiver address %q: %w", address, err)
+	}
+	if !IsAllowedIP(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrAddressNotAllowed, addrPort.Addr())
+	}
+	return nil
+}
+
+// dialer connects to webhook receivers, refusing forbidden addresses
+var dialer = &net.Dialer{
+	Timeout: Timeout,
+	Control: checkDialAddress,
+}
@@ -0,0 +1,91 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestIsAllowedIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"127.8.8.8", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsAllowedIP(netip.MustParseAddr(tt.ip)); got != tt.allowed {
+			t.Errorf("IsAllowedIP(%s) = %v, want %v", tt.ip, got, tt.allowed)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		allowed bool
+	}{
+		{"hooks.example.com", true},
+		{"93.184.216.34", true},
+		{"localhost", false},
+		{"LOCALHOST.", false},
+		{"api.localhost", false},
+		{"127.0.0.1", false},
+		{"[::1]", false},
+		{"::1", false},
+		{"169.254.169.254", false},
+		{"10.0.0.5", false},
+	}
+
+	for _, tt := range tests {
+		err := CheckHost(tt.host)
+		if (err == nil) != tt.allowed {
+			t.Errorf("CheckHost(%q) = %v, want allowed %v", tt.host, err, tt.allowed)
+		}
+	}
+}
+
+func TestSendRefusesLoopback(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	result := Send(context.Background(), &Request{URL: server.URL, Secret: "whsec_test", Body: []byte("{}")})
+	if !errors.Is(result.Err, ErrAddressNotAllowed) {
+		t.Errorf("Send to %s error = %v, want ErrAddressNotAllowed", server.URL, result.Err)
+	}
+	if called {
+		t.Error("the loopback receiver was reached")
+	}
+}
+
+func TestCheckDialAddress(t *testing.T) {
+	if err := checkDialAddress("tcp", net.JoinHostPort("93.184.216.34", "443"), nil); err != nil {
+		t.Errorf("checkDialAddress refused a public address: %v", err)
+	}
+	if err := checkDialAddress("tcp", net.JoinHostPort("::1", "80"), nil); !errors.Is(err, ErrAddressNotAllowed) {
+		t.Errorf("checkDialAddress(::1) = %v, want ErrAddressNotAllowed", err)
+	}
+}
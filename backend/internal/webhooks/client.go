@@ -0,0 +1,77 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timeout caps how long a receiver may take to answer
+const Timeout = 10 * time.Second
+
+// maxResponseExcerpt is how much of an error response is kept for the delivery log
+const maxResponseExcerpt = 512
+
+// client sends webhook requests; redirects aren't followed so a receiver can't
+// point deliveries elsewhere. It connects directly, never through a proxy, so
+// the dialer sees and checks the address of the receiver itself.
+var client = &http.Client{
+	Timeout: Timeout,
+	Transport: &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: Timeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Request is a webhook delivery to send
+type Request struct {
+	URL        string
+	Secret     string
+	DeliveryID int64
+	Topic      string
+	Body       []byte // JSON
+}
+
+// Result is the outcome of sending a webhook request
+type Result struct {
+	StatusCode int // 0 when no response was received
+	Duration   time.Duration
+	Err        error // nil only for 2xx responses
+}
+
+// Send posts a signed webhook request and reports how the receiver answered
+func Send(ctx context.Context, r *Request) Result {
+	start := time.Now()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return Result{Err: err}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "event-poster-webhooks/1.0")
+	httpReq.Header.Set("X-Webhook-ID", strconv.FormatInt(r.DeliveryID, 10))
+	httpReq.Header.Set("X-Webhook-Topic", r.Topic)
+	httpReq.Header.Set(SignatureHeader, Sign(r.Secret, start, r.Body))
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return Result{Duration: time.Since(start), Err: err}
+	}
+	defer resp.Body.Close()
+
+	result := Result{StatusCode: resp.StatusCode, Duration: time.Since(start)}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseExcerpt))
+		result.Err = fmt.Errorf("receiver answered %s: %s", resp.Status, strings.TrimSpace(string(excerpt)))
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package webhooks
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(`{"topic":"event.created"}`)
+	sentAt := time.Unix(1767225600, 0)
+
+	header := Sign(secret, sentAt, body)
+	if !strings.HasPrefix(header, "t=1767225600,v1=") {
+		t.Errorf("Sign = %q, want it to start with the timestamp", header)
+	}
+	if !Verify(secret, header, body, 5*time.Minute, sentAt.Add(time.Minute)) {
+		t.Error("Verify rejected a fresh signature")
+	}
+}
+
+func TestSignKnownValue(t *testing.T) {
+	// HMAC-SHA256 of "1700000000.{}" under "secret", computed independently
+	header := Sign("secret", time.Unix(1700000000, 0), []byte("{}"))
+	want := "t=1700000000,v1=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163"
+	if header != want {
+		t.Errorf("Sign = %q, want %q", header, want)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	body := []byte(`{"topic":"event.created"}`)
+	sentAt := time.Unix(1767225600, 0)
+	header := Sign("whsec_a", sentAt, body)
+	tolerance := 5 * time.Minute
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   string
+		now    time.Time
+	}{
+		{"other secret", "whsec_b", header, string(body), sentAt},
+		{"changed body", "whsec_a", header, `{"topic":"event.deleted"}`, sentAt},
+		{"replayed late", "whsec_a", header, string(body), sentAt.Add(tolerance + time.Second)},
+		{"from the future", "whsec_a", header, string(body), sentAt.Add(-tolerance - time.Second)},
+		{"changed timestamp", "whsec_a", strings.Replace(header, "t=1767225600", "t=1767225601", 1), string(body), sentAt},
+		{"no signature", "whsec_a", "t=1767225600", string(body), sentAt},
+		{"no timestamp", "whsec_a", header[strings.Index(header, ",")+1:], string(body), sentAt},
+		{"empty", "whsec_a", "", string(body), sentAt},
+	}
+
+	for _, tt := range tests {
+		if Verify(tt.secret, tt.header, []byte(tt.body), tolerance, tt.now) {
+			t.Errorf("%s: Verify accepted %q", tt.name, tt.header)
+		}
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(a, secretPrefix) || len(a) != len(secretPrefix)+64 {
+		t.Errorf("NewSecret = %q, want %q and 32 random bytes in hex", a, secretPrefix)
+	}
+	if a == b {
+		t.Error("NewSecret returned the same secret twice")
+	}
+}